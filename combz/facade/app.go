@@ -331,6 +331,7 @@ func InitApp(opts ...ConfigOption) (tb *ToolFacade, err error) {
 	if tb.configs.health != nil {
 		if tb.healthChecker, err = http_health.NewHttpHealthCheckServer(
 			http_health.HealthOptionWithDisableLog(tb.configs.health.DisableLog),
+			http_health.HealthOptionWithEnableLogLevel(tb.configs.health.EnableLogLevel),
 			http_health.HealthOptionWithPprof(tb.configs.health.Pprof),
 			http_health.HealthOptionWithAddr(tb.configs.health.Addr),
 			http_health.HealthOptionWithPort(tb.configs.health.Port)); err != nil {
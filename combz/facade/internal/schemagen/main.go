@@ -0,0 +1,24 @@
+// 生成 facade.Config 的 JSON Schema, 在 combz/facade 目录下执行 go generate
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/senyu-up/toolbox/combz/facade"
+	"github.com/senyu-up/toolbox/tool/config/schema"
+)
+
+func main() {
+	output := flag.String("o", "config.schema.json", "output file")
+	flag.Parse()
+
+	out, err := schema.Marshal(&facade.Config{}, schema.OptWithTitle("toolbox facade config"))
+	if err != nil {
+		log.Fatalf("generate schema err %v", err)
+	}
+	if err = os.WriteFile(*output, out, 0644); err != nil {
+		log.Fatalf("write schema err %v", err)
+	}
+}
@@ -0,0 +1,5 @@
+package facade
+
+// config.schema.json 为 Config 对应的 JSON Schema, 修改 Config 或 tool/config 中的配置结构体后需要重新生成
+// 在 config.yaml 第一行加上 # yaml-language-server: $schema=<config.schema.json 路径> 即可在编辑器中补全、校验
+//go:generate go run ./internal/schemagen -o config.schema.json
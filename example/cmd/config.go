@@ -0,0 +1,17 @@
+/*
+Copyright © 2023 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"github.com/senyu-up/toolbox/example/config"
+	"github.com/senyu-up/toolbox/example/global"
+	"github.com/senyu-up/toolbox/tool/config/confcmd"
+)
+
+func init() {
+	// 配置工具命令, 例如：查看实际生效的配置 config dump -c ./ ; 加密配置值 config encrypt --key-file ./config.key "password"
+	rootCmd.AddCommand(confcmd.NewConfigCmd(
+		confcmd.CmdOptWithConfigPath(&global.ConfigPath),
+		confcmd.CmdOptWithSchema(&config.Config{})))
+}
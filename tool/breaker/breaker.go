@@ -0,0 +1,339 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/senyu-up/toolbox/tool/config"
+	"github.com/senyu-up/toolbox/tool/logger"
+)
+
+// 未配置时的默认值, 与 hystrix 一致
+const (
+	DefaultTimeout                = time.Second
+	DefaultMaxConcurrentRequests  = 10
+	DefaultRequestVolumeThreshold = 20
+	DefaultErrorPercentThreshold  = 50
+	DefaultSleepWindow            = 5 * time.Second
+	DefaultWindowBuckets          = 10 // 统计窗口的桶数, 每个桶 1 秒
+)
+
+var (
+	ErrOpen           = errors.New("circuit breaker is open")
+	ErrMaxConcurrency = errors.New("circuit breaker max concurrency")
+	ErrTimeout        = errors.New("circuit breaker timeout")
+)
+
+// errIgnored 只释放并发数, 不计入统计, 结果由异步回调通过 report 计入
+var errIgnored = errors.New("circuit breaker result ignored")
+
+// softFailure 计为失败, 但 Do 不返回错误, 如 5xx 响应仍需返回给调用方
+type softFailure struct {
+	err error
+}
+
+func (s softFailure) Error() string {
+	return s.err.Error()
+}
+
+// State 熔断器状态
+type State int
+
+const (
+	StateClosed   State = iota // 正常放行
+	StateOpen                  // 熔断, 直接返回 ErrOpen
+	StateHalfOpen              // 熔断 SleepWindow 之后, 放行 1 个探测请求
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("state(%d)", int(s))
+}
+
+// Stats 当前统计窗口内的数据
+type Stats struct {
+	State        State
+	Requests     int64
+	Failures     int64
+	ErrorPercent int
+}
+
+// Breaker 一个命令(name)的熔断器
+// 统计窗口内请求数达到 RequestVolumeThreshold 且错误率达到 ErrorPercentThreshold 时熔断,
+// 熔断 SleepWindow 之后放行 1 个探测请求, 成功则恢复, 失败则继续熔断
+type Breaker struct {
+	name            string
+	timeout         time.Duration
+	volumeThreshold int64
+	errorPercent    int64
+	sleepWindow     time.Duration
+	isFailure       func(err error) bool
+	onStateChange   func(name string, from, to State)
+	now             func() time.Time
+
+	tickets chan struct{} // 并发数
+
+	lock     sync.Mutex
+	state    State
+	openedAt time.Time
+	probing  bool // 半开状态下探测请求是否已放行
+	window   *window
+}
+
+// New 创建熔断器, conf 中为 0 的配置使用默认值
+func New(name string, conf config.BreakerConf, opts ...Option) *Breaker {
+	b := &Breaker{
+		name:            name,
+		timeout:         conf.Timeout,
+		volumeThreshold: int64(conf.RequestVolumeThreshold),
+		errorPercent:    int64(conf.ErrorPercentThreshold),
+		sleepWindow:     conf.SleepWindow,
+		isFailure:       func(err error) bool { return err != nil },
+		now:             time.Now,
+		window:          newWindow(DefaultWindowBuckets),
+	}
+	if b.timeout <= 0 {
+		b.timeout = DefaultTimeout
+	}
+	if b.volumeThreshold <= 0 {
+		b.volumeThreshold = DefaultRequestVolumeThreshold
+	}
+	if b.errorPercent <= 0 {
+		b.errorPercent = DefaultErrorPercentThreshold
+	}
+	if b.sleepWindow <= 0 {
+		b.sleepWindow = DefaultSleepWindow
+	}
+	maxConcurrent := conf.MaxConcurrentRequests
+	if maxConcurrent <= 0 {
+		maxConcurrent = DefaultMaxConcurrentRequests
+	}
+	b.tickets = make(chan struct{}, maxConcurrent)
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// Do 通过熔断器执行 run, run 的 ctx 带有 Timeout 超时
+// run 返回错误、超时、panic 计为失败; 熔断、超过并发数、失败时执行 fallback, fallback 为 nil 时返回对应的错误
+func (b *Breaker) Do(ctx context.Context, run func(ctx context.Context) error, fallback func(ctx context.Context, err error) error) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	done, err := b.Allow()
+	if err != nil {
+		return b.fallback(ctx, fallback, err)
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, b.timeout)
+	defer cancel()
+	errC := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				errC <- fmt.Errorf("circuit breaker %s panic: %v", b.name, r)
+			}
+		}()
+		errC <- run(runCtx)
+	}()
+
+	select {
+	case err = <-errC:
+		done(err)
+	case <-runCtx.Done():
+		if ctx.Err() != nil {
+			// 调用方取消, 不计入统计
+			err = ctx.Err()
+			done(errIgnored)
+		} else {
+			err = ErrTimeout
+			done(err)
+		}
+	}
+	if _, ok := err.(softFailure); ok || err == nil {
+		return nil
+	}
+	return b.fallback(ctx, fallback, err)
+}
+
+func (b *Breaker) fallback(ctx context.Context, fallback func(ctx context.Context, err error) error, err error) error {
+	if fallback == nil {
+		return err
+	}
+	return fallback(ctx, err)
+}
+
+// Allow 判断是否放行, 放行时返回的 done 需要在执行结束后以执行结果调用, 用于无法通过 Do 包装的场景, 如 gorm callback
+func (b *Breaker) Allow() (done func(err error), err error) {
+	select {
+	case b.tickets <- struct{}{}:
+	default:
+		return nil, ErrMaxConcurrency
+	}
+	if err = b.allow(); err != nil {
+		<-b.tickets
+		return nil, err
+	}
+	var once sync.Once
+	return func(err error) {
+		once.Do(func() {
+			<-b.tickets
+			b.report(err)
+		})
+	}, nil
+}
+
+func (b *Breaker) allow() error {
+	b.lock.Lock()
+	from := b.state
+	switch b.state {
+	case StateOpen:
+		if b.now().Sub(b.openedAt) < b.sleepWindow {
+			b.lock.Unlock()
+			return ErrOpen
+		}
+		b.state = StateHalfOpen
+		b.probing = true
+	case StateHalfOpen:
+		if b.probing {
+			b.lock.Unlock()
+			return ErrOpen
+		}
+		b.probing = true
+	}
+	to := b.state
+	b.lock.Unlock()
+	b.changed(from, to)
+	return nil
+}
+
+// report 计入执行结果
+func (b *Breaker) report(err error) {
+	b.lock.Lock()
+	from := b.state
+	if err == errIgnored {
+		b.probing = false
+		b.lock.Unlock()
+		return
+	}
+	if _, ok := err.(softFailure); !ok && err != nil && !b.isFailure(err) {
+		err = nil
+	}
+	now := b.now()
+	switch b.state {
+	case StateHalfOpen:
+		b.probing = false
+		if err != nil {
+			b.state, b.openedAt = StateOpen, now
+		} else {
+			b.state = StateClosed
+			b.window.reset()
+		}
+	case StateClosed:
+		b.window.add(now, err != nil)
+		requests, failures := b.window.sum(now)
+		if requests >= b.volumeThreshold && failures*100 >= b.errorPercent*requests {
+			b.state, b.openedAt = StateOpen, now
+		}
+	}
+	to := b.state
+	b.lock.Unlock()
+	b.changed(from, to)
+}
+
+func (b *Breaker) changed(from, to State) {
+	if from == to {
+		return
+	}
+	logger.GetLogger().Warnw(context.Background(), "circuit breaker state changed", "name", b.name, "from", from.String(), "to", to.String())
+	if b.onStateChange != nil {
+		b.onStateChange(b.name, from, to)
+	}
+}
+
+// State 当前状态, 熔断超过 SleepWindow 后, 下一个请求到来时才变为半开
+func (b *Breaker) State() State {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.state
+}
+
+func (b *Breaker) Stats() Stats {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	s := Stats{State: b.state}
+	s.Requests, s.Failures = b.window.sum(b.now())
+	if s.Requests > 0 {
+		s.ErrorPercent = int(s.Failures * 100 / s.Requests)
+	}
+	return s
+}
+
+// Reset 恢复为正常状态并清空统计
+func (b *Breaker) Reset() {
+	b.lock.Lock()
+	from := b.state
+	b.state, b.probing = StateClosed, false
+	b.window.reset()
+	b.lock.Unlock()
+	b.changed(from, StateClosed)
+}
+
+// window 按秒分桶的滚动统计窗口
+type window struct {
+	buckets []bucket
+}
+
+type bucket struct {
+	sec      int64
+	requests int64
+	failures int64
+}
+
+func newWindow(size int) *window {
+	return &window{buckets: make([]bucket, size)}
+}
+
+func (w *window) add(now time.Time, failed bool) {
+	sec := now.Unix()
+	b := &w.buckets[sec%int64(len(w.buckets))]
+	if b.sec != sec {
+		*b = bucket{sec: sec}
+	}
+	b.requests++
+	if failed {
+		b.failures++
+	}
+}
+
+func (w *window) sum(now time.Time) (requests, failures int64) {
+	sec := now.Unix()
+	for _, b := range w.buckets {
+		if sec-b.sec < int64(len(w.buckets)) {
+			requests += b.requests
+			failures += b.failures
+		}
+	}
+	return
+}
+
+func (w *window) reset() {
+	for i := range w.buckets {
+		w.buckets[i] = bucket{}
+	}
+}
@@ -0,0 +1,188 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/senyu-up/toolbox/tool/config"
+)
+
+type clock struct {
+	sync.Mutex
+	t time.Time
+}
+
+func (c *clock) now() time.Time {
+	c.Lock()
+	defer c.Unlock()
+	return c.t
+}
+
+func (c *clock) add(d time.Duration) {
+	c.Lock()
+	c.t = c.t.Add(d)
+	c.Unlock()
+}
+
+func newTestBreaker(conf config.BreakerConf, opts ...Option) (*Breaker, *clock) {
+	c := &clock{t: time.Unix(1700000000, 0)}
+	b := New("test", conf, opts...)
+	b.now = c.now
+	return b, c
+}
+
+var errFail = errors.New("fail")
+
+func succeed(ctx context.Context) error { return nil }
+
+func fail(ctx context.Context) error { return errFail }
+
+func TestBreaker_State(t *testing.T) {
+	var changes []string
+	b, c := newTestBreaker(config.BreakerConf{RequestVolumeThreshold: 4, ErrorPercentThreshold: 50, SleepWindow: time.Second},
+		OptWithOnStateChange(func(name string, from, to State) {
+			changes = append(changes, from.String()+"->"+to.String())
+		}))
+	ctx := context.TODO()
+
+	// 请求数未达到阈值时不熔断
+	b.Do(ctx, fail, nil)
+	b.Do(ctx, fail, nil)
+	b.Do(ctx, fail, nil)
+	if b.State() != StateClosed {
+		t.Fatalf("state = %s, want closed below volume threshold", b.State())
+	}
+	b.Do(ctx, succeed, nil)
+	if b.State() != StateOpen {
+		t.Fatalf("state = %s, want open", b.State())
+	}
+	if err := b.Do(ctx, succeed, nil); err != ErrOpen {
+		t.Fatalf("Do() error = %v, want ErrOpen", err)
+	}
+
+	// SleepWindow 之后只放行 1 个探测请求, 失败则继续熔断
+	c.add(time.Second)
+	done, err := b.Allow()
+	if err != nil || b.State() != StateHalfOpen {
+		t.Fatalf("Allow() error = %v, state = %s", err, b.State())
+	}
+	if _, err = b.Allow(); err != ErrOpen {
+		t.Fatalf("second probe error = %v, want ErrOpen", err)
+	}
+	done(errFail)
+	if b.State() != StateOpen {
+		t.Fatalf("state = %s, want open after failed probe", b.State())
+	}
+
+	// 探测成功则恢复, 统计清空
+	c.add(time.Second)
+	if err = b.Do(ctx, succeed, nil); err != nil || b.State() != StateClosed {
+		t.Fatalf("Do() error = %v, state = %s", err, b.State())
+	}
+	if s := b.Stats(); s.Requests != 0 {
+		t.Errorf("stats = %+v, should be reset", s)
+	}
+	want := []string{"closed->open", "open->half-open", "half-open->open", "open->half-open", "half-open->closed"}
+	if len(changes) != len(want) {
+		t.Fatalf("changes = %v, want %v", changes, want)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Errorf("changes = %v, want %v", changes, want)
+		}
+	}
+}
+
+func TestBreaker_Window(t *testing.T) {
+	b, c := newTestBreaker(config.BreakerConf{RequestVolumeThreshold: 3, ErrorPercentThreshold: 50}, OptWithWindow(2*time.Second))
+	ctx := context.TODO()
+
+	b.Do(ctx, fail, nil)
+	b.Do(ctx, fail, nil)
+	// 超出窗口的失败不再统计
+	c.add(2 * time.Second)
+	b.Do(ctx, fail, nil)
+	if s := b.Stats(); s.State != StateClosed || s.Requests != 1 || s.Failures != 1 || s.ErrorPercent != 100 {
+		t.Fatalf("stats = %+v", s)
+	}
+	c.add(time.Second)
+	b.Do(ctx, succeed, nil)
+	b.Do(ctx, fail, nil)
+	if s := b.Stats(); s.State != StateOpen || s.Requests != 3 || s.Failures != 2 {
+		t.Errorf("stats = %+v", s)
+	}
+}
+
+func TestBreaker_Do(t *testing.T) {
+	b, _ := newTestBreaker(config.BreakerConf{Timeout: 20 * time.Millisecond, MaxConcurrentRequests: 1},
+		OptWithIsFailure(func(err error) bool { return err != errFail }))
+	fallback := func(ctx context.Context, err error) error {
+		return errors.New("fallback: " + err.Error())
+	}
+
+	if err := b.Do(context.TODO(), fail, nil); err != errFail {
+		t.Errorf("Do() error = %v, want errFail", err)
+	}
+	if s := b.Stats(); s.Requests != 1 || s.Failures != 0 {
+		t.Errorf("stats = %+v, errFail should not be a failure", s)
+	}
+
+	err := b.Do(context.TODO(), func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, fallback)
+	if err == nil || err.Error() != "fallback: "+ErrTimeout.Error() {
+		t.Errorf("Do() error = %v, want timeout fallback", err)
+	}
+
+	err = b.Do(context.TODO(), func(ctx context.Context) error {
+		panic("boom")
+	}, nil)
+	if err == nil || b.Stats().Failures != 2 {
+		t.Errorf("Do() error = %v, stats = %+v", err, b.Stats())
+	}
+
+	// 调用方取消不计入统计
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	if err = b.Do(ctx, func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	}, nil); err != context.Canceled || b.Stats().Requests != 3 {
+		t.Errorf("Do() error = %v, stats = %+v", err, b.Stats())
+	}
+
+	// 超过并发数
+	done, err := b.Allow()
+	if err != nil {
+		t.Fatalf("Allow() error = %v", err)
+	}
+	if err = b.Do(context.TODO(), succeed, fallback); err == nil || err.Error() != "fallback: "+ErrMaxConcurrency.Error() {
+		t.Errorf("Do() error = %v, want max concurrency fallback", err)
+	}
+	done(nil)
+	done(errFail) // 重复调用无效
+	if s := b.Stats(); s.Requests != 4 || s.Failures != 2 {
+		t.Errorf("stats = %+v", s)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	b := Configure("registry", config.BreakerConf{Timeout: time.Minute})
+	if Get("registry") != b || b.timeout != time.Minute {
+		t.Errorf("Get() should return the configured breaker")
+	}
+	d := Get("registry_default")
+	if d.timeout != DefaultTimeout || cap(d.tickets) != DefaultMaxConcurrentRequests || d.sleepWindow != DefaultSleepWindow {
+		t.Errorf("default breaker = %+v", d)
+	}
+	if err := Do(context.TODO(), "registry", fail, nil); err != errFail {
+		t.Errorf("Do() error = %v", err)
+	}
+	if s := States()["registry"]; s.Failures != 1 {
+		t.Errorf("States() = %+v", States())
+	}
+}
@@ -0,0 +1,96 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+const gormDoneKey = "breaker:done"
+
+// GormPlugin 通过 gorm callback 接入熔断器, 熔断时直接返回 ErrOpen, 不访问数据库
+// 语句的 context 带有熔断器的 Timeout 超时, gorm.ErrRecordNotFound 不计为失败
+//
+//	db.Use(breaker.NewGormPlugin("mysql-main"))
+type GormPlugin struct {
+	b *Breaker
+}
+
+func NewGormPlugin(name string) *GormPlugin {
+	return &GormPlugin{b: Get(name)}
+}
+
+func (p *GormPlugin) Name() string {
+	return "breaker:" + p.b.name
+}
+
+func (p *GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	errs := []error{
+		cb.Create().Before("*").Register("breaker:before_create", p.before),
+		cb.Create().After("*").Register("breaker:after_create", p.after),
+		cb.Query().Before("*").Register("breaker:before_query", p.before),
+		cb.Query().After("*").Register("breaker:after_query", p.after),
+		cb.Update().Before("*").Register("breaker:before_update", p.before),
+		cb.Update().After("*").Register("breaker:after_update", p.after),
+		cb.Delete().Before("*").Register("breaker:before_delete", p.before),
+		cb.Delete().After("*").Register("breaker:after_delete", p.after),
+		cb.Row().Before("*").Register("breaker:before_row", p.beforeRow),
+		cb.Row().After("*").Register("breaker:after_row", p.after),
+		cb.Raw().Before("*").Register("breaker:before_raw", p.before),
+		cb.Raw().After("*").Register("breaker:after_raw", p.after),
+	}
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *GormPlugin) before(db *gorm.DB) {
+	p.allow(db, true)
+}
+
+// beforeRow Row, Rows 返回后调用方才读取结果, context 不能在 after 中取消
+func (p *GormPlugin) beforeRow(db *gorm.DB) {
+	p.allow(db, false)
+}
+
+func (p *GormPlugin) allow(db *gorm.DB, timeout bool) {
+	if db.Error != nil {
+		return
+	}
+	done, err := p.b.Allow()
+	if err != nil {
+		db.AddError(err)
+		return
+	}
+	if !timeout {
+		db.InstanceSet(gormDoneKey, done)
+		return
+	}
+	ctx := db.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(ctx, p.b.timeout)
+	db.Statement.Context = ctx
+	db.InstanceSet(gormDoneKey, func(err error) {
+		cancel()
+		done(err)
+	})
+}
+
+func (p *GormPlugin) after(db *gorm.DB) {
+	v, ok := db.InstanceGet(gormDoneKey)
+	if !ok {
+		return
+	}
+	err := db.Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+	}
+	v.(func(error))(err)
+}
@@ -0,0 +1,70 @@
+package breaker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/senyu-up/toolbox/tool/config"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// failPool 所有语句都返回 err 的连接池
+type failPool struct {
+	err   error
+	calls int
+}
+
+func (p *failPool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	p.calls++
+	return nil, p.err
+}
+
+func (p *failPool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	p.calls++
+	return nil, p.err
+}
+
+func (p *failPool) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	p.calls++
+	return nil, p.err
+}
+
+func (p *failPool) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	p.calls++
+	return nil
+}
+
+type gormUser struct {
+	Id   int
+	Name string
+}
+
+func TestGormPlugin(t *testing.T) {
+	pool := &failPool{err: errors.New("connection refused")}
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: pool, SkipInitializeWithVersion: true}), &gorm.Config{SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+	Configure("gorm_test", config.BreakerConf{RequestVolumeThreshold: 2})
+	if err = db.Use(NewGormPlugin("gorm_test")); err != nil {
+		t.Fatalf("Use() error = %v", err)
+	}
+
+	var users []gormUser
+	if err = db.Find(&users).Error; err != pool.err {
+		t.Fatalf("Find() error = %v", err)
+	}
+	if err = db.Create(&gormUser{Name: "xh"}).Error; err != pool.err {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if s := Get("gorm_test").Stats(); s.State != StateOpen || s.Failures != 2 {
+		t.Fatalf("stats = %+v", s)
+	}
+	// 熔断时不访问数据库
+	if err = db.Where("id = ?", 1).Delete(&gormUser{}).Error; !errors.Is(err, ErrOpen) || pool.calls != 2 {
+		t.Errorf("Delete() error = %v, calls = %d", err, pool.calls)
+	}
+}
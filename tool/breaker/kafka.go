@@ -0,0 +1,173 @@
+package breaker
+
+import (
+	"context"
+
+	"github.com/IBM/sarama"
+	segkafka "github.com/segmentio/kafka-go"
+	"github.com/senyu-up/toolbox/tool/mq/aws_kafka"
+	"github.com/senyu-up/toolbox/tool/mq/kafka"
+)
+
+// KafkaProducer 通过熔断器发送 kafka 消息
+// 同步发送计入发送结果; 异步发送在熔断时返回 ErrOpen, 结果由 producer 的成功、失败回调计入,
+// 需要通过 KafkaProducer.HandleError, HandleSucceed 设置回调, 包装前在 producer 上设置的回调会被替换
+type KafkaProducer struct {
+	*kafka.Producer
+	b *Breaker
+	e kafka.HandleErrorFunc
+	s kafka.HandleSucceedFunc
+}
+
+type kafkaResult struct {
+	partition int32
+	offset    int64
+}
+
+func WrapKafkaProducer(name string, p *kafka.Producer) *KafkaProducer {
+	w := &KafkaProducer{Producer: p, b: Get(name)}
+	p.HandleError(func(err error) {
+		w.b.report(err)
+		if w.e != nil {
+			w.e(err)
+		}
+	})
+	p.HandleSucceed(func(msg *sarama.ProducerMessage) {
+		w.b.report(nil)
+		if w.s != nil {
+			w.s(msg)
+		}
+	})
+	return w
+}
+
+func (p *KafkaProducer) HandleError(e kafka.HandleErrorFunc) {
+	p.e = e
+}
+
+func (p *KafkaProducer) HandleSucceed(s kafka.HandleSucceedFunc) {
+	p.s = s
+}
+
+func (p *KafkaProducer) PushSyncRaw(ctx context.Context, msg *sarama.ProducerMessage) (partition int32, offset int64, err error) {
+	res := make(chan kafkaResult, 1)
+	err = p.b.Do(ctx, func(ctx context.Context) error {
+		partition, offset, err := p.Producer.PushSyncRaw(ctx, msg)
+		res <- kafkaResult{partition: partition, offset: offset}
+		return err
+	}, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	r := <-res
+	return r.partition, r.offset, nil
+}
+
+func (p *KafkaProducer) PushSyncRawMsgs(ctx context.Context, msgs []*sarama.ProducerMessage) error {
+	return p.b.Do(ctx, func(ctx context.Context) error {
+		return p.Producer.PushSyncRawMsgs(ctx, msgs)
+	}, nil)
+}
+
+func (p *KafkaProducer) PushSync(ctx context.Context, topic string, event interface{}) (partition int32, offset int64, err error) {
+	res := make(chan kafkaResult, 1)
+	err = p.b.Do(ctx, func(ctx context.Context) error {
+		partition, offset, err := p.Producer.PushSync(ctx, topic, event)
+		res <- kafkaResult{partition: partition, offset: offset}
+		return err
+	}, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	r := <-res
+	return r.partition, r.offset, nil
+}
+
+// PushAsyncRaw 熔断时返回 ErrOpen, 不再写入 producer
+func (p *KafkaProducer) PushAsyncRaw(ctx context.Context, msg *sarama.ProducerMessage) error {
+	done, err := p.b.Allow()
+	if err != nil {
+		return err
+	}
+	defer done(errIgnored)
+	p.Producer.PushAsyncRaw(ctx, msg)
+	return nil
+}
+
+func (p *KafkaProducer) PushAsync(ctx context.Context, topic string, event interface{}) error {
+	done, err := p.b.Allow()
+	if err != nil {
+		return err
+	}
+	defer done(errIgnored)
+	return p.Producer.PushAsync(ctx, topic, event)
+}
+
+// AwsKafkaProducer 通过熔断器发送 aws kafka 消息, producer 为异步模式时同 KafkaProducer 的异步发送
+type AwsKafkaProducer struct {
+	*aws_kafka.Producer
+	b *Breaker
+	e aws_kafka.HandleErrorFunc
+	s aws_kafka.HandleSucceedFunc
+}
+
+func WrapAwsKafkaProducer(name string, p *aws_kafka.Producer) *AwsKafkaProducer {
+	w := &AwsKafkaProducer{Producer: p, b: Get(name)}
+	// 同步模式下结果由 PushMsgs 的返回值计入, 回调中不再重复计入
+	p.HandleError(func(err error) {
+		if w.async() {
+			w.b.report(err)
+		}
+		if w.e != nil {
+			w.e(err)
+		}
+	})
+	p.HandleSucceed(func(msg segkafka.Message) {
+		if w.async() {
+			w.b.report(nil)
+		}
+		if w.s != nil {
+			w.s(msg)
+		}
+	})
+	return w
+}
+
+func (p *AwsKafkaProducer) async() bool {
+	return p.Producer.Producer != nil && p.Producer.Producer.Async
+}
+
+func (p *AwsKafkaProducer) HandleError(e aws_kafka.HandleErrorFunc) {
+	p.e = e
+}
+
+func (p *AwsKafkaProducer) HandleSucceed(s aws_kafka.HandleSucceedFunc) {
+	p.s = s
+}
+
+func (p *AwsKafkaProducer) PushMsgs(ctx context.Context, msgs []segkafka.Message) error {
+	return p.push(ctx, func(ctx context.Context) error {
+		return p.Producer.PushMsgs(ctx, msgs)
+	})
+}
+
+func (p *AwsKafkaProducer) PushObj(ctx context.Context, topic string, event interface{}) error {
+	return p.push(ctx, func(ctx context.Context) error {
+		return p.Producer.PushObj(ctx, topic, event)
+	})
+}
+
+func (p *AwsKafkaProducer) push(ctx context.Context, send func(ctx context.Context) error) error {
+	if !p.async() {
+		return p.b.Do(ctx, send, nil)
+	}
+	done, err := p.b.Allow()
+	if err != nil {
+		return err
+	}
+	defer done(errIgnored)
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return send(ctx)
+}
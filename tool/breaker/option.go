@@ -0,0 +1,30 @@
+package breaker
+
+import "time"
+
+type Option func(*Breaker)
+
+// OptWithWindow 统计窗口的时长, 按秒分桶, 默认 10 秒
+func OptWithWindow(d time.Duration) Option {
+	return func(b *Breaker) {
+		if sec := int(d / time.Second); sec > 0 {
+			b.window = newWindow(sec)
+		}
+	}
+}
+
+// OptWithIsFailure 判断错误是否计为失败, 如参数错误之类的业务错误不应触发熔断
+func OptWithIsFailure(f func(err error) bool) Option {
+	return func(b *Breaker) {
+		if f != nil {
+			b.isFailure = f
+		}
+	}
+}
+
+// OptWithOnStateChange 状态变化时的回调, 如发送告警
+func OptWithOnStateChange(f func(name string, from, to State)) Option {
+	return func(b *Breaker) {
+		b.onStateChange = f
+	}
+}
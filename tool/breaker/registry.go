@@ -0,0 +1,69 @@
+package breaker
+
+import (
+	"context"
+	"sync"
+
+	"github.com/senyu-up/toolbox/tool/config"
+)
+
+var (
+	breakers = map[string]*Breaker{}
+	lock     sync.RWMutex
+)
+
+// Configure 按配置创建命名的熔断器, 已存在时替换, 统计数据清空
+func Configure(name string, conf config.BreakerConf, opts ...Option) *Breaker {
+	b := New(name, conf, opts...)
+	lock.Lock()
+	defer lock.Unlock()
+	breakers[name] = b
+	return b
+}
+
+// ConfigureAll 批量配置, key 为命令名称
+func ConfigureAll(confs map[string]config.BreakerConf) {
+	for name, conf := range confs {
+		Configure(name, conf)
+	}
+}
+
+// Get 返回命名的熔断器, 未配置时使用默认配置创建
+func Get(name string) *Breaker {
+	lock.RLock()
+	b, ok := breakers[name]
+	lock.RUnlock()
+	if ok {
+		return b
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	if b, ok = breakers[name]; !ok {
+		b = New(name, config.BreakerConf{})
+		breakers[name] = b
+	}
+	return b
+}
+
+// Do 通过命名的熔断器执行 run, 见 Breaker.Do
+//
+//	err := breaker.Do(ctx, "user-api", func(ctx context.Context) error {
+//		return callUserApi(ctx)
+//	}, func(ctx context.Context, err error) error {
+//		return useCache(ctx)
+//	})
+func Do(ctx context.Context, name string, run func(ctx context.Context) error, fallback func(ctx context.Context, err error) error) error {
+	return Get(name).Do(ctx, run, fallback)
+}
+
+// States 返回所有熔断器的当前状态, key 为命令名称
+func States() map[string]Stats {
+	lock.RLock()
+	defer lock.RUnlock()
+	res := make(map[string]Stats, len(breakers))
+	for name, b := range breakers {
+		res[name] = b.Stats()
+	}
+	return res
+}
@@ -0,0 +1,82 @@
+package breaker
+
+import (
+	"context"
+	"fmt"
+
+	imreq "github.com/imroc/req/v3"
+	"github.com/senyu-up/toolbox/tool/http/req"
+)
+
+// ReqClient 通过熔断器发送 req.Client 请求, 网络错误与 5xx 响应计为失败, 5xx 响应仍原样返回
+// req.Client 的链式设置返回 *req.Client, 需要在设置完成后再包装
+//
+//	resp, err := breaker.WrapReq("user-api", req.New(ctx).BodyJson(data)).Post(url)
+type ReqClient struct {
+	*req.Client
+	b        *Breaker
+	fallback func(err error) (*imreq.Response, error)
+}
+
+type reqResult struct {
+	resp *imreq.Response
+	err  error
+}
+
+// WrapReq 使用命名的熔断器包装 req.Client
+// 熔断器的 Timeout 到达时取消请求并返回 ErrTimeout, 请求的 context 取消时同样取消
+func WrapReq(name string, c *req.Client) *ReqClient {
+	return &ReqClient{Client: c, b: Get(name)}
+}
+
+// Fallback 熔断、超过并发数、请求失败时的降级处理
+func (c *ReqClient) Fallback(f func(err error) (*imreq.Response, error)) *ReqClient {
+	c.fallback = f
+	return c
+}
+
+func (c *ReqClient) Get(url string) (*imreq.Response, error) {
+	return c.do(c.Client.Get, url)
+}
+
+func (c *ReqClient) Post(url string) (*imreq.Response, error) {
+	return c.do(c.Client.Post, url)
+}
+
+func (c *ReqClient) Put(url string) (*imreq.Response, error) {
+	return c.do(c.Client.Put, url)
+}
+
+func (c *ReqClient) Patch(url string) (*imreq.Response, error) {
+	return c.do(c.Client.Patch, url)
+}
+
+func (c *ReqClient) Delete(url string) (*imreq.Response, error) {
+	return c.do(c.Client.Delete, url)
+}
+
+func (c *ReqClient) do(send func(url string) (*imreq.Response, error), url string) (*imreq.Response, error) {
+	// 超时返回后请求可能仍在进行, 结果通过 channel 传递, 避免并发读写
+	res := make(chan reqResult, 1)
+	err := c.b.Do(c.Client.GetContext(), func(ctx context.Context) error {
+		// 超时后取消请求, 避免熔断器返回后请求仍占用下游
+		c.Client.SetContext(ctx)
+		resp, err := send(url)
+		res <- reqResult{resp: resp, err: err}
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode >= 500 {
+			return softFailure{err: fmt.Errorf("%s response status %d", url, resp.StatusCode)}
+		}
+		return nil
+	}, nil)
+	if err != nil {
+		if c.fallback != nil {
+			return c.fallback(err)
+		}
+		return nil, err
+	}
+	r := <-res
+	return r.resp, r.err
+}
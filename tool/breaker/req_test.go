@@ -0,0 +1,70 @@
+package breaker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	imreq "github.com/imroc/req/v3"
+	"github.com/senyu-up/toolbox/tool/config"
+	"github.com/senyu-up/toolbox/tool/http/req"
+)
+
+func TestReqClient(t *testing.T) {
+	status := http.StatusInternalServerError
+	requests := 0
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(status)
+	}))
+	defer s.Close()
+	Configure("req_test", config.BreakerConf{RequestVolumeThreshold: 2})
+
+	// 5xx 响应原样返回, 但计为失败
+	for i := 0; i < 2; i++ {
+		resp, err := WrapReq("req_test", req.New(context.TODO())).Get(s.URL)
+		if err != nil || resp.StatusCode != status {
+			t.Fatalf("Get() resp = %v, error = %v", resp, err)
+		}
+	}
+	if Get("req_test").State() != StateOpen {
+		t.Fatalf("state = %s, want open", Get("req_test").State())
+	}
+
+	status = http.StatusOK
+	_, err := WrapReq("req_test", req.New(context.TODO())).Post(s.URL)
+	if err != ErrOpen || requests != 2 {
+		t.Errorf("Post() error = %v, requests = %d", err, requests)
+	}
+	_, err = WrapReq("req_test", req.New(context.TODO())).Fallback(func(err error) (*imreq.Response, error) {
+		return nil, nil
+	}).Put(s.URL)
+	if err != nil {
+		t.Errorf("Put() error = %v, want fallback", err)
+	}
+}
+
+func TestReqClient_Timeout(t *testing.T) {
+	canceled := make(chan struct{})
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+			close(canceled)
+		case <-time.After(time.Second):
+		}
+	}))
+	defer s.Close()
+	Configure("req_timeout_test", config.BreakerConf{Timeout: 20 * time.Millisecond})
+
+	if _, err := WrapReq("req_timeout_test", req.New(context.TODO())).Get(s.URL); err != ErrTimeout {
+		t.Fatalf("Get() error = %v, want ErrTimeout", err)
+	}
+	// 熔断器超时后请求被取消, 不再占用下游
+	select {
+	case <-canceled:
+	case <-time.After(500 * time.Millisecond):
+		t.Error("request should be canceled after breaker timeout")
+	}
+}
@@ -2,7 +2,7 @@ package config
 
 type Aws struct {
 	AwsAccessId  string `yaml:"awsAccessId"`
-	AwsAccessKey string `yaml:"awsAccessKey"`
+	AwsAccessKey string `yaml:"awsAccessKey" secret:"true"`
 
 	S3 []S3Storage `yaml:"s3"` // 单个regin下的配置
 }
@@ -49,9 +49,9 @@ type DbGroup struct {
 type DBConf struct {
 	Addr         string
 	User         string
-	Password     string
-	PoolSize     int `yaml:"poolsize"`
-	MinIdleConns int `yaml:"minidleconns"`
+	Password     string `secret:"true"`
+	PoolSize     int    `yaml:"poolsize"`
+	MinIdleConns int    `yaml:"minidleconns"`
 	Db           interface{}
 	IsCluster    bool `yaml:"iscluster"`
 	IsSrv        bool `yaml:"isSrv"`
@@ -68,7 +68,7 @@ type Ansible struct {
 	//用户名
 	User string `yaml:"user"`
 	//密码
-	Pass string `yaml:"pass"`
+	Pass string `yaml:"pass" secret:"true"`
 	//秘钥文件
 	SshKeyPath string `yaml:"ssh_key_path"`
 	//端口
@@ -83,11 +83,11 @@ type ThirdParty struct {
 	FlexionPub       string `yaml:"flexionPub"`
 	XsollaProductId  int    `yaml:"xsollaProductId"`
 	XsollaMerchantId int    `yaml:"xsollaMerchantId"`
-	XsollaApiSecret  string `yaml:"xsollaApiSecret"`
+	XsollaApiSecret  string `yaml:"xsollaApiSecret" secret:"true"`
 }
 
 type Report struct {
-	Secret string `yaml:"secret"`
+	Secret string `yaml:"secret" secret:"true"`
 }
 
 type BroadcastConf struct {
@@ -99,7 +99,7 @@ type Smtp struct {
 	// Username 用户名称
 	Username string `json:"username"`
 	// Password 用户密码
-	Password string `json:"password"`
+	Password string `json:"password" secret:"true"`
 	// Host host
 	Host string `json:"host"`
 }
@@ -0,0 +1,175 @@
+package confcmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/senyu-up/toolbox/tool/config/loader"
+	"github.com/senyu-up/toolbox/tool/file"
+	"github.com/spf13/cobra"
+)
+
+type cmdOption struct {
+	path     *string             // 配置文件路径, 一般为根命令的 --conf 参数
+	schema   interface{}         // 配置结构体, 用于按 secret tag 脱敏, 生成 JSON Schema
+	confOpts []loader.ConfOption // 加载配置时额外的参数, 例如环境变量前缀
+}
+
+type CmdOption func(*cmdOption)
+
+// CmdOptWithConfigPath 指定配置文件路径, 传入指针以便在命令执行时读取已解析的根命令参数
+// 路径为目录时与 boot 一致, 在目录下查找 config/config.yaml
+func CmdOptWithConfigPath(p *string) CmdOption {
+	return func(o *cmdOption) {
+		o.path = p
+	}
+}
+
+// CmdOptWithSchema 指定项目的配置结构体, dump, diff 时按结构体中的 secret tag 脱敏, schema 命令由它生成 JSON Schema
+// 未指定时按 key 名脱敏, 例如 password, secret
+func CmdOptWithSchema(schema interface{}) CmdOption {
+	return func(o *cmdOption) {
+		o.schema = schema
+	}
+}
+
+// CmdOptWithConfOpts 加载配置时额外的参数, 与项目启动时保持一致, 例如 loader.ConfOptWithEnvPrefix("APP")
+func CmdOptWithConfOpts(opts ...loader.ConfOption) CmdOption {
+	return func(o *cmdOption) {
+		o.confOpts = append(o.confOpts, opts...)
+	}
+}
+
+// NewConfigCmd 配置相关的命令, 挂载到项目的根命令上即可使用
+//
+//	rootCmd.AddCommand(confcmd.NewConfigCmd(
+//		confcmd.CmdOptWithConfigPath(&global.ConfigPath),
+//		confcmd.CmdOptWithSchema(&config.Config{})))
+func NewConfigCmd(opts ...CmdOption) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "config tools, dump, diff, schema, encrypt value, generate key",
+		Long:  ``,
+		// 覆盖根命令的 PersistentPostRun, 配置命令执行完直接退出
+		PersistentPostRun: func(cmd *cobra.Command, args []string) {},
+	}
+	cmd.AddCommand(NewDumpCmd(opts...), NewDiffCmd(opts...), NewSchemaCmd(opts...), NewEncryptCmd(), NewGenKeyCmd())
+	return cmd
+}
+
+func newCmdOption(opts []CmdOption) *cmdOption {
+	o := &cmdOption{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// 配置文件路径, 参数 p 优先, 其次为 CmdOptWithConfigPath 指定的路径
+func (o *cmdOption) configPath(p string) (string, error) {
+	if p == "" && o.path != nil {
+		p = *o.path
+	}
+	if p == "" {
+		p = "."
+	}
+	info, err := os.Stat(p)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return p, nil
+	}
+	if conf := file.ScanConfigPath(p); conf != "" {
+		return conf, nil
+	}
+	return "", fmt.Errorf("config file not found in %s", p)
+}
+
+// 加载某个配置文件（及其 stage 配置）的全部配置, 返回脱敏后的配置
+func (o *cmdOption) load(path, stage string, extra ...loader.ConfOption) (settings, redacted map[string]interface{}, err error) {
+	param := append([]loader.ConfOption{loader.ConfOptWithPath(path)}, o.confOpts...)
+	if stage != "" {
+		param = append(param, loader.ConfOptWithStage(stage))
+	}
+	param = append(param, extra...)
+	c, err := loader.InitConf(&loader.File{}, param...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if settings, err = c.AllSettings(); err != nil {
+		return nil, nil, err
+	}
+	return settings, o.redact(settings), nil
+}
+
+// 未指定 schema 时按 key 名脱敏的 key
+var secretKeys = []string{"password", "pass", "secret", "secretkey", "tokensecret", "apikey", "accesskey", "awsaccesskey", "webhook", "dsn"}
+
+func (o *cmdOption) redact(settings map[string]interface{}) map[string]interface{} {
+	if o.schema != nil {
+		return loader.Redact(settings, o.schema)
+	}
+	return redactByKey(settings)
+}
+
+func redactByKey(settings map[string]interface{}) map[string]interface{} {
+	res := make(map[string]interface{}, len(settings))
+	for k, v := range settings {
+		switch val := v.(type) {
+		case map[string]interface{}:
+			res[k] = redactByKey(val)
+			continue
+		case []interface{}:
+			items := make([]interface{}, len(val))
+			for i, item := range val {
+				if m, ok := item.(map[string]interface{}); ok {
+					items[i] = redactByKey(m)
+				} else {
+					items[i] = item
+				}
+			}
+			res[k] = items
+			continue
+		}
+		res[k] = v
+		for _, key := range secretKeys {
+			if strings.EqualFold(k, key) && v != nil && v != "" {
+				res[k] = loader.RedactedValue
+				break
+			}
+		}
+	}
+	return res
+}
+
+// 解密相关的参数, 与 encrypt 命令的参数一致
+type decryptFlags struct {
+	keyFile    string
+	privateKey string
+}
+
+func (f *decryptFlags) bind(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&f.keyFile, "key-file", "k", "", "aes key file to decrypt ENC(...) values")
+	cmd.Flags().StringVar(&f.privateKey, "private-key", "", "rsa private key file to decrypt ENC(...) values")
+}
+
+func (f *decryptFlags) confOpts() ([]loader.ConfOption, error) {
+	var (
+		d   loader.Decrypter
+		err error
+	)
+	switch {
+	case f.keyFile != "":
+		d, err = loader.NewAesGcmDecrypterFromFile(f.keyFile)
+	case f.privateKey != "":
+		d, err = loader.NewRsaDecrypterFromFile(f.privateKey)
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []loader.ConfOption{loader.ConfOptWithDecrypter(d)}, nil
+}
@@ -0,0 +1,105 @@
+package confcmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/senyu-up/toolbox/tool/config"
+)
+
+type appConf struct {
+	App   config.App
+	Mysql config.MysqlConfig
+	Redis config.RedisConfig
+}
+
+func writeFile(t *testing.T, p, content string) {
+	if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+		t.Fatalf("write file err %v", err)
+	}
+}
+
+func execute(t *testing.T, args ...string) (string, error) {
+	var out = &bytes.Buffer{}
+	cmd := NewConfigCmd(CmdOptWithSchema(&appConf{}))
+	cmd.SetOut(out)
+	cmd.SetErr(out)
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestConfigCmd(t *testing.T) {
+	var (
+		dir     = t.TempDir()
+		base    = filepath.Join(dir, "config.yaml")
+		other   = filepath.Join(dir, "other.yaml")
+		keyFile = filepath.Join(dir, "config.key")
+	)
+	t.Setenv("stage", "")
+	os.Unsetenv("stage")
+
+	if _, err := execute(t, "genkey", "-o", keyFile); err != nil {
+		t.Fatalf("genkey error = %v", err)
+	}
+	enc, err := execute(t, "encrypt", "-k", keyFile, "root_pass")
+	if err != nil || !strings.HasPrefix(enc, "ENC(") {
+		t.Fatalf("encrypt got = %s, error = %v", enc, err)
+	}
+	writeFile(t, base, "app:\n  name: demo\nmysql:\n  master:\n    user: root\n    password: "+strings.TrimSpace(enc)+"\nredis:\n  addrs: [localhost:6379]\n")
+	writeFile(t, filepath.Join(dir, "config.production.yaml"), "app:\n  dev: false\nredis:\n  addrs: [production:6379]\n  password: prod_pass\n")
+	writeFile(t, other, "app:\n  name: demo2\nmysql:\n  master:\n    user: root\n    password: other_pass\n")
+
+	tests := []struct {
+		name     string
+		args     []string
+		contains []string
+		excludes []string
+	}{
+		{
+			name:     "dump yaml",
+			args:     []string{"dump", "-f", base, "-k", keyFile},
+			contains: []string{"name: demo", "password: '******'", "- localhost:6379"},
+			excludes: []string{"root_pass", "ENC("},
+		},
+		{
+			name:     "dump stage json",
+			args:     []string{"dump", "-f", base, "-s", "production", "-o", "json"},
+			contains: []string{`"stage": "production"`, `"production:6379"`, `"password": "******"`},
+			excludes: []string{"prod_pass"},
+		},
+		{
+			name:     "diff files",
+			args:     []string{"diff", "-k", keyFile, base, other},
+			contains: []string{"~ app.name: demo -> demo2", "~ mysql.master.password: ****** -> ******", `- redis.addrs: ["localhost:6379"]`},
+			excludes: []string{"root_pass", "other_pass", "mysql.master.user"},
+		},
+		{
+			name:     "diff stages",
+			args:     []string{"diff", "-f", base, "local", "production"},
+			contains: []string{"+ app.dev: false", "~ app.stage: local -> production", "+ redis.password: ******"},
+			excludes: []string{"prod_pass", "app.name"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := execute(t, tt.args...)
+			if err != nil {
+				t.Fatalf("execute() error = %v, output %s", err, got)
+			}
+			for _, s := range tt.contains {
+				if !strings.Contains(got, s) {
+					t.Errorf("execute() got = %s, want contains %s", got, s)
+				}
+			}
+			for _, s := range tt.excludes {
+				if strings.Contains(got, s) {
+					t.Errorf("execute() got = %s, want not contains %s", got, s)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package confcmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+
+	"github.com/spf13/cobra"
+)
+
+// NewDiffCmd 比较两份配置合并后实际生效的配置, 敏感配置会被脱敏
+// 参数都是文件时比较两个文件, 否则比较配置文件的两个 stage
+//
+//	app config diff ./config.yaml ./config.bak.yaml
+//	app config diff develop production
+func NewDiffCmd(opts ...CmdOption) *cobra.Command {
+	var (
+		o       = newCmdOption(opts)
+		path    string
+		decrypt decryptFlags
+	)
+	cmd := &cobra.Command{
+		Use:   "diff <file|stage> <file|stage>",
+		Short: "diff effective config of two files or stages",
+		Long:  `diff effective config of two files or stages, "-" only in left, "+" only in right, "~" changed`,
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			extra, err := decrypt.confOpts()
+			if err != nil {
+				return err
+			}
+			var paths, stages = make([]string, 2), make([]string, 2)
+			if isFile(args[0]) && isFile(args[1]) {
+				copy(paths, args)
+			} else {
+				p, err := o.configPath(path)
+				if err != nil {
+					return err
+				}
+				paths[0], paths[1] = p, p
+				copy(stages, args)
+			}
+			left, leftRedacted, err := o.load(paths[0], stages[0], extra...)
+			if err != nil {
+				return fmt.Errorf("load %s err: %w", args[0], err)
+			}
+			right, rightRedacted, err := o.load(paths[1], stages[1], extra...)
+			if err != nil {
+				return fmt.Errorf("load %s err: %w", args[1], err)
+			}
+			return printDiff(cmd.OutOrStdout(), left, right, leftRedacted, rightRedacted)
+		},
+	}
+	cmd.Flags().StringVarP(&path, "file", "f", "", "config file when diff stages, default the config path of app")
+	decrypt.bind(cmd)
+	return cmd
+}
+
+// 比较原始配置, 输出脱敏后的值; 敏感配置有变化时也会输出, 但看不到具体的值
+func printDiff(w io.Writer, left, right, leftRedacted, rightRedacted map[string]interface{}) error {
+	var (
+		l, r   = flatten("", left, nil), flatten("", right, nil)
+		lr, rr = flatten("", leftRedacted, nil), flatten("", rightRedacted, nil)
+		keys   = make([]string, 0, len(l)+len(r))
+	)
+	for k := range l {
+		keys = append(keys, k)
+	}
+	for k := range r {
+		if _, ok := l[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		lv, inLeft := l[k]
+		rv, inRight := r[k]
+		var err error
+		switch {
+		case !inRight:
+			_, err = fmt.Fprintf(w, "- %s: %s\n", k, formatValue(lr[k]))
+		case !inLeft:
+			_, err = fmt.Fprintf(w, "+ %s: %s\n", k, formatValue(rr[k]))
+		case !reflect.DeepEqual(lv, rv):
+			_, err = fmt.Fprintf(w, "~ %s: %s -> %s\n", k, formatValue(lr[k]), formatValue(rr[k]))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 把嵌套 map 展开为 a.b.c => value, 数组作为一个值
+func flatten(prefix string, settings map[string]interface{}, res map[string]interface{}) map[string]interface{} {
+	if res == nil {
+		res = make(map[string]interface{})
+	}
+	for k, v := range settings {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		if m, ok := v.(map[string]interface{}); ok && len(m) > 0 {
+			flatten(key, m, res)
+			continue
+		}
+		res[key] = v
+	}
+	return res
+}
+
+func formatValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(out)
+}
+
+func isFile(p string) bool {
+	info, err := os.Stat(p)
+	return err == nil && !info.IsDir()
+}
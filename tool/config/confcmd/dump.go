@@ -0,0 +1,71 @@
+package confcmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	FormatYaml = "yaml"
+	FormatJson = "json"
+)
+
+// NewDumpCmd 打印合并后（基础配置 + stage 配置 + 环境变量）实际生效的配置, 敏感配置会被脱敏
+//
+//	app config dump
+//	app config dump --stage production -o json
+func NewDumpCmd(opts ...CmdOption) *cobra.Command {
+	var (
+		o                   = newCmdOption(opts)
+		path, stage, format string
+		decrypt             decryptFlags
+	)
+	cmd := &cobra.Command{
+		Use:   "dump",
+		Short: "print effective config, secrets redacted",
+		Long:  ``,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p, err := o.configPath(path)
+			if err != nil {
+				return err
+			}
+			extra, err := decrypt.confOpts()
+			if err != nil {
+				return err
+			}
+			_, redacted, err := o.load(p, stage, extra...)
+			if err != nil {
+				return err
+			}
+			out, err := marshal(redacted, format)
+			if err != nil {
+				return err
+			}
+			_, err = cmd.OutOrStdout().Write(out)
+			return err
+		},
+	}
+	cmd.Flags().StringVarP(&path, "file", "f", "", "config file, default the config path of app")
+	cmd.Flags().StringVarP(&stage, "stage", "s", "", "stage to merge, default env stage or app.stage")
+	cmd.Flags().StringVarP(&format, "output", "o", FormatYaml, "output format, yaml or json")
+	decrypt.bind(cmd)
+	return cmd
+}
+
+func marshal(v interface{}, format string) ([]byte, error) {
+	switch strings.ToLower(format) {
+	case FormatYaml:
+		return yaml.Marshal(v)
+	case FormatJson:
+		out, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(out, '\n'), nil
+	}
+	return nil, fmt.Errorf("unsupported output format %s", format)
+}
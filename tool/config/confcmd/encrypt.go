@@ -0,0 +1,127 @@
+package confcmd
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/senyu-up/toolbox/tool/config/loader"
+	"github.com/senyu-up/toolbox/tool/encrypt"
+	"github.com/spf13/cobra"
+)
+
+const (
+	ModeAes = "aes"
+	ModeRsa = "rsa"
+)
+
+// NewEncryptCmd 加密配置值, 输出 ENC(...), 可以直接写入配置文件
+//
+//	app config encrypt --key-file ./config.key "my password"
+//	app config encrypt --mode rsa --public-key ./public.pem "my password"
+//	echo -n "my password" | app config encrypt --key-file ./config.key
+func NewEncryptCmd() *cobra.Command {
+	var mode, keyFile, publicKey string
+	cmd := &cobra.Command{
+		Use:   "encrypt [value...]",
+		Short: "encrypt config value, print ENC(...)",
+		Long:  `encrypt config value, if no value given, read lines from stdin`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			enc, err := newEncrypter(mode, keyFile, publicKey)
+			if err != nil {
+				return err
+			}
+			if 1 > len(args) {
+				// 从标准输入读取, 避免明文出现在 shell 历史中
+				scanner := bufio.NewScanner(cmd.InOrStdin())
+				for scanner.Scan() {
+					args = append(args, scanner.Text())
+				}
+				if err = scanner.Err(); err != nil {
+					return err
+				}
+			}
+			for _, arg := range args {
+				ciphertext, err := enc([]byte(arg))
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "ENC(%s)\n", ciphertext)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&mode, "mode", "m", ModeAes, "encrypt mode, aes or rsa")
+	cmd.Flags().StringVarP(&keyFile, "key-file", "k", "", "aes key file, base64 encoded, generated by genkey")
+	cmd.Flags().StringVarP(&publicKey, "public-key", "p", "", "rsa public key file, pem format")
+	return cmd
+}
+
+func newEncrypter(mode, keyFile, publicKey string) (func(data []byte) (string, error), error) {
+	switch strings.ToLower(mode) {
+	case ModeAes:
+		if 1 > len(keyFile) {
+			return nil, fmt.Errorf("--key-file is required in aes mode")
+		}
+		key, err := loader.ReadAesKeyFile(keyFile)
+		if err != nil {
+			return nil, err
+		}
+		return func(data []byte) (string, error) {
+			return encrypt.AesGCMEncrypt(data, key)
+		}, nil
+	case ModeRsa:
+		if 1 > len(publicKey) {
+			return nil, fmt.Errorf("--public-key is required in rsa mode")
+		}
+		key, err := os.ReadFile(publicKey)
+		if err != nil {
+			return nil, err
+		}
+		return func(data []byte) (string, error) {
+			return encrypt.RsaEncrypt(data, key)
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown encrypt mode %s", mode)
+}
+
+// NewGenKeyCmd 生成 AES 密钥文件, 内容为 base64 编码的密钥
+//
+//	app config genkey --out ./config.key
+func NewGenKeyCmd() *cobra.Command {
+	var out string
+	var size int
+	cmd := &cobra.Command{
+		Use:   "genkey",
+		Short: "generate aes key file for config encryption",
+		Long:  ``,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if size != 16 && size != 24 && size != 32 {
+				return loader.ErrAesKeyInvalid
+			}
+			key := make([]byte, size)
+			if _, err := rand.Read(key); err != nil {
+				return err
+			}
+			content := base64.StdEncoding.EncodeToString(key) + "\n"
+			if 1 > len(out) {
+				_, err := fmt.Fprint(cmd.OutOrStdout(), content)
+				return err
+			}
+			// 密钥文件仅当前用户可读写, 已存在时不覆盖
+			f, err := os.OpenFile(out, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			_, err = f.WriteString(content)
+			return err
+		},
+	}
+	cmd.Flags().StringVarP(&out, "out", "o", "", "key file path, print to stdout if empty")
+	cmd.Flags().IntVarP(&size, "size", "s", 32, "key size in bytes, 16, 24 or 32")
+	return cmd
+}
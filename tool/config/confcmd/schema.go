@@ -0,0 +1,42 @@
+package confcmd
+
+import (
+	"errors"
+	"os"
+
+	"github.com/senyu-up/toolbox/tool/config/schema"
+	"github.com/spf13/cobra"
+)
+
+// NewSchemaCmd 由 CmdOptWithSchema 指定的配置结构体生成 JSON Schema, 用于编辑器补全、校验 config.yaml
+//
+//	app config schema -o ./config.schema.json
+func NewSchemaCmd(opts ...CmdOption) *cobra.Command {
+	var (
+		o      = newCmdOption(opts)
+		output string
+		strict bool
+	)
+	cmd := &cobra.Command{
+		Use:   "schema",
+		Short: "generate json schema of config",
+		Long:  ``,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if o.schema == nil {
+				return errors.New("config schema not set, use confcmd.CmdOptWithSchema")
+			}
+			out, err := schema.Marshal(o.schema, schema.OptWithStrict(strict))
+			if err != nil {
+				return err
+			}
+			if output != "" {
+				return os.WriteFile(output, out, 0644)
+			}
+			_, err = cmd.OutOrStdout().Write(out)
+			return err
+		},
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", "", "output file, default stdout")
+	cmd.Flags().BoolVar(&strict, "strict", true, "reject unknown keys")
+	return cmd
+}
@@ -1,5 +1,8 @@
 package config
 
 type Etcd struct {
-	Addrs []string
+	Addrs       []string `yaml:"addrs"`
+	User        string   `yaml:"user,omitempty"`                   // [可选] 用户名
+	Password    string   `yaml:"password,omitempty" secret:"true"` // [可选] 密码
+	DialTimeout int      `yaml:"dialTimeout,omitempty"`            // 连接超时时间, 单位秒, 默认5s
 }
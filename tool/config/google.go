@@ -1,7 +1,7 @@
 package config
 
 type Google struct {
-	ApiKey string `yaml:"apiKey"`
+	ApiKey string `yaml:"apiKey" secret:"true"`
 }
 
 type Gcs struct {
@@ -29,4 +29,6 @@ type HealthCheck struct {
 	Addr       string `yaml:"addr"`       // 监听地址
 	Port       uint32 `yaml:"port"`       // 端口
 	DisableLog bool   `yaml:"disableLog"` // 是否禁用日志, 禁用后访问 /system/health 时不会打印日志
+
+	EnableLogLevel bool `yaml:"enableLogLevel"` // 是否开启 /debug/loglevel, 该接口可以运行时查看、修改日志级别, 没有鉴权, 只在内网端口开启
 }
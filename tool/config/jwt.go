@@ -1,6 +1,6 @@
 package config
 
 type Jwt struct {
-	TokenSecret     string `yaml:"tokenSecret"`
+	TokenSecret     string `yaml:"tokenSecret" secret:"true"`
 	TokenExpiration int64  `yaml:"tokenExpiration"`
 }
@@ -1,16 +1,16 @@
 package config
 
 type KafkaConfig struct {
-	Brokers          []string `yaml:"brokers"`            // kafka集群地址列表
-	Timeout          int      `yaml:"timeout"`            // 发送消息超时时间, 单位秒
-	Level            int      `yaml:"level"`              // 消息等级 1. 允许消息出现丢失, leader确认收到消息即可, 性能较高 2. 不允许消息丢失, 所有broker均确认收到消息
-	User             string   `yaml:"user,omitempty"`     // [可选] 用户名
-	Password         string   `yaml:"password,omitempty"` // [可选] 密码
-	SyncFullMetadata bool     `yaml:"syncFullMetadata"`   // 同步所有主题的metadata信息, 默认为 false
-	DialTimeout      int      `yaml:"dialTimeout"`        // Dial网络时间配置, 单位秒, connection 超时时间, 默认30s
-	ReadTimeout      int      `yaml:"readTimeout"`        // Read 时间, 单位秒, 默认30s
-	WriteTimeout     int      `yaml:"writeTimeout"`       // Write 时间, 单位秒, 默认30s
-	Version          string   `yaml:"version"`            // Kafka 版本，不传则使用：2.6.0.0
+	Brokers          []string `yaml:"brokers"`                          // kafka集群地址列表
+	Timeout          int      `yaml:"timeout"`                          // 发送消息超时时间, 单位秒
+	Level            int      `yaml:"level"`                            // 消息等级 1. 允许消息出现丢失, leader确认收到消息即可, 性能较高 2. 不允许消息丢失, 所有broker均确认收到消息
+	User             string   `yaml:"user,omitempty"`                   // [可选] 用户名
+	Password         string   `yaml:"password,omitempty" secret:"true"` // [可选] 密码
+	SyncFullMetadata bool     `yaml:"syncFullMetadata"`                 // 同步所有主题的metadata信息, 默认为 false
+	DialTimeout      int      `yaml:"dialTimeout"`                      // Dial网络时间配置, 单位秒, connection 超时时间, 默认30s
+	ReadTimeout      int      `yaml:"readTimeout"`                      // Read 时间, 单位秒, 默认30s
+	WriteTimeout     int      `yaml:"writeTimeout"`                     // Write 时间, 单位秒, 默认30s
+	Version          string   `yaml:"version"`                          // Kafka 版本，不传则使用：2.6.0.0
 
 	MetadataTimeout               int `yaml:"metadataTimeout"`               // 获取metadata超时时间, 单位秒, 默认30s
 	ProducerTimeout               int `yaml:"producerTimeout"`               // 投递超时时间, 单位秒
@@ -29,7 +29,7 @@ type KafkaConfig struct {
 	SASL struct {
 		Enable   bool   `yaml:"enable"`
 		User     string `yaml:"user"`
-		Password string `yaml:"password"`
+		Password string `yaml:"password" secret:"true"`
 	} `yaml:"sasl"` // consumer sasl 配置
 
 	TraceOn bool `yaml:"traceOn"` // 是否开启 trace, 打开后会产生一层 span
@@ -46,7 +46,7 @@ type KafkaConsumerConfig struct {
 	SASL  struct {
 		Enable   bool   `yaml:"enable"`
 		User     string `yaml:"user"`
-		Password string `yaml:"password"`
+		Password string `yaml:"password" secret:"true"`
 	} `yaml:"sasl"`
 	// 多少个协程
 	Workers int `yaml:"workers"`
@@ -64,12 +64,12 @@ type AwsKafkaConfig struct {
 		// option: PLAIN, AWS_MSK_IAM, SCRAM-SHA-512, SCRAM-SHA-256
 		Mechanism string
 
-		Region    string `yaml:"region"`    // 区域
-		AccessId  string `yaml:"accessId"`  // 访问ID
-		SecretKey string `yaml:"secretKey"` // 访问密钥
+		Region    string `yaml:"region"`                  // 区域
+		AccessId  string `yaml:"accessId"`                // 访问ID
+		SecretKey string `yaml:"secretKey" secret:"true"` // 访问密钥
 
-		UserName string `yaml:"userName"` // 用户名
-		Password string `yaml:"password"` // 密码
+		UserName string `yaml:"userName"`               // 用户名
+		Password string `yaml:"password" secret:"true"` // 密码
 	}
 
 	Brokers []string `yaml:"brokers"` // kafka集群地址列表
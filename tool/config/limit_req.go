@@ -23,7 +23,7 @@ type LimiterType string
 const (
 	// 分布式限流, 采用 令牌桶 算法进行限流, 对精度控制较高, 依赖redis
 	LimiterTypeDistributed LimiterType = "distributed"
-	// 单机限流, 采用 滑动时间窗口 算法进行限流, 高性能, 推荐
+	// 单机限流, 采用 令牌桶 算法进行限流, 进程内计数, 高性能, 推荐
 	LimiterTypeLocal LimiterType = "local"
 	LimiterTypeAli               = "ali"
 )
@@ -44,7 +44,7 @@ type LimiterRule struct {
 }
 
 type Trust struct {
-	// 子网掩码
+	// 子网掩码, 如 10.0.0.0/8, 也可以是单个 ip, 可信子网内的请求不限流
 	SubnetMask []string
 }
 
@@ -56,7 +56,7 @@ type Limiter struct {
 	Redis redis.UniversalClient
 	// distributed => 分布式    local => 单机
 	Category LimiterType
-	// 指定如何获取数据
+	// 指定如何获取数据, input 为 *fiber.Ctx 或 *gin.Context, key 为 LimiterRule.SourceKey
 	Taker func(input interface{}, key string) string
 }
 
@@ -1,20 +1,44 @@
 package loader
 
 import (
+	"context"
 	"errors"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/senyu-up/toolbox/tool/config"
+	"github.com/spf13/pflag"
 )
 
 var (
-	ErrConfigEmpty     = errors.New("Config Empty ")
-	ErrConfigKeyNotSet = errors.New("Config key not set ")
+	ErrConfigEmpty          = errors.New("Config Empty ")
+	ErrConfigKeyNotSet      = errors.New("Config key not set ")
+	ErrConfigWatchUnsupport = errors.New("Config loader not support watch ")
+	ErrConfigDumpUnsupport  = errors.New("Config loader not support dump all settings ")
 )
 
 type Config struct {
 	loader Loader
 
-	configType string // 配置类型
-	path       string // 配置路径, 可以是filepath，或者 uri
+	configType string        // 配置类型
+	path       string        // 配置路径, 可以是filepath，或者 uri
+	etcd       config.Etcd   // etcd 连接配置, 仅 Etcd loader 使用
+	debounce   time.Duration // 配置变更的防抖时间, 仅 Watch 时使用
+
+	stage     string                 // 当前环境, 仅 File loader 使用, 用于加载 stage 配置文件
+	envPrefix string                 // 环境变量前缀, Env, File loader 使用
+	flags     *pflag.FlagSet         // 命令行参数, 仅 Flags loader 使用
+	flagKeys  map[string]string      // 命令行参数名与配置路径的映射, 仅 Flags loader 使用
+	defaults  map[string]interface{} // 默认配置, 仅 Defaults loader 使用
+
+	httpClient   *http.Client  // 请求配置的 http client, 仅 HTTP loader 使用
+	httpHeader   http.Header   // 请求配置时额外的 header, 例如鉴权, 仅 HTTP loader 使用
+	pollInterval time.Duration // 轮询配置的间隔, 仅 HTTP loader Watch 时使用
+	cacheFile    string        // 最近一次成功拉取的配置的本地副本, 仅 HTTP loader 使用
 
+	decrypter Decrypter     // 解密 ENC(...) 包裹的配置值, 在 Config.Get, Config.Unmarshal 时使用
+	subs      *subscription // 配置变更订阅
 }
 
 type ConfVal struct {
@@ -27,6 +51,19 @@ type Loader interface {
 	Unmarshal(dst interface{}) error     // 把配置信息反序列化到传入的结构体上
 }
 
+// Watcher loader 可选实现的接口, 监听配置源的变化
+// 配置源变化后 loader 需要重新解析配置并整体替换，再调用 onChange；重新解析失败时保留旧配置，并把错误传给 onChange
+// ctx 取消后停止监听
+type Watcher interface {
+	Watch(ctx context.Context, onChange func(err error)) error
+}
+
+// OriginReporter loader 可选实现的接口, 报告配置最终取自哪一层配置源
+type OriginReporter interface {
+	Origin(key string) string   // 某个叶子节点配置的来源, 未设置的 key 返回空字符串
+	Origins() map[string]string // 所有叶子节点配置的来源
+}
+
 type ConfOption func(*Config)
 
 func ConfOptWithType(confType string) ConfOption {
@@ -41,17 +78,186 @@ func ConfOptWithPath(p string) ConfOption {
 	}
 }
 
+// 指定 etcd 连接配置, 仅 Etcd loader 使用
+func ConfOptWithEtcd(conf config.Etcd) ConfOption {
+	return func(option *Config) {
+		option.etcd = conf
+	}
+}
+
+// 指定配置变更的防抖时间, 在该时间内的多次变更只会触发一次重新加载, 默认 200ms
+func ConfOptWithDebounce(d time.Duration) ConfOption {
+	return func(option *Config) {
+		option.debounce = d
+	}
+}
+
+// 指定环境变量前缀, 例如 APP, 则 APP_REDIS_ADDR 对应 redis.addr
+func ConfOptWithEnvPrefix(prefix string) ConfOption {
+	return func(option *Config) {
+		option.envPrefix = prefix
+	}
+}
+
+// 指定当前环境, 例如 develop, 则在 config.yaml 的基础上合并 config.develop.yaml
+func ConfOptWithStage(stage string) ConfOption {
+	return func(option *Config) {
+		option.stage = stage
+	}
+}
+
+// 指定命令行参数集合, keys 为参数名与配置路径的映射, 不在 keys 中的参数以参数名作为配置路径, 可以为 nil
+func ConfOptWithFlags(flags *pflag.FlagSet, keys map[string]string) ConfOption {
+	return func(option *Config) {
+		option.flags = flags
+		option.flagKeys = keys
+	}
+}
+
+// 指定默认配置, key 支持 "." 分隔的路径
+func ConfOptWithDefaults(defaults map[string]interface{}) ConfOption {
+	return func(option *Config) {
+		option.defaults = defaults
+	}
+}
+
+// 指定请求配置的 http client, 仅 HTTP loader 使用, 默认超时 10s
+func ConfOptWithHttpClient(client *http.Client) ConfOption {
+	return func(option *Config) {
+		option.httpClient = client
+	}
+}
+
+// 指定请求配置时额外的 header, 例如 Authorization, 仅 HTTP loader 使用
+func ConfOptWithHttpHeader(header http.Header) ConfOption {
+	return func(option *Config) {
+		option.httpHeader = header
+	}
+}
+
+// 指定轮询配置的间隔, 仅 HTTP loader Watch 时使用, 默认 30s
+func ConfOptWithPollInterval(d time.Duration) ConfOption {
+	return func(option *Config) {
+		option.pollInterval = d
+	}
+}
+
+// 指定远程配置的本地副本路径, 仅 HTTP loader 使用, 不指定时不使用本地副本
+// 每次成功拉取后写入该文件(权限 0600), 启动时服务端不可用则使用该副本; 文件不属于当前用户或其他用户可写时不使用
+func ConfOptWithCacheFile(p string) ConfOption {
+	return func(option *Config) {
+		option.cacheFile = p
+	}
+}
+
+// 指定配置解密器, 配置值为 ENC(...) 时, Get 与 Unmarshal 会用它解密
+// 例如 loader.NewAesGcmDecrypterFromFile("./config.key") 或 loader.NewRsaDecrypterFromFile("./private.pem")
+func ConfOptWithDecrypter(d Decrypter) ConfOption {
+	return func(option *Config) {
+		option.decrypter = d
+	}
+}
+
 // 初始化配置对象，同时调用 loader.init 方法
 func InitConf(loader Loader, param ...ConfOption) (Config, error) {
-	return Config{loader: loader}, loader.Init(param...)
+	var c = Config{loader: loader, subs: &subscription{}}
+	for _, opt := range param {
+		opt(&c)
+	}
+	return c, loader.Init(param...)
 }
 
 // 把配置序列化到传入结构体
-// 如果结构体和配置有差异，会尽量赋值，不会报错！
+// 结构体字段有 default tag 且配置中未设置时使用默认值, 例如 `default:"10"`
+// 指定了解密器时，结构体中 ENC(...) 包裹的字符串会被解密
+// 最后按 validate tag 校验, 例如 `validate:"required,min=1"`, 所有未通过的配置项合并为一个 *InvalidConfigError 返回
 func (c *Config) Unmarshal(dst interface{}) error {
-	return c.loader.Unmarshal(dst)
+	if err := c.loader.Unmarshal(dst); err != nil {
+		return err
+	}
+	if err := applyDefaults(dst, c.isSet); err != nil {
+		return err
+	}
+	if c.decrypter != nil {
+		if err := decryptStruct(c.decrypter, "", reflect.ValueOf(dst), map[uintptr]bool{}); err != nil {
+			return err
+		}
+	}
+	return validateStruct(dst)
+}
+
+func (c *Config) isSet(key string) bool {
+	val, err := c.loader.Get(key)
+	return err == nil && val != nil
 }
 
+// 指定了解密器时，ENC(...) 包裹的值会被解密后返回
 func (c *Config) Get(key string) (interface{}, error) {
-	return c.loader.Get(key)
+	val, err := c.loader.Get(key)
+	if err != nil || c.decrypter == nil {
+		return val, err
+	}
+	return decryptValue(c.decrypter, key, val)
+}
+
+// AllSettings 返回嵌套 map 形式的全部配置, 需要 loader 实现 Source 接口（内置的 loader 都已实现）
+// 指定了解密器时，ENC(...) 包裹的值会被解密后返回
+func (c *Config) AllSettings() (map[string]interface{}, error) {
+	s, ok := c.loader.(Source)
+	if !ok {
+		return nil, ErrConfigDumpUnsupport
+	}
+	settings := s.AllSettings()
+	if c.decrypter == nil {
+		return settings, nil
+	}
+	val, err := decryptValue(c.decrypter, "", settings)
+	if err != nil {
+		return nil, err
+	}
+	return val.(map[string]interface{}), nil
+}
+
+// Watch 开始监听配置变化, 需要 loader 实现 Watcher 接口
+// 配置变化后会依次检查 OnChange 订阅的 key, 值有变化则回调; ctx 取消后停止监听
+func (c *Config) Watch(ctx context.Context) error {
+	w, ok := c.loader.(Watcher)
+	if !ok {
+		return ErrConfigWatchUnsupport
+	}
+	return w.Watch(ctx, func(err error) {
+		if err != nil {
+			c.subs.fail(err)
+			return
+		}
+		c.subs.notify(c.Get)
+	})
+}
+
+// OnChange 订阅某个 key 的变化, 变化后回调 fn, old 为变化前的值, new 为变化后的值
+// key 被删除时 new 为 nil; 回调在监听协程中串行执行, 不要在回调中阻塞
+func (c *Config) OnChange(key string, fn func(old, new interface{})) {
+	val, _ := c.Get(key)
+	c.subs.add(key, val, fn)
+}
+
+// OnWatchError 配置变化后重新加载失败时回调 fn, 此时仍然使用旧配置
+func (c *Config) OnWatchError(fn func(err error)) {
+	c.subs.setErrHandler(fn)
+}
+
+// Origin 返回某个配置最终取自哪一层配置源, 需要 loader 实现 OriginReporter 接口（例如 Layered），否则返回空字符串
+func (c *Config) Origin(key string) string {
+	if r, ok := c.loader.(OriginReporter); ok {
+		return r.Origin(key)
+	}
+	return ""
+}
+
+// Origins 返回所有配置的来源, 需要 loader 实现 OriginReporter 接口（例如 Layered），否则返回 nil
+func (c *Config) Origins() map[string]string {
+	if r, ok := c.loader.(OriginReporter); ok {
+		return r.Origins()
+	}
+	return nil
 }
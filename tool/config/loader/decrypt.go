@@ -0,0 +1,199 @@
+package loader
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/senyu-up/toolbox/tool/encrypt"
+)
+
+var (
+	ErrAesKeyInvalid = errors.New("Aes key length must be 16, 24 or 32 ")
+)
+
+const (
+	encPrefix = "ENC("
+	encSuffix = ")"
+)
+
+// Decrypter 解密配置中 ENC(...) 包裹的密文, 例如 password: ENC(base64...)
+type Decrypter interface {
+	Decrypt(ciphertext string) ([]byte, error)
+}
+
+// RsaDecrypter 用 RSA 私钥（PKCS1, PEM 格式）解密, 密文由 encrypt.RsaEncrypt 生成
+type RsaDecrypter struct {
+	PrivateKey []byte
+}
+
+func (r *RsaDecrypter) Decrypt(ciphertext string) ([]byte, error) {
+	return encrypt.RsaDecrypt(ciphertext, r.PrivateKey)
+}
+
+// NewRsaDecrypterFromFile 从 PEM 格式的私钥文件创建 RsaDecrypter
+func NewRsaDecrypterFromFile(p string) (*RsaDecrypter, error) {
+	key, err := os.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	return &RsaDecrypter{PrivateKey: key}, nil
+}
+
+// AesGcmDecrypter 用 AES-GCM 解密, 密文由 encrypt.AesGCMEncrypt 生成
+type AesGcmDecrypter struct {
+	Key []byte // 16, 24 或 32 字节
+}
+
+func (a *AesGcmDecrypter) Decrypt(ciphertext string) ([]byte, error) {
+	return encrypt.AesGCMDecrypt(ciphertext, a.Key)
+}
+
+// NewAesGcmDecrypterFromFile 从密钥文件创建 AesGcmDecrypter, 密钥文件内容为 base64 编码的密钥
+func NewAesGcmDecrypterFromFile(p string) (*AesGcmDecrypter, error) {
+	key, err := ReadAesKeyFile(p)
+	if err != nil {
+		return nil, err
+	}
+	return &AesGcmDecrypter{Key: key}, nil
+}
+
+// ReadAesKeyFile 读取 base64 编码的 AES 密钥文件
+func ReadAesKeyFile(p string) ([]byte, error) {
+	content, err := os.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	key, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(content)))
+	if err != nil {
+		return nil, err
+	}
+	if l := len(key); l != 16 && l != 24 && l != 32 {
+		return nil, ErrAesKeyInvalid
+	}
+	return key, nil
+}
+
+// 是否为 ENC(...) 包裹的密文
+func isEncrypted(s string) bool {
+	return len(s) > len(encPrefix)+len(encSuffix) && strings.HasPrefix(s, encPrefix) && strings.HasSuffix(s, encSuffix)
+}
+
+func decryptString(d Decrypter, s string) (string, error) {
+	plain, err := d.Decrypt(strings.TrimSpace(s[len(encPrefix) : len(s)-len(encSuffix)]))
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+// 解密 Get 返回的值, map 与 slice 会复制一份, 不修改 loader 中的配置
+func decryptValue(d Decrypter, key string, v interface{}) (interface{}, error) {
+	switch val := v.(type) {
+	case string:
+		if !isEncrypted(val) {
+			return val, nil
+		}
+		plain, err := decryptString(d, val)
+		if err != nil {
+			return nil, fmt.Errorf("decrypt config %s err: %w", key, err)
+		}
+		return plain, nil
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			plain, err := decryptValue(d, joinKey(key, k), item)
+			if err != nil {
+				return nil, err
+			}
+			res[k] = plain
+		}
+		return res, nil
+	case []interface{}:
+		res := make([]interface{}, len(val))
+		for i, item := range val {
+			plain, err := decryptValue(d, fmt.Sprintf("%s[%d]", key, i), item)
+			if err != nil {
+				return nil, err
+			}
+			res[i] = plain
+		}
+		return res, nil
+	}
+	return v, nil
+}
+
+// 解密 Unmarshal 后结构体中的密文字段
+func decryptStruct(d Decrypter, path string, v reflect.Value, visited map[uintptr]bool) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() || visited[v.Pointer()] {
+			return nil
+		}
+		visited[v.Pointer()] = true
+		return decryptStruct(d, path, v.Elem(), visited)
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		elem := v.Elem()
+		if elem.Kind() == reflect.String && v.CanSet() {
+			// interface 中的值不可寻址, 复制后再设置回去
+			cp := reflect.New(elem.Type()).Elem()
+			cp.Set(elem)
+			if err := decryptStruct(d, path, cp, visited); err != nil {
+				return err
+			}
+			v.Set(cp)
+			return nil
+		}
+		return decryptStruct(d, path, elem, visited)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if !v.Field(i).CanSet() {
+				continue
+			}
+			if err := decryptStruct(d, joinKey(path, v.Type().Field(i).Name), v.Field(i), visited); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := decryptStruct(d, fmt.Sprintf("%s[%d]", path, i), v.Index(i), visited); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			// map 的值不可寻址, 复制后再设置回去
+			cp := reflect.New(v.Type().Elem()).Elem()
+			cp.Set(iter.Value())
+			if err := decryptStruct(d, joinKey(path, fmt.Sprint(iter.Key().Interface())), cp, visited); err != nil {
+				return err
+			}
+			v.SetMapIndex(iter.Key(), cp)
+		}
+	case reflect.String:
+		if !isEncrypted(v.String()) || !v.CanSet() {
+			return nil
+		}
+		plain, err := decryptString(d, v.String())
+		if err != nil {
+			return fmt.Errorf("decrypt config %s err: %w", path, err)
+		}
+		v.SetString(plain)
+	}
+	return nil
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
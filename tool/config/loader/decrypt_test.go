@@ -0,0 +1,159 @@
+package loader
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/senyu-up/toolbox/tool/config"
+	"github.com/senyu-up/toolbox/tool/encrypt"
+)
+
+func genRsaKey(t *testing.T) (pub, pri []byte) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate rsa key err %v", err)
+	}
+	pkix, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("marshal public key err %v", err)
+	}
+	pub = pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: pkix})
+	pri = pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	return
+}
+
+func TestConfig_Decrypt(t *testing.T) {
+	type AppConf struct {
+		Redis   config.RedisConfig
+		Mysql   config.MysqlConfig
+		Kafka   *config.KafkaConfig
+		Secrets map[string]string
+		Tokens  []string
+		Extra   interface{}
+	}
+	var (
+		dir      = t.TempDir()
+		aesKey   = []byte("0123456789abcdef0123456789abcdef")
+		keyFile  = filepath.Join(dir, "config.key")
+		priFile  = filepath.Join(dir, "private.pem")
+		pub, pri = genRsaKey(t)
+	)
+	writeFile(t, keyFile, base64.StdEncoding.EncodeToString(aesKey)+"\n")
+	writeFile(t, priFile, string(pri))
+
+	aesEnc := func(s string) string {
+		c, err := encrypt.AesGCMEncrypt([]byte(s), aesKey)
+		if err != nil {
+			t.Fatalf("aes encrypt err %v", err)
+		}
+		return "ENC(" + c + ")"
+	}
+	rsaEnc := func(s string) string {
+		c, err := encrypt.RsaEncrypt([]byte(s), pub)
+		if err != nil {
+			t.Fatalf("rsa encrypt err %v", err)
+		}
+		return "ENC(" + c + ")"
+	}
+	content := func(enc func(string) string) string {
+		return "redis:\n  password: " + enc("redis_pass") + "\n  addrs: [localhost:6379]\n" +
+			"mysql:\n  dsn: " + enc("root:pass@tcp(127.0.0.1:3306)/db") + "\n  master:\n    password: " + enc("master_pass") + "\n" +
+			"kafka:\n  password: " + enc("kafka_pass") + "\n" +
+			"secrets:\n  token: " + enc("secret_token") + "\n  plain: plain\n" +
+			"tokens:\n  - " + enc("token1") + "\n  - token2\n" +
+			"extra: " + enc("extra") + "\n"
+	}
+	var want = &AppConf{
+		Redis:   config.RedisConfig{Password: "redis_pass", Addrs: []string{"localhost:6379"}},
+		Mysql:   config.MysqlConfig{Dsn: "root:pass@tcp(127.0.0.1:3306)/db", Master: config.MysqlSingleConfig{Password: "master_pass"}},
+		Kafka:   &config.KafkaConfig{Password: "kafka_pass"},
+		Secrets: map[string]string{"token": "secret_token", "plain": "plain"},
+		Tokens:  []string{"token1", "token2"},
+		Extra:   "extra",
+	}
+	aesDecrypter, err := NewAesGcmDecrypterFromFile(keyFile)
+	if err != nil {
+		t.Fatalf("NewAesGcmDecrypterFromFile() error = %v", err)
+	}
+	rsaDecrypter, err := NewRsaDecrypterFromFile(priFile)
+	if err != nil {
+		t.Fatalf("NewRsaDecrypterFromFile() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		enc       func(string) string
+		decrypter Decrypter
+	}{
+		{name: "aes", enc: aesEnc, decrypter: aesDecrypter},
+		{name: "rsa", enc: rsaEnc, decrypter: rsaDecrypter},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := filepath.Join(dir, tt.name+".yaml")
+			writeFile(t, p, content(tt.enc))
+			c, err := InitConf(&File{}, ConfOptWithPath(p), ConfOptWithDecrypter(tt.decrypter))
+			if err != nil {
+				t.Fatalf("InitConf() error = %v", err)
+			}
+			var dst = &AppConf{}
+			if err = c.Unmarshal(dst); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if !reflect.DeepEqual(dst, want) {
+				t.Errorf("Unmarshal() got = %+v, want %+v", dst, want)
+			}
+			if got, err := c.Get("redis.password"); err != nil || got != "redis_pass" {
+				t.Errorf("Get() got = %v, err %v", got, err)
+			}
+			if got, err := c.Get("secrets"); err != nil || !reflect.DeepEqual(got, map[string]interface{}{"token": "secret_token", "plain": "plain"}) {
+				t.Errorf("Get() got = %v, err %v", got, err)
+			}
+			if got, err := c.Get("tokens"); err != nil || !reflect.DeepEqual(got, []interface{}{"token1", "token2"}) {
+				t.Errorf("Get() got = %v, err %v", got, err)
+			}
+
+			// 未指定解密器时原样返回
+			raw, _ := InitConf(&File{}, ConfOptWithPath(p))
+			if got, _ := raw.Get("redis.password"); !isEncrypted(got.(string)) {
+				t.Errorf("Get() without decrypter got = %v", got)
+			}
+		})
+	}
+}
+
+func TestConfig_DecryptErr(t *testing.T) {
+	var (
+		dir    = t.TempDir()
+		p      = filepath.Join(dir, "config.yaml")
+		aesKey = []byte("0123456789abcdef")
+	)
+	c, err := encrypt.AesGCMEncrypt([]byte("redis_pass"), aesKey)
+	if err != nil {
+		t.Fatalf("aes encrypt err %v", err)
+	}
+	writeFile(t, p, "redis:\n  password: ENC("+c+")\n")
+	conf, err := InitConf(&File{}, ConfOptWithPath(p), ConfOptWithDecrypter(&AesGcmDecrypter{Key: []byte("fedcba9876543210")}))
+	if err != nil {
+		t.Fatalf("InitConf() error = %v", err)
+	}
+	if _, err = conf.Get("redis.password"); err == nil {
+		t.Errorf("Get() error = nil, want err")
+	}
+	var dst = &struct{ Redis config.RedisConfig }{}
+	if err = conf.Unmarshal(dst); err == nil {
+		t.Errorf("Unmarshal() error = nil, want err")
+	}
+
+	writeFile(t, filepath.Join(dir, "short.key"), base64.StdEncoding.EncodeToString([]byte("short")))
+	if _, err = NewAesGcmDecrypterFromFile(filepath.Join(dir, "short.key")); !errors.Is(err, ErrAesKeyInvalid) {
+		t.Errorf("NewAesGcmDecrypterFromFile() error = %v, want %v", err, ErrAesKeyInvalid)
+	}
+}
@@ -0,0 +1,31 @@
+package loader
+
+import (
+	"strings"
+)
+
+// Defaults 内置的默认配置, 一般作为 Layered 的最底层
+// key 支持 "." 分隔的路径, 例如 {"redis.addr": "localhost:6379"} 等价于 {"redis": {"addr": "localhost:6379"}}
+type Defaults struct {
+	tree
+}
+
+// 参数说明
+// ConfOptWithDefaults 指定默认配置
+func (d *Defaults) Init(opts ...ConfOption) error {
+	var c = &Config{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	var settings = map[string]interface{}{}
+	for k, v := range c.defaults {
+		if m, ok := toStringMap(v); ok {
+			nested := map[string]interface{}{}
+			mergeTree(nested, m, "", nil)
+			v = nested
+		}
+		setByPath(settings, strings.Split(k, "."), v)
+	}
+	d.driver.Store(newDriver(settings))
+	return nil
+}
@@ -0,0 +1,112 @@
+package loader
+
+import (
+	"errors"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrEnvPrefixEmpty = errors.New("Env prefix empty ")
+)
+
+// Env 从环境变量读取配置, 环境变量名为 "前缀_配置路径" 的大写形式, 路径中的 "." 替换为 "_"
+// 例如前缀为 APP 时, APP_REDIS_ADDR => redis.addr
+// 配置名本身可能含有 "_"（例如 run_env），所以已知的配置 key 会优先按完整名称匹配（APP_RUN_ENV => run_env），
+// 其余的环境变量按 "_" 切分为嵌套路径
+// 值保持为字符串, Unmarshal 时按字段类型转换, 例如 APP_DB_PASSWORD=007 不会变成 7, 切片用 "," 分隔
+type Env struct {
+	tree
+	known []string // 已知的配置 key, 由 Layered 传入下层配置的 key
+}
+
+// 参数说明
+// ConfOptWithEnvPrefix 指定环境变量前缀, 必传
+func (e *Env) Init(opts ...ConfOption) error {
+	var c = &Config{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if 1 > len(c.envPrefix) {
+		return ErrEnvPrefixEmpty
+	}
+	e.driver.Store(newDriver(envSettings(c.envPrefix, e.known)))
+	return nil
+}
+
+func (e *Env) bindKeys(keys []string) {
+	e.known = keys
+}
+
+// 环境变量名, 例如 APP + redis.addr => APP_REDIS_ADDR
+func envName(prefix, key string) string {
+	return strings.ToUpper(strings.TrimRight(prefix, "_") + "_" + strings.ReplaceAll(key, ".", "_"))
+}
+
+// 读取所有带前缀的环境变量, 转换为嵌套 map
+func envSettings(prefix string, known []string) map[string]interface{} {
+	var (
+		settings = map[string]interface{}{}
+		envKeys  = make(map[string]string, len(known))
+		head     = strings.ToUpper(strings.TrimRight(prefix, "_") + "_")
+		environ  = os.Environ()
+	)
+	for _, key := range known {
+		envKeys[envName(prefix, key)] = key
+	}
+	sort.Strings(environ)
+	for _, kv := range environ {
+		name, val, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(strings.ToUpper(name), head) || len(name) == len(head) {
+			continue
+		}
+		if key, ok := envKeys[strings.ToUpper(name)]; ok {
+			setByPath(settings, strings.Split(key, "."), val)
+			continue
+		}
+		setByPath(settings, strings.Split(strings.ToLower(name[len(head):]), "_"), val)
+	}
+	return settings
+}
+
+// ${VAR} 或 ${VAR:-default}, $$ 为转义的 $
+var envPlaceholder = regexp.MustCompile(`\$\$|\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// 替换字符串中的环境变量占位符
+// ${VAR} 替换为环境变量 VAR 的值, 未设置则为空字符串; ${VAR:-default} 在 VAR 未设置或为空时使用 default; $${VAR} 不替换, 结果为 ${VAR}
+// 替换后的值保持为字符串, Unmarshal 时按字段类型转换, 例如 port: ${PORT:-8080} 可以解析到 int 字段
+func expandEnv(s string) string {
+	if !strings.Contains(s, "$") {
+		return s
+	}
+	return envPlaceholder.ReplaceAllStringFunc(s, func(m string) string {
+		if m == "$$" {
+			return "$"
+		}
+		sub := envPlaceholder.FindStringSubmatch(m)
+		val, ok := os.LookupEnv(sub[1])
+		if (!ok || val == "") && sub[2] != "" {
+			return sub[3]
+		}
+		return val
+	})
+}
+
+// 递归替换配置中所有字符串值的环境变量占位符
+func expandEnvTree(v interface{}) interface{} {
+	switch val := v.(type) {
+	case string:
+		return expandEnv(val)
+	case map[string]interface{}:
+		for k, item := range val {
+			val[k] = expandEnvTree(item)
+		}
+	case []interface{}:
+		for i, item := range val {
+			val[i] = expandEnvTree(item)
+		}
+	}
+	return v
+}
@@ -0,0 +1,129 @@
+package loader
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/senyu-up/toolbox/tool/config"
+)
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("EXPAND_HOST", "10.0.0.1")
+	t.Setenv("EXPAND_PORT", "6380")
+	t.Setenv("EXPAND_EMPTY", "")
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "localhost", want: "localhost"},
+		{in: "${EXPAND_HOST}", want: "10.0.0.1"},
+		{in: "${EXPAND_HOST}:${EXPAND_PORT}", want: "10.0.0.1:6380"},
+		{in: "${EXPAND_PORT}", want: "6380"},
+		{in: "${EXPAND_UNSET:-localhost}:${EXPAND_PORT:-6379}", want: "localhost:6380"},
+		{in: "${EXPAND_EMPTY:-default}", want: "default"},
+		{in: "${EXPAND_EMPTY}", want: ""},
+		{in: "${EXPAND_UNSET}", want: ""},
+		{in: "${EXPAND_UNSET:-true}", want: "true"},
+		{in: "${EXPAND_UNSET:-007}", want: "007"},
+		{in: "${EXPAND_UNSET:-1.10}", want: "1.10"},
+		{in: "${EXPAND_UNSET:-}", want: ""},
+		{in: "pa$$${EXPAND_PORT}", want: "pa$6380"},
+		{in: "$${EXPAND_HOST}", want: "${EXPAND_HOST}"},
+		{in: "pa$word", want: "pa$word"},
+		{in: "${1INVALID}", want: "${1INVALID}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := expandEnv(tt.in); got != tt.want {
+				t.Errorf("expandEnv() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFile_Env(t *testing.T) {
+	var (
+		dir  = t.TempDir()
+		yml  = filepath.Join(dir, "config.yaml")
+		toml = filepath.Join(dir, "config.toml")
+	)
+	writeFile(t, yml, `run_env: ${FILEENV_STAGE:-local}
+redisdb:
+  addrs:
+    - ${FILEENV_REDIS_HOST}:6379
+  db: ${FILEENV_REDIS_DB:-0}
+  password: ${FILEENV_REDIS_PASSWORD}
+mysql:
+  dsn: "root:${FILEENV_MYSQL_PASSWORD}@tcp(${FILEENV_MYSQL_HOST:-127.0.0.1}:3306)/db"
+`)
+	writeFile(t, toml, `run_env = "${FILEENV_STAGE:-local}"
+[redisdb]
+addrs = ["${FILEENV_REDIS_HOST}:6379"]
+db = "${FILEENV_REDIS_DB:-0}"
+password = "${FILEENV_REDIS_PASSWORD}"
+[mysql]
+dsn = "root:${FILEENV_MYSQL_PASSWORD}@tcp(${FILEENV_MYSQL_HOST:-127.0.0.1}:3306)/db"
+`)
+	t.Setenv("FILEENV_REDIS_HOST", "redis")
+	t.Setenv("FILEENV_REDIS_DB", "2")
+	t.Setenv("FILEENV_REDIS_PASSWORD", "secret")
+	t.Setenv("FILEENV_MYSQL_PASSWORD", "p@ss: word")
+	t.Setenv("APP_RUN_ENV", "production")
+	t.Setenv("APP_REDISDB_PASSWORD", "from_env")
+	t.Setenv("APP_MYSQL_MAXOPENCONN", "20")
+
+	type AppConf struct {
+		RunEnv  string `mapstructure:"run_env"`
+		RedisDb config.RedisConfig
+		Mysql   config.MysqlConfig
+	}
+	tests := []struct {
+		name  string
+		param []ConfOption
+		want  *AppConf
+	}{
+		{
+			name:  "yaml",
+			param: []ConfOption{ConfOptWithPath(yml)},
+			want: &AppConf{
+				RunEnv:  "local",
+				RedisDb: config.RedisConfig{Addrs: []string{"redis:6379"}, DB: 2, Password: "secret"},
+				Mysql:   config.MysqlConfig{Dsn: "root:p@ss: word@tcp(127.0.0.1:3306)/db"},
+			},
+		},
+		{
+			name:  "toml",
+			param: []ConfOption{ConfOptWithPath(toml)},
+			want: &AppConf{
+				RunEnv:  "local",
+				RedisDb: config.RedisConfig{Addrs: []string{"redis:6379"}, DB: 2, Password: "secret"},
+				Mysql:   config.MysqlConfig{Dsn: "root:p@ss: word@tcp(127.0.0.1:3306)/db"},
+			},
+		},
+		{
+			name:  "env prefix",
+			param: []ConfOption{ConfOptWithPath(yml), ConfOptWithEnvPrefix("APP")},
+			want: &AppConf{
+				RunEnv:  "production",
+				RedisDb: config.RedisConfig{Addrs: []string{"redis:6379"}, DB: 2, Password: "from_env"},
+				Mysql:   config.MysqlConfig{Dsn: "root:p@ss: word@tcp(127.0.0.1:3306)/db", MaxOpenConn: 20},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := InitConf(&File{}, tt.param...)
+			if err != nil {
+				t.Fatalf("InitConf() error = %v", err)
+			}
+			var dst = &AppConf{}
+			if err = c.Unmarshal(dst); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if !reflect.DeepEqual(dst, tt.want) {
+				t.Errorf("Unmarshal() got = %+v, want %+v", dst, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package loader
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/spf13/viper"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+var (
+	ErrEtcdAddrsEmpty = errors.New("Etcd addrs empty ")
+)
+
+const (
+	defaultEtcdPrefix      = "/config"
+	defaultEtcdDialTimeout = 5 * time.Second
+)
+
+// Etcd 从 etcd 的某个 key 前缀下读取配置
+// 前缀下的 key 按 "/" 切分后映射为嵌套的配置路径, 例如：
+//
+//	/config/redisdb/addr => redisdb.addr
+//
+// 前缀本身对应的 value 会被当作一份完整的配置文档（格式由 ConfOptWithType 指定，默认 yaml）解析，
+// 前缀下的子 key 会覆盖文档中的同名配置
+type Etcd struct {
+	tree
+	client *clientv3.Client
+
+	prefix      string
+	configType  string
+	dialTimeout time.Duration
+	debounce    time.Duration
+	reloadLock  sync.Mutex
+	rev         atomic.Int64 // 当前配置对应的 etcd revision, Watch 从下一个 revision 开始监听
+}
+
+// 参数说明
+// ConfOptWithEtcd 指定 etcd 连接配置, 必传
+// ConfOptWithPath 指定 key 前缀, 默认 /config
+// ConfOptWithType 指定前缀本身 value 的配置格式, 默认 yaml
+func (e *Etcd) Init(opts ...ConfOption) (err error) {
+	var (
+		c = &Config{
+			path:       defaultEtcdPrefix,
+			configType: "yaml",
+		}
+	)
+	for _, opt := range opts {
+		opt(c)
+	}
+	if 1 > len(c.etcd.Addrs) {
+		return ErrEtcdAddrsEmpty
+	}
+	e.dialTimeout = defaultEtcdDialTimeout
+	if 0 < c.etcd.DialTimeout {
+		e.dialTimeout = time.Duration(c.etcd.DialTimeout) * time.Second
+	}
+	e.prefix, e.configType, e.debounce = strings.TrimRight(c.path, "/"), c.configType, c.debounce
+
+	if e.client != nil {
+		_ = e.client.Close()
+	}
+	e.client, err = clientv3.New(clientv3.Config{
+		Endpoints:   c.etcd.Addrs,
+		Username:    c.etcd.User,
+		Password:    c.etcd.Password,
+		DialTimeout: e.dialTimeout,
+	})
+	if err != nil {
+		return err
+	}
+
+	driver, rev, err := e.load()
+	if err != nil {
+		return err
+	}
+	e.driver.Store(driver)
+	e.rev.Store(rev)
+	return nil
+}
+
+// 读取前缀下所有的 key, 解析为新的 viper 实例, 同时返回读取时的 revision
+func (e *Etcd) load() (*viper.Viper, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), e.dialTimeout)
+	defer cancel()
+	resp, err := e.client.Get(ctx, e.prefix, clientv3.WithPrefix(), clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var (
+		driver = viper.New()
+		tree   = map[string]interface{}{}
+	)
+	driver.SetConfigType(e.configType)
+	for _, kv := range resp.Kvs {
+		var key = string(kv.Key)
+		if key == e.prefix {
+			// 前缀本身为一份完整的配置
+			if err = driver.MergeConfig(bytes.NewReader(kv.Value)); err != nil {
+				return nil, 0, err
+			}
+			continue
+		}
+		if !strings.HasPrefix(key, e.prefix+"/") {
+			// 例如前缀为 /config 时的 /configx/a, 不属于当前前缀
+			continue
+		}
+		setByPath(tree, strings.Split(strings.Trim(key[len(e.prefix):], "/"), "/"), parseValue(kv.Value))
+	}
+	if err = driver.MergeConfigMap(tree); err != nil {
+		return nil, 0, err
+	}
+	return driver, resp.Header.Revision, nil
+}
+
+// Watch 监听前缀下 key 的变化, 变化后重新读取整个前缀并整体替换配置, 读取失败则保留旧配置
+// 从 Init 读取配置时的下一个 revision 开始监听, 读取与监听之间的变化不会漏掉
+func (e *Etcd) Watch(ctx context.Context, onChange func(err error)) error {
+	reload := newDebouncer(e.debounce, func() {
+		e.reloadLock.Lock()
+		defer e.reloadLock.Unlock()
+		driver, rev, err := e.load()
+		if err == nil {
+			e.driver.Store(driver)
+			e.rev.Store(rev)
+		}
+		onChange(err)
+	})
+
+	rev := e.rev.Load() + 1
+	go func() {
+		defer reload.stop()
+		for {
+			var compacted int64
+			for resp := range e.client.Watch(clientv3.WithRequireLeader(ctx), e.prefix, clientv3.WithPrefix(), clientv3.WithRev(rev)) {
+				if 0 < resp.CompactRevision {
+					compacted = resp.CompactRevision
+					continue
+				}
+				if err := resp.Err(); err != nil {
+					onChange(err)
+					continue
+				}
+				if 0 < len(resp.Events) {
+					reload.trigger()
+				}
+			}
+			if 1 > compacted || ctx.Err() != nil {
+				return
+			}
+			// 起始 revision 已被压缩, 可能漏掉了变化, 重新读取并从压缩后的 revision 继续监听
+			rev = compacted
+			reload.trigger()
+		}
+	}()
+	return nil
+}
+
+// Close 关闭 etcd 连接
+func (e *Etcd) Close() error {
+	if e.client == nil {
+		return nil
+	}
+	return e.client.Close()
+}
@@ -0,0 +1,266 @@
+package loader
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/senyu-up/toolbox/tool/config"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/server/v3/embed"
+)
+
+func freeUrl(t *testing.T) url.URL {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen err %v", err)
+	}
+	defer l.Close()
+	u, _ := url.Parse(fmt.Sprintf("http://%s", l.Addr().String()))
+	return *u
+}
+
+// 启动一个内嵌的 etcd 服务, 并写入 kvs
+func startEtcd(t *testing.T, kvs map[string]string) config.Etcd {
+	cfg := embed.NewConfig()
+	cfg.Dir = t.TempDir()
+	cfg.LogLevel = "error"
+	clientUrl, peerUrl := freeUrl(t), freeUrl(t)
+	cfg.ListenClientUrls, cfg.AdvertiseClientUrls = []url.URL{clientUrl}, []url.URL{clientUrl}
+	cfg.ListenPeerUrls, cfg.AdvertisePeerUrls = []url.URL{peerUrl}, []url.URL{peerUrl}
+	cfg.InitialCluster = cfg.InitialClusterFromName(cfg.Name)
+
+	e, err := embed.StartEtcd(cfg)
+	if err != nil {
+		t.Fatalf("start etcd err %v", err)
+	}
+	t.Cleanup(e.Close)
+	select {
+	case <-e.Server.ReadyNotify():
+	case <-time.After(10 * time.Second):
+		t.Fatalf("etcd start timeout")
+	}
+
+	conf := config.Etcd{Addrs: []string{clientUrl.Host}}
+	cli, err := clientv3.New(clientv3.Config{Endpoints: conf.Addrs, DialTimeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("new etcd client err %v", err)
+	}
+	defer cli.Close()
+	for k, v := range kvs {
+		if _, err = cli.Put(context.Background(), k, v); err != nil {
+			t.Fatalf("put %s err %v", k, err)
+		}
+	}
+	return conf
+}
+
+func TestEtcd_Get(t *testing.T) {
+	conf := startEtcd(t, map[string]string{
+		"/config":                   "run_env: local\nmysql:\n  user: root\n  dsn: from_doc\n",
+		"/config/mysql/dsn":         "from_key",
+		"/config/redisdb/addr":      "localhost:6379",
+		"/config/redisdb/db":        "1",
+		"/config/redisdb/isCluster": "true",
+		"/config/redisdb/addrs":     "[localhost:6379, localhost:6378]",
+		"/configx/redisdb/addr":     "other",
+		"/other/redisdb/addr":       "other",
+	})
+
+	tests := []struct {
+		name    string
+		param   []ConfOption
+		key     string
+		want    interface{}
+		wantErr bool
+	}{
+		{name: "1", param: []ConfOption{ConfOptWithEtcd(conf)}, key: "redisdb.addr", want: "localhost:6379"},
+		{name: "2", param: []ConfOption{ConfOptWithEtcd(conf)}, key: "redisdb.db", want: 1},
+		{name: "3", param: []ConfOption{ConfOptWithEtcd(conf)}, key: "redisdb.iscluster", want: true},
+		{name: "4", param: []ConfOption{ConfOptWithEtcd(conf)}, key: "run_env", want: "local"},
+		{name: "5", param: []ConfOption{ConfOptWithEtcd(conf)}, key: "mysql.user", want: "root"},
+		{name: "6", param: []ConfOption{ConfOptWithEtcd(conf), ConfOptWithPath("/config/")}, key: "mysql.dsn", want: "from_key"},
+		{name: "7", param: []ConfOption{ConfOptWithEtcd(conf), ConfOptWithPath("/other")}, key: "redisdb.addr", want: "other"},
+		{name: "e1", param: []ConfOption{ConfOptWithEtcd(conf)}, key: "env", want: nil, wantErr: true},
+		{name: "e2", param: []ConfOption{ConfOptWithEtcd(conf), ConfOptWithPath("/other")}, key: "run_env", want: nil, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Etcd{}
+			defer e.Close()
+			c, err := InitConf(e, tt.param...)
+			if err != nil {
+				t.Fatalf("InitConf() error = %v", err)
+			}
+			got, err := c.Get(tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Get() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Get() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEtcd_Unmarshal(t *testing.T) {
+	type AppConf struct {
+		RedisDb config.RedisConfig
+		Mysql   config.MysqlConfig
+	}
+	conf := startEtcd(t, map[string]string{
+		"/app/mysql/dsn":         "root:xxx@tcp(127.0.0.1:3306)/center_service",
+		"/app/redisdb/addrs":     "[localhost:6379]",
+		"/app/redisdb/iscluster": "true",
+		"/app/redisdb/db":        "1",
+		"/empty_other/mysql/dsn": "",
+	})
+	var want = &AppConf{
+		RedisDb: config.RedisConfig{Addrs: []string{"localhost:6379"}, IsCluster: true, DB: 1},
+		Mysql:   config.MysqlConfig{Dsn: "root:xxx@tcp(127.0.0.1:3306)/center_service"},
+	}
+
+	tests := []struct {
+		name    string
+		param   []ConfOption
+		want    interface{}
+		wantErr bool
+	}{
+		{name: "1", param: []ConfOption{ConfOptWithEtcd(conf), ConfOptWithPath("/app")}, want: want},
+		{name: "e1", param: []ConfOption{ConfOptWithEtcd(conf), ConfOptWithPath("/empty")}, want: &AppConf{}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Etcd{}
+			defer e.Close()
+			c, err := InitConf(e, tt.param...)
+			if err != nil {
+				t.Fatalf("InitConf() error = %v", err)
+			}
+			var dst = &AppConf{}
+			if err = c.Unmarshal(dst); (err != nil) != tt.wantErr {
+				t.Errorf("Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(dst, tt.want) {
+				t.Errorf("Unmarshal() got = %+v, want %+v", dst, tt.want)
+			}
+		})
+	}
+}
+
+func TestEtcd_InitErr(t *testing.T) {
+	if _, err := InitConf(&Etcd{}); err != ErrEtcdAddrsEmpty {
+		t.Errorf("InitConf() error = %v, want %v", err, ErrEtcdAddrsEmpty)
+	}
+}
+
+func TestEtcd_Watch(t *testing.T) {
+	conf := startEtcd(t, map[string]string{
+		"/watch/redisdb/addr": "localhost:6379",
+	})
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		changes     = make(chan [2]interface{}, 10)
+		e           = &Etcd{}
+	)
+	defer cancel()
+	defer e.Close()
+	c, err := InitConf(e, ConfOptWithEtcd(conf), ConfOptWithPath("/watch"), ConfOptWithDebounce(50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("InitConf() error = %v", err)
+	}
+	c.OnChange("redisdb.addr", func(old, new interface{}) {
+		changes <- [2]interface{}{old, new}
+	})
+	if err = c.Watch(ctx); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+
+	cli, err := clientv3.New(clientv3.Config{Endpoints: conf.Addrs, DialTimeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("new etcd client err %v", err)
+	}
+	defer cli.Close()
+	if _, err = cli.Put(context.Background(), "/watch/redisdb/addr", "localhost:6378"); err != nil {
+		t.Fatalf("put err %v", err)
+	}
+	select {
+	case got := <-changes:
+		if got[0] != "localhost:6379" || got[1] != "localhost:6378" {
+			t.Errorf("OnChange() got %v", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("OnChange() not called")
+	}
+
+	if _, err = cli.Delete(context.Background(), "/watch/redisdb/addr"); err != nil {
+		t.Fatalf("delete err %v", err)
+	}
+	select {
+	case got := <-changes:
+		if got[0] != "localhost:6378" || got[1] != nil {
+			t.Errorf("OnChange() got %v", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("OnChange() not called after delete")
+	}
+}
+
+func TestEtcd_WatchFromLoadedRev(t *testing.T) {
+	conf := startEtcd(t, map[string]string{
+		"/rev/redisdb/addr": "localhost:6379",
+	})
+	cli, err := clientv3.New(clientv3.Config{Endpoints: conf.Addrs, DialTimeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("new etcd client err %v", err)
+	}
+	defer cli.Close()
+
+	for _, compact := range []bool{false, true} {
+		t.Run(fmt.Sprintf("compact %v", compact), func(t *testing.T) {
+			var (
+				ctx, cancel = context.WithCancel(context.Background())
+				changes     = make(chan interface{}, 10)
+				e           = &Etcd{}
+			)
+			defer cancel()
+			defer e.Close()
+			c, err := InitConf(e, ConfOptWithEtcd(conf), ConfOptWithPath("/rev"), ConfOptWithDebounce(50*time.Millisecond))
+			if err != nil {
+				t.Fatalf("InitConf() error = %v", err)
+			}
+			c.OnChange("redisdb.addr", func(old, new interface{}) {
+				changes <- new
+			})
+
+			// 读取配置之后, Watch 之前的变化
+			want := fmt.Sprintf("localhost:%v", compact)
+			resp, err := cli.Put(context.Background(), "/rev/redisdb/addr", want)
+			if err != nil {
+				t.Fatalf("put err %v", err)
+			}
+			if compact {
+				// 压缩之后从读取时的 revision 开始监听会失败, 需要重新读取
+				if _, err = cli.Compact(context.Background(), resp.Header.Revision); err != nil {
+					t.Fatalf("compact err %v", err)
+				}
+			}
+			if err = c.Watch(ctx); err != nil {
+				t.Fatalf("Watch() error = %v", err)
+			}
+			select {
+			case got := <-changes:
+				if got != want {
+					t.Errorf("OnChange() got %v, want %v", got, want)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("change between Init and Watch is missed")
+			}
+		})
+	}
+}
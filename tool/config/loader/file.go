@@ -1,18 +1,42 @@
 package loader
 
 import (
+	"context"
+	"errors"
+	"github.com/fsnotify/fsnotify"
+	"github.com/senyu-up/toolbox/enum"
+	"github.com/spf13/cast"
 	"github.com/spf13/viper"
+	"io/fs"
+	"os"
 	"path"
+	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type File struct {
-	driver *viper.Viper
+	tree
+
+	path       string
+	configType string
+	envPrefix  string
+	stage      string
+	debounce   time.Duration
+	reloadLock sync.Mutex
+	files      atomic.Pointer[[]string] // 实际加载的配置文件, 基础配置文件与 stage 配置文件
+	noStage    bool                     // 不合并 stage 配置文件, LayerFile 中 stage 配置文件作为单独的一层
 }
 
-// 接受最多两个参数
-// 第一个参数指定文件路径，
-// 第二个参数指定配置类型，例如：yaml，toml，json，如果不传第二个，则通过文件拓展名自动判断
+// 参数说明
+// ConfOptWithPath 指定文件路径，默认 config.yaml
+// ConfOptWithType 指定配置类型，例如：yaml，toml，json，如果不传，则通过文件拓展名自动判断
+// ConfOptWithEnvPrefix 指定环境变量前缀，可选，例如 APP，则环境变量 APP_REDIS_ADDR 会覆盖配置 redis.addr
+// ConfOptWithStage 指定 stage，可选，不传则依次从环境变量 stage、配置文件的 app.stage 获取
+//
+// 确定 stage 后，会把同目录下的 stage 配置文件（例如 config.develop.yaml）深度合并到基础配置上，stage 配置文件不存在则忽略
 func (f *File) Init(opts ...ConfOption) error {
 	var (
 		c = &Config{
@@ -25,27 +49,127 @@ func (f *File) Init(opts ...ConfOption) error {
 	if 1 > len(c.configType) {
 		c.configType = strings.Trim(path.Ext(c.path), ".") // 获取的ext带点！
 	}
+	f.path, f.configType, f.envPrefix, f.stage, f.debounce = c.path, c.configType, c.envPrefix, c.stage, c.debounce
+
+	driver, err := f.load()
+	f.driver.Store(driver)
+	return err
+}
+
+// 读取并解析配置文件, 返回新的 viper 实例
+// 配置值中的 ${VAR}, ${VAR:-default} 会替换为环境变量; 指定了环境变量前缀时, 对应的环境变量会覆盖文件中的配置
+func (f *File) load() (*viper.Viper, error) {
+	driver, err := f.read(f.path)
+	if err != nil {
+		return driver, err
+	}
+	var (
+		files    = []string{filepath.Clean(f.path)}
+		settings = expandEnvTree(driver.AllSettings()).(map[string]interface{})
+		stage    = f.stage
+	)
+	if 1 > len(stage) {
+		stage = os.Getenv(enum.StageKey)
+	}
+	if 0 < len(stage) {
+		// stage 来自参数或者环境变量时, 同步到 app.stage
+		setByPath(settings, []string{"app", "stage"}, stage)
+	} else if app, ok := settings["app"].(map[string]interface{}); ok {
+		stage = cast.ToString(app["stage"])
+	}
+	if 0 < len(stage) && !f.noStage {
+		stageFile := stagePath(f.path, stage)
+		files = append(files, filepath.Clean(stageFile))
+		stageDriver, err := f.read(stageFile)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return driver, err
+		} else if err == nil {
+			mergeTree(settings, expandEnvTree(stageDriver.AllSettings()).(map[string]interface{}), "", nil)
+		}
+	}
+	if 0 < len(f.envPrefix) {
+		mergeTree(settings, envSettings(f.envPrefix, leafKeys(settings)), "", nil)
+	}
+	f.files.Store(&files)
+	return newDriver(settings), nil
+}
 
-	f.driver = viper.New()
-	f.driver.SetConfigType(c.configType)
-	f.driver.SetConfigFile(c.path)
+func (f *File) read(p string) (*viper.Viper, error) {
+	driver := viper.New()
+	driver.SetConfigType(f.configType)
+	driver.SetConfigFile(p)
+	return driver, driver.ReadInConfig()
+}
 
-	return f.driver.ReadInConfig()
+// stage 配置文件路径, 例如 ./config.yaml + develop => ./config.develop.yaml
+func stagePath(p, stage string) string {
+	ext := filepath.Ext(p)
+	return strings.TrimSuffix(p, ext) + "." + stage + ext
 }
 
-func (f *File) Get(key string) (interface{}, error) {
-	var err error = nil
-	if !f.driver.IsSet(key) {
-		err = ErrConfigKeyNotSet
+// Watch 监听配置文件所在目录, 配置文件被修改、重新创建，或者软链接指向变化（例如 k8s ConfigMap 更新）时重新加载
+// 重新加载成功后整体替换配置, 失败则保留旧配置
+func (f *File) Watch(ctx context.Context, onChange func(err error)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	var (
+		configFile  = filepath.Clean(f.path)
+		realFile, _ = filepath.EvalSymlinks(configFile)
+	)
+	// 监听整个目录, 以便感知编辑器的 rename 保存方式
+	if err = watcher.Add(filepath.Dir(configFile)); err != nil {
+		_ = watcher.Close()
+		return err
 	}
-	return f.driver.Get(key), err
+
+	reload := newDebouncer(f.debounce, func() {
+		f.reloadLock.Lock()
+		defer f.reloadLock.Unlock()
+		driver, err := f.load()
+		if err == nil {
+			f.driver.Store(driver)
+		}
+		onChange(err)
+	})
+
+	go func() {
+		defer watcher.Close()
+		defer reload.stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				currentFile, _ := filepath.EvalSymlinks(configFile)
+				if (f.isLoaded(filepath.Clean(event.Name)) && (event.Has(fsnotify.Write) || event.Has(fsnotify.Create))) ||
+					(currentFile != "" && currentFile != realFile) {
+					realFile = currentFile
+					reload.trigger()
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				onChange(err)
+			}
+		}
+	}()
+	return nil
 }
 
-func (f *File) Unmarshal(dst interface{}) error {
-	keys := f.driver.AllKeys()
-	if 1 > len(keys) {
-		// 如果配置为空，报错
-		return ErrConfigEmpty
+// 是否为已加载的配置文件
+func (f *File) isLoaded(name string) bool {
+	if files := f.files.Load(); files != nil {
+		for _, file := range *files {
+			if file == name {
+				return true
+			}
+		}
 	}
-	return f.driver.Unmarshal(dst)
+	return false
 }
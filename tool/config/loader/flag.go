@@ -0,0 +1,50 @@
+package loader
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+var (
+	ErrFlagSetEmpty = errors.New("Flag set empty ")
+)
+
+// Flags 从命令行参数读取配置, 只有命令行中显式指定的参数才会生效, 参数的默认值不会覆盖其他配置
+// 参数名即配置路径（例如 --redis.addr），也可以通过 ConfOptWithFlags 的 keys 指定参数名与配置路径的映射
+type Flags struct {
+	tree
+}
+
+// 参数说明
+// ConfOptWithFlags 指定命令行参数集合, 必传
+func (f *Flags) Init(opts ...ConfOption) error {
+	var c = &Config{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.flags == nil {
+		return ErrFlagSetEmpty
+	}
+	var settings = map[string]interface{}{}
+	c.flags.Visit(func(flag *pflag.Flag) {
+		key, ok := c.flagKeys[flag.Name]
+		if !ok {
+			key = flag.Name
+		}
+		setByPath(settings, strings.Split(key, "."), flagValue(flag))
+	})
+	f.driver.Store(newDriver(settings))
+	return nil
+}
+
+func flagValue(flag *pflag.Flag) interface{} {
+	if sv, ok := flag.Value.(pflag.SliceValue); ok {
+		return sv.GetSlice()
+	}
+	if flag.Value.Type() == "string" {
+		return flag.Value.String()
+	}
+	return parseValue([]byte(flag.Value.String()))
+}
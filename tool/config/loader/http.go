@@ -0,0 +1,268 @@
+package loader
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+var (
+	ErrHttpUrlEmpty = errors.New("Http config url empty ")
+	ErrHttpStatus   = errors.New("Http config unexpected status ")
+	// ErrHttpCacheUnsafe 本地副本不属于当前用户或其他用户可写
+	ErrHttpCacheUnsafe = errors.New("Http config cache file unsafe ")
+)
+
+const (
+	defaultHttpTimeout  = 10 * time.Second
+	defaultPollInterval = 30 * time.Second
+)
+
+// HTTP 从 http(s) 地址拉取 yaml, json, toml 格式的配置
+// Watch 时按间隔轮询, 请求带上 If-None-Match, 服务端返回 304 则认为配置未变化
+// 指定了本地副本时, 每次成功拉取后把配置写入本地副本, 启动时服务端不可用则使用本地副本
+type HTTP struct {
+	tree
+
+	url          string
+	configType   string
+	client       *http.Client
+	header       http.Header
+	pollInterval time.Duration
+	cacheFile    string
+
+	lock sync.Mutex
+	etag string // 最近一次响应的 ETag
+	sum  string // 最近一次配置内容的 sha256, 服务端不支持 ETag 时用于判断配置是否变化
+}
+
+// 参数说明
+// ConfOptWithPath 指定配置地址, 必传, 例如 https://config.example.com/app/config.yaml
+// ConfOptWithType 指定配置类型，例如：yaml，toml，json，如果不传，则依次通过地址的拓展名、响应的 Content-Type 判断，默认 yaml
+// ConfOptWithHttpClient 指定 http client，可选，默认超时 10s
+// ConfOptWithHttpHeader 指定请求 header，可选，例如鉴权 token
+// ConfOptWithPollInterval 指定 Watch 时轮询的间隔，可选，默认 30s
+// ConfOptWithCacheFile 指定本地副本路径，可选，不指定时不使用本地副本；地址没有拓展名时，本地副本按其拓展名解析，例如 config.cache.json
+func (h *HTTP) Init(opts ...ConfOption) error {
+	var (
+		c = &Config{}
+	)
+	for _, opt := range opts {
+		opt(c)
+	}
+	if 1 > len(c.path) {
+		return ErrHttpUrlEmpty
+	}
+	h.url, h.configType, h.header, h.cacheFile = c.path, c.configType, c.httpHeader, c.cacheFile
+	if 1 > len(h.configType) {
+		if u, err := url.Parse(h.url); err == nil {
+			h.configType = strings.Trim(path.Ext(u.Path), ".")
+		}
+	}
+	h.client = c.httpClient
+	if h.client == nil {
+		h.client = &http.Client{Timeout: defaultHttpTimeout}
+	}
+	h.pollInterval = c.pollInterval
+	if 0 >= h.pollInterval {
+		h.pollInterval = defaultPollInterval
+	}
+	h.etag, h.sum = "", ""
+
+	_, err := h.reload(context.Background())
+	if err == nil {
+		return nil
+	}
+	// 服务端不可用时使用本地副本, 配置本身有误时直接报错
+	if 1 > len(h.cacheFile) || !errors.Is(err, ErrHttpStatus) && !isNetErr(err) {
+		return err
+	}
+	body, cacheErr := readCacheFile(h.cacheFile)
+	if cacheErr != nil {
+		return errors.Join(err, cacheErr)
+	}
+	// 类型由参数或地址的拓展名确定, 否则按本地副本的拓展名
+	configType := h.configType
+	if ext := strings.Trim(filepath.Ext(h.cacheFile), "."); 1 > len(configType) && slices.Contains(viper.SupportedExts, ext) {
+		configType = ext
+	}
+	driver, parseErr := h.parse(body, configType)
+	if parseErr != nil {
+		return errors.Join(err, fmt.Errorf("parse http config cache file %s err: %w", h.cacheFile, parseErr))
+	}
+	h.driver.Store(driver)
+	return nil
+}
+
+// 拉取配置, 有变化则整体替换, 并写入本地副本; 返回配置是否有变化
+func (h *HTTP) reload(ctx context.Context) (bool, error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.url, nil)
+	if err != nil {
+		return false, err
+	}
+	for k, v := range h.header {
+		req.Header[k] = v
+	}
+	if 0 < len(h.etag) {
+		req.Header.Set("If-None-Match", h.etag)
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return false, &netErr{err: err}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("%w%d, url %s", ErrHttpStatus, resp.StatusCode, h.url)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return false, &netErr{err: err}
+	}
+
+	sum := sha256.Sum256(body)
+	if hex.EncodeToString(sum[:]) == h.sum {
+		h.etag = resp.Header.Get("ETag")
+		return false, nil
+	}
+	configType := h.configType
+	if 1 > len(configType) {
+		configType = typeFromContentType(resp.Header.Get("Content-Type"))
+	}
+	driver, err := h.parse(body, configType)
+	if err != nil {
+		return false, err
+	}
+	h.driver.Store(driver)
+	h.etag, h.sum = resp.Header.Get("ETag"), hex.EncodeToString(sum[:])
+	if 1 > len(h.configType) {
+		h.configType = configType
+	}
+	// 本地副本只是兜底, 写入失败不影响使用
+	if 0 < len(h.cacheFile) {
+		_ = writeFileAtomic(h.cacheFile, body)
+	}
+	return true, nil
+}
+
+func (h *HTTP) parse(body []byte, configType string) (*viper.Viper, error) {
+	if 1 > len(configType) {
+		configType = "yaml"
+	}
+	driver := viper.New()
+	driver.SetConfigType(configType)
+	if err := driver.ReadConfig(bytes.NewReader(body)); err != nil {
+		return nil, err
+	}
+	return newDriver(expandEnvTree(driver.AllSettings()).(map[string]interface{})), nil
+}
+
+// Watch 按间隔轮询配置地址, 配置有变化时整体替换; 请求失败或配置解析失败时保留旧配置, 并把错误传给 onChange
+func (h *HTTP) Watch(ctx context.Context, onChange func(err error)) error {
+	go func() {
+		ticker := time.NewTicker(h.pollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				changed, err := h.reload(ctx)
+				if ctx.Err() != nil {
+					return
+				}
+				if changed || err != nil {
+					onChange(err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// 请求失败的错误, 用于区分服务端不可用与配置解析失败
+type netErr struct {
+	err error
+}
+
+func (e *netErr) Error() string {
+	return e.err.Error()
+}
+
+func (e *netErr) Unwrap() error {
+	return e.err
+}
+
+func isNetErr(err error) bool {
+	var e *netErr
+	return errors.As(err, &e)
+}
+
+func typeFromContentType(contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case strings.HasSuffix(mediaType, "json"):
+		return "json"
+	case strings.HasSuffix(mediaType, "toml"):
+		return "toml"
+	}
+	return "yaml"
+}
+
+// readCacheFile 读取本地副本, 文件不属于当前用户或其他用户可写时拒绝, 避免被注入配置
+func readCacheFile(p string) ([]byte, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if err = checkCacheOwner(fi); err != nil {
+		return nil, fmt.Errorf("%w, file %s", err, p)
+	}
+	return io.ReadAll(f)
+}
+
+// writeFileAtomic 先写临时文件再 rename, 避免写到一半时进程退出导致副本损坏, 文件权限为 0600
+func writeFileAtomic(p string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(p), filepath.Base(p)+".tmp*")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package loader
+
+import "os"
+
+// checkCacheOwner 非 unix 平台没有文件属主信息, 不检查
+func checkCacheOwner(fi os.FileInfo) error {
+	return nil
+}
@@ -0,0 +1,19 @@
+//go:build unix
+
+package loader
+
+import (
+	"os"
+	"syscall"
+)
+
+// checkCacheOwner 本地副本需要属于当前用户, 且组和其他用户不可写
+func checkCacheOwner(fi os.FileInfo) error {
+	if fi.Mode().Perm()&0022 != 0 {
+		return ErrHttpCacheUnsafe
+	}
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok && int(st.Uid) != os.Getuid() {
+		return ErrHttpCacheUnsafe
+	}
+	return nil
+}
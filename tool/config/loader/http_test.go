@@ -0,0 +1,245 @@
+package loader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 配置服务端, 支持 ETag
+type configServer struct {
+	lock        sync.Mutex
+	body        string
+	contentType string
+	version     int
+	etag        bool
+	hits        atomic.Int32
+	notModified atomic.Int32
+}
+
+func (s *configServer) set(body string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.body = body
+	s.version++
+}
+
+func (s *configServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.hits.Add(1)
+	if r.Header.Get("Authorization") != "Bearer token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if s.etag {
+		etag := fmt.Sprintf(`"v%d"`, s.version)
+		if r.Header.Get("If-None-Match") == etag {
+			s.notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+	}
+	if s.contentType != "" {
+		w.Header().Set("Content-Type", s.contentType)
+	}
+	_, _ = w.Write([]byte(s.body))
+}
+
+func TestHTTP_Init(t *testing.T) {
+	var (
+		dir    = t.TempDir()
+		header = http.Header{"Authorization": []string{"Bearer token"}}
+	)
+	tests := []struct {
+		name        string
+		path        string
+		contentType string
+		body        string
+		header      http.Header
+		want        interface{}
+		wantErr     bool
+	}{
+		{name: "yaml", path: "/config.yaml", body: "redis:\n  addr: yaml:6379\n", header: header, want: "yaml:6379"},
+		{name: "json content type", path: "/config", contentType: "application/json; charset=utf-8", body: `{"redis": {"addr": "json:6379"}}`, header: header, want: "json:6379"},
+		{name: "toml", path: "/config.toml", body: "[redis]\naddr = \"toml:6379\"\n", header: header, want: "toml:6379"},
+		{name: "unauthorized", path: "/config.yaml", body: "redis:\n  addr: yaml:6379\n", wantErr: true},
+		{name: "invalid", path: "/config.yaml", body: "redis: [", header: header, wantErr: true},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &configServer{body: tt.body, contentType: tt.contentType}
+			ts := httptest.NewServer(server)
+			defer ts.Close()
+			c, err := InitConf(&HTTP{}, ConfOptWithPath(ts.URL+tt.path), ConfOptWithHttpHeader(tt.header),
+				ConfOptWithCacheFile(filepath.Join(dir, fmt.Sprintf("cache%d", i))))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("InitConf() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got, err := c.Get("redis.addr"); err != nil || got != tt.want {
+				t.Errorf("Get() got = %v, err %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTP_Fallback(t *testing.T) {
+	var (
+		cacheFile = filepath.Join(t.TempDir(), "cache", "config.yaml")
+		server    = &configServer{body: "redis:\n  addr: localhost:6379\n", etag: true}
+		ts        = httptest.NewServer(server)
+		url       = ts.URL + "/config.yaml"
+		opts      = []ConfOption{ConfOptWithPath(url), ConfOptWithCacheFile(cacheFile),
+			ConfOptWithHttpHeader(http.Header{"Authorization": []string{"Bearer token"}})}
+	)
+	if _, err := InitConf(&HTTP{}, opts...); err != nil {
+		t.Fatalf("InitConf() error = %v", err)
+	}
+	ts.Close()
+
+	// 服务端不可用, 使用本地副本
+	c, err := InitConf(&HTTP{}, opts...)
+	if err != nil {
+		t.Fatalf("InitConf() fallback error = %v", err)
+	}
+	if got, err := c.Get("redis.addr"); err != nil || got != "localhost:6379" {
+		t.Errorf("Get() got = %v, err %v", got, err)
+	}
+	if runtime.GOOS != "windows" {
+		if fi, err := os.Stat(cacheFile); err != nil || fi.Mode().Perm() != 0600 {
+			t.Errorf("cache file mode = %v, err %v, want 0600", fi.Mode().Perm(), err)
+		}
+		// 其他用户可写的本地副本不使用
+		if err = os.Chmod(cacheFile, 0666); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = InitConf(&HTTP{}, opts...); err == nil {
+			t.Errorf("InitConf() with unsafe cache error = nil, want err")
+		}
+	}
+
+	// 默认不使用本地副本
+	if _, err = InitConf(&HTTP{}, ConfOptWithPath(url)); err == nil {
+		t.Errorf("InitConf() without cache file error = nil, want err")
+	}
+	// 没有本地副本时报错
+	_, err = InitConf(&HTTP{}, ConfOptWithPath(url), ConfOptWithCacheFile(filepath.Join(t.TempDir(), "none.yaml")))
+	if err == nil {
+		t.Errorf("InitConf() without cache error = nil, want err")
+	}
+	if _, err = InitConf(&HTTP{}); !errors.Is(err, ErrHttpUrlEmpty) {
+		t.Errorf("InitConf() error = %v, want %v", err, ErrHttpUrlEmpty)
+	}
+}
+
+func TestHTTP_FallbackType(t *testing.T) {
+	var (
+		dir    = t.TempDir()
+		server = &configServer{body: "[redis]\naddr = \"toml:6379\"\n", contentType: "application/toml"}
+		ts     = httptest.NewServer(server)
+		header = ConfOptWithHttpHeader(http.Header{"Authorization": []string{"Bearer token"}})
+	)
+	// 地址没有拓展名, 类型来自 Content-Type, 本地副本按其拓展名解析
+	opts := []ConfOption{ConfOptWithPath(ts.URL + "/config"), header, ConfOptWithCacheFile(filepath.Join(dir, "config.cache.toml"))}
+	if _, err := InitConf(&HTTP{}, opts...); err != nil {
+		t.Fatalf("InitConf() error = %v", err)
+	}
+	ts.Close()
+	c, err := InitConf(&HTTP{}, opts...)
+	if err != nil {
+		t.Fatalf("InitConf() fallback error = %v", err)
+	}
+	if got, err := c.Get("redis.addr"); err != nil || got != "toml:6379" {
+		t.Errorf("Get() got = %v, err %v", got, err)
+	}
+
+	// 本地副本解析失败时, 同时返回请求与解析的错误
+	broken := filepath.Join(dir, "broken.yaml")
+	writeFile(t, broken, "redis: [")
+	if runtime.GOOS != "windows" {
+		if err = os.Chmod(broken, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err = InitConf(&HTTP{}, ConfOptWithPath(ts.URL+"/config"), header, ConfOptWithCacheFile(broken))
+	if !isNetErr(err) || !strings.Contains(fmt.Sprint(err), "broken.yaml") {
+		t.Errorf("InitConf() with broken cache error = %v, want both errors", err)
+	}
+}
+
+func TestHTTP_Watch(t *testing.T) {
+	for _, etag := range []bool{true, false} {
+		t.Run(fmt.Sprintf("etag %v", etag), func(t *testing.T) {
+			var (
+				ctx, cancel = context.WithCancel(context.Background())
+				server      = &configServer{body: "redis:\n  addr: localhost:6379\n", etag: etag}
+				ts          = httptest.NewServer(server)
+				changes     = make(chan interface{}, 10)
+				errs        = make(chan error, 10)
+			)
+			defer cancel()
+			defer ts.Close()
+			c, err := InitConf(&HTTP{}, ConfOptWithPath(ts.URL+"/config.yaml"),
+				ConfOptWithCacheFile(filepath.Join(t.TempDir(), "config.yaml")),
+				ConfOptWithPollInterval(20*time.Millisecond),
+				ConfOptWithHttpHeader(http.Header{"Authorization": []string{"Bearer token"}}))
+			if err != nil {
+				t.Fatalf("InitConf() error = %v", err)
+			}
+			c.OnChange("redis.addr", func(old, new interface{}) {
+				changes <- new
+			})
+			c.OnWatchError(func(err error) {
+				errs <- err
+			})
+			if err = c.Watch(ctx); err != nil {
+				t.Fatalf("Watch() error = %v", err)
+			}
+
+			// 配置未变化时不回调
+			time.Sleep(100 * time.Millisecond)
+			if etag && server.notModified.Load() < 1 {
+				t.Errorf("If-None-Match not sent, hits %d", server.hits.Load())
+			}
+			server.set("redis:\n  addr: remote:6379\n")
+			select {
+			case got := <-changes:
+				if got != "remote:6379" {
+					t.Errorf("OnChange() got = %v, want remote:6379", got)
+				}
+			case <-time.After(3 * time.Second):
+				t.Fatalf("OnChange() not called")
+			}
+
+			// 解析失败保留旧配置
+			server.set("redis: [")
+			select {
+			case <-errs:
+			case <-time.After(3 * time.Second):
+				t.Fatalf("OnWatchError() not called")
+			}
+			if got, _ := c.Get("redis.addr"); got != "remote:6379" {
+				t.Errorf("Get() got = %v, want remote:6379", got)
+			}
+			select {
+			case got := <-changes:
+				t.Errorf("OnChange() called with %v, want not called", got)
+			default:
+			}
+		})
+	}
+}
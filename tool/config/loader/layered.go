@@ -0,0 +1,210 @@
+package loader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"github.com/senyu-up/toolbox/enum"
+	"github.com/spf13/cast"
+	"github.com/spf13/pflag"
+)
+
+var (
+	ErrLayerEmpty = errors.New("Config layer empty ")
+)
+
+// Source 可以作为 Layered 中一层的配置源
+type Source interface {
+	Loader
+	AllSettings() map[string]interface{} // 返回嵌套 map 形式的全部配置
+}
+
+// 需要知道下层已有配置 key 的配置源, 例如 Env
+type keyBinder interface {
+	bindKeys(keys []string)
+}
+
+// Layer 一层配置源
+type Layer struct {
+	Name     string       // 层名称, Origin 会返回该名称
+	Loader   Source       // 配置源
+	Opts     []ConfOption // 配置源的初始化参数, 会追加在 InitConf 的参数之后
+	Optional bool         // 配置源不存在时是否跳过, 例如可选的 stage 配置文件
+
+	path string // LayerFile 的文件路径, 初始化后在其后追加 stage 配置文件层
+}
+
+// 内置默认配置层
+func LayerDefaults(defaults map[string]interface{}) Layer {
+	return Layer{Name: "defaults", Loader: &Defaults{}, Opts: []ConfOption{ConfOptWithDefaults(defaults)}}
+}
+
+// 配置文件层, 层名称为文件路径, optional 为 true 时文件不存在会跳过
+// 确定 stage 后, 同目录下的 stage 配置文件（例如 config.develop.yaml）作为单独的一层紧跟其后, 不存在则跳过;
+// 已经显式添加了该 stage 配置文件层时不再重复添加
+func LayerFile(path string, optional bool, opts ...ConfOption) Layer {
+	return Layer{Name: path, Loader: &File{noStage: true}, Opts: append([]ConfOption{ConfOptWithPath(path)}, opts...),
+		Optional: optional, path: path}
+}
+
+// 环境变量层
+func LayerEnv(prefix string) Layer {
+	return Layer{Name: "env", Loader: &Env{}, Opts: []ConfOption{ConfOptWithEnvPrefix(prefix)}}
+}
+
+// 命令行参数层
+func LayerFlags(flags *pflag.FlagSet, keys map[string]string) Layer {
+	return Layer{Name: "flags", Loader: &Flags{}, Opts: []ConfOption{ConfOptWithFlags(flags, keys)}}
+}
+
+// Layered 按顺序叠加多层配置源, 后面的层按 key 覆盖前面的层
+// 例如：默认配置 < 基础配置文件 < stage 配置文件(LayerFile 自动追加) < 环境变量 < 命令行参数
+//
+//	conf, err := loader.InitConf(loader.NewLayered(
+//		loader.LayerDefaults(map[string]interface{}{"app.stage": "local"}),
+//		loader.LayerFile("./config.yaml", false),
+//		loader.LayerEnv("APP"),
+//		loader.LayerFlags(cmd.Flags(), nil),
+//	))
+//	conf.Origin("redis.addr") // => env
+type Layered struct {
+	tree
+	layers  []Layer
+	active  []Layer // 实际生效的层, 不含被跳过的可选层
+	origins atomic.Pointer[map[string]string]
+	lock    sync.Mutex
+}
+
+func NewLayered(layers ...Layer) *Layered {
+	return &Layered{layers: layers}
+}
+
+// Init 依次初始化每一层, opts 会传给每一层
+func (l *Layered) Init(opts ...ConfOption) error {
+	if 1 > len(l.layers) {
+		return ErrLayerEmpty
+	}
+	var (
+		merged = map[string]interface{}{}
+		active = make([]Layer, 0, len(l.layers))
+		layers = append([]Layer{}, l.layers...)
+	)
+	for i := 0; i < len(layers); i++ {
+		layer := layers[i]
+		if b, ok := layer.Loader.(keyBinder); ok {
+			b.bindKeys(leafKeys(merged))
+		}
+		err := layer.Loader.Init(append(append([]ConfOption{}, opts...), layer.Opts...)...)
+		if err != nil {
+			if layer.Optional && errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return fmt.Errorf("init config layer %s err: %w", layer.Name, err)
+		}
+		mergeTree(merged, layer.Loader.AllSettings(), "", nil)
+		active = append(active, layer)
+		if stageLayer, ok := l.stageLayer(layer, merged, opts); ok {
+			layers = append(layers[:i+1], append([]Layer{stageLayer}, layers[i+1:]...)...)
+		}
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.active = active
+	l.merge()
+	return nil
+}
+
+// stageLayer LayerFile 对应的 stage 配置文件层, stage 依次取自参数、环境变量 stage、已合并配置的 app.stage
+func (l *Layered) stageLayer(layer Layer, merged map[string]interface{}, opts []ConfOption) (Layer, bool) {
+	if 1 > len(layer.path) {
+		return Layer{}, false
+	}
+	c := &Config{}
+	for _, opt := range append(append([]ConfOption{}, opts...), layer.Opts...) {
+		opt(c)
+	}
+	stage := c.stage
+	if 1 > len(stage) {
+		stage = os.Getenv(enum.StageKey)
+	}
+	if app, ok := merged["app"].(map[string]interface{}); ok && 1 > len(stage) {
+		stage = cast.ToString(app["stage"])
+	}
+	if 1 > len(stage) {
+		return Layer{}, false
+	}
+	stageFile := stagePath(layer.path, stage)
+	for _, other := range l.layers {
+		if filepath.Clean(other.Name) == filepath.Clean(stageFile) {
+			return Layer{}, false
+		}
+	}
+	return Layer{Name: stageFile, Loader: &File{noStage: true}, Opts: append(append([]ConfOption{}, layer.Opts...), ConfOptWithPath(stageFile)),
+		Optional: true}, true
+}
+
+// 合并所有生效的层, 并记录每个 key 的来源
+func (l *Layered) merge() {
+	var (
+		merged  = map[string]interface{}{}
+		origins = map[string]string{}
+	)
+	for _, layer := range l.active {
+		name := layer.Name
+		mergeTree(merged, layer.Loader.AllSettings(), "", func(key string) {
+			origins[key] = name
+		})
+	}
+	l.driver.Store(newDriver(merged))
+	l.origins.Store(&origins)
+}
+
+func (l *Layered) Origin(key string) string {
+	return (*l.origins.Load())[key]
+}
+
+func (l *Layered) Origins() map[string]string {
+	var (
+		origins = *l.origins.Load()
+		res     = make(map[string]string, len(origins))
+	)
+	for k, v := range origins {
+		res[k] = v
+	}
+	return res
+}
+
+// Watch 监听所有支持 Watcher 的层, 任意一层变化后重新合并
+func (l *Layered) Watch(ctx context.Context, onChange func(err error)) error {
+	var watched = 0
+	for _, layer := range l.active {
+		w, ok := layer.Loader.(Watcher)
+		if !ok {
+			continue
+		}
+		err := w.Watch(ctx, func(err error) {
+			if err != nil {
+				onChange(err)
+				return
+			}
+			l.lock.Lock()
+			l.merge()
+			l.lock.Unlock()
+			onChange(nil)
+		})
+		if err != nil {
+			return fmt.Errorf("watch config layer %s err: %w", layer.Name, err)
+		}
+		watched++
+	}
+	if watched == 0 {
+		return ErrConfigWatchUnsupport
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package loader
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/senyu-up/toolbox/tool/config"
+	"github.com/spf13/pflag"
+)
+
+func TestLayered(t *testing.T) {
+	var (
+		dir   = t.TempDir()
+		base  = filepath.Join(dir, "config.yaml")
+		stage = filepath.Join(dir, "config.develop.yaml")
+		flags = pflag.NewFlagSet("test", pflag.ContinueOnError)
+	)
+	writeFile(t, base, "run_env: local\nredisdb:\n  addr: localhost:6379\n  db: 1\nmysql:\n  dsn: base_dsn\n  maxopenconn: 10\n")
+	writeFile(t, stage, "mysql:\n  dsn: stage_dsn\n")
+	t.Setenv("TEST_RUN_ENV", "develop")
+	t.Setenv("TEST_REDISDB_ADDR", "env:6379")
+	t.Setenv("TEST_REDISDB_ISCLUSTER", "true")
+	t.Setenv("TESTX_REDISDB_ADDR", "other")
+	flags.String("redisdb.addr", "", "")
+	flags.Int("db", 0, "")
+	flags.StringSlice("addrs", nil, "")
+	flags.String("prefix", "default_prefix", "")
+	if err := flags.Parse([]string{"--db=2", "--addrs=a:1,b:2"}); err != nil {
+		t.Fatalf("parse flags err %v", err)
+	}
+
+	c, err := InitConf(NewLayered(
+		LayerDefaults(map[string]interface{}{"redisdb.poolsize": 10, "mysql": map[string]interface{}{"MaxIdleConn": 5}}),
+		LayerFile(base, false),
+		LayerFile(stage, true),
+		LayerFile(filepath.Join(dir, "config.release.yaml"), true),
+		LayerEnv("TEST"),
+		LayerFlags(flags, map[string]string{"db": "redisdb.db", "addrs": "redisdb.addrs"}),
+	))
+	if err != nil {
+		t.Fatalf("InitConf() error = %v", err)
+	}
+
+	tests := []struct {
+		key    string
+		want   interface{}
+		origin string
+	}{
+		{key: "redisdb.poolsize", want: 10, origin: "defaults"},
+		{key: "mysql.maxidleconn", want: 5, origin: "defaults"},
+		{key: "mysql.maxopenconn", want: 10, origin: base},
+		{key: "mysql.dsn", want: "stage_dsn", origin: stage},
+		{key: "run_env", want: "develop", origin: "env"},
+		{key: "redisdb.addr", want: "env:6379", origin: "env"},
+		{key: "redisdb.iscluster", want: "true", origin: "env"},
+		{key: "redisdb.db", want: 2, origin: "flags"},
+		{key: "redisdb.addrs", want: []string{"a:1", "b:2"}, origin: "flags"},
+		{key: "prefix", want: nil, origin: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, _ := c.Get(tt.key)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Get() got = %v(%T), want %v(%T)", got, got, tt.want, tt.want)
+			}
+			if origin := c.Origin(tt.key); origin != tt.origin {
+				t.Errorf("Origin() got = %v, want %v", origin, tt.origin)
+			}
+		})
+	}
+
+	type AppConf struct {
+		RedisDb config.RedisConfig
+		Mysql   config.MysqlConfig
+	}
+	var (
+		dst  = &AppConf{}
+		want = &AppConf{
+			RedisDb: config.RedisConfig{Addrs: []string{"a:1", "b:2"}, DB: 2, IsCluster: true, PoolSize: 10},
+			Mysql:   config.MysqlConfig{Dsn: "stage_dsn", MaxOpenConn: 10, MaxIdleConn: 5},
+		}
+	)
+	if err = c.Unmarshal(dst); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !reflect.DeepEqual(dst, want) {
+		t.Errorf("Unmarshal() got = %+v, want %+v", dst, want)
+	}
+	if origins := c.Origins(); len(origins) != 9 {
+		t.Errorf("Origins() got = %v", origins)
+	}
+}
+
+func TestLayered_Stage(t *testing.T) {
+	var (
+		dir   = t.TempDir()
+		base  = filepath.Join(dir, "config.yaml")
+		stage = filepath.Join(dir, "config.develop.yaml")
+	)
+	writeFile(t, base, "app:\n  stage: develop\nmysql:\n  dsn: base_dsn\n  maxopenconn: 10\n")
+	writeFile(t, stage, "mysql:\n  dsn: stage_dsn\n")
+
+	tests := []struct {
+		name    string
+		layered *Layered
+		active  int
+	}{
+		// stage 配置文件作为单独的一层, Origin 为 stage 配置文件
+		{name: "auto", layered: NewLayered(LayerFile(base, false)), active: 2},
+		// 显式添加的 stage 配置文件层不重复加载
+		{name: "explicit", layered: NewLayered(LayerFile(base, false), LayerFile(stage, true)), active: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := InitConf(tt.layered)
+			if err != nil {
+				t.Fatalf("InitConf() error = %v", err)
+			}
+			if got, _ := c.Get("mysql.dsn"); got != "stage_dsn" {
+				t.Errorf("mysql.dsn = %v, want stage_dsn", got)
+			}
+			if origin := c.Origin("mysql.dsn"); origin != stage {
+				t.Errorf("Origin(mysql.dsn) = %v, want %v", origin, stage)
+			}
+			if origin := c.Origin("mysql.maxopenconn"); origin != base {
+				t.Errorf("Origin(mysql.maxopenconn) = %v, want %v", origin, base)
+			}
+			if n := len(tt.layered.active); n != tt.active {
+				t.Errorf("active layers = %d, want %d", n, tt.active)
+			}
+		})
+	}
+
+	// 参数指定的 stage 优先, stage 配置文件不存在时跳过
+	l := NewLayered(LayerFile(base, false))
+	c, err := InitConf(l, ConfOptWithStage("production"))
+	if err != nil {
+		t.Fatalf("InitConf() error = %v", err)
+	}
+	if got, _ := c.Get("mysql.dsn"); got != "base_dsn" || len(l.active) != 1 {
+		t.Errorf("mysql.dsn = %v, active layers = %d", got, len(l.active))
+	}
+}
+
+func TestLayered_InitErr(t *testing.T) {
+	var dir = t.TempDir()
+	tests := []struct {
+		name    string
+		layered *Layered
+	}{
+		{name: "empty", layered: NewLayered()},
+		{name: "required file", layered: NewLayered(LayerFile(filepath.Join(dir, "config.yaml"), false))},
+		{name: "env prefix", layered: NewLayered(LayerEnv(""))},
+		{name: "flags", layered: NewLayered(LayerFlags(nil, nil))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := InitConf(tt.layered); err == nil {
+				t.Errorf("InitConf() error = nil, want err")
+			}
+		})
+	}
+}
+
+func TestEnv(t *testing.T) {
+	t.Setenv("ENVTEST_MYSQL_DSN", "env_dsn")
+	t.Setenv("ENVTEST_REDISDB_ADDRS", "localhost:6379,localhost:6378")
+	t.Setenv("ENVTEST_REDISDB_DB", "2")
+	t.Setenv("ENVTEST_REDISDB_PASSWORD", "007")
+	t.Setenv("ENVTEST_APP_VERSION", "1.10")
+	c, err := InitConf(&Env{}, ConfOptWithEnvPrefix("envtest"))
+	if err != nil {
+		t.Fatalf("InitConf() error = %v", err)
+	}
+	if got, _ := c.Get("mysql.dsn"); got != "env_dsn" {
+		t.Errorf("Get() got = %v, want env_dsn", got)
+	}
+	// 环境变量的值保持为字符串, Unmarshal 时按字段类型转换
+	if got, _ := c.Get("redisdb.password"); got != "007" {
+		t.Errorf("Get() got = %v(%T), want 007", got, got)
+	}
+	var dst struct {
+		RedisDb config.RedisConfig
+		App     struct{ Version string }
+	}
+	if err := c.Unmarshal(&dst); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if want := []string{"localhost:6379", "localhost:6378"}; !reflect.DeepEqual(dst.RedisDb.Addrs, want) {
+		t.Errorf("Unmarshal() addrs = %v, want %v", dst.RedisDb.Addrs, want)
+	}
+	if dst.RedisDb.DB != 2 || dst.RedisDb.Password != "007" || dst.App.Version != "1.10" {
+		t.Errorf("Unmarshal() got = %+v", dst)
+	}
+	if got := c.Origin("mysql.dsn"); got != "" {
+		t.Errorf("Origin() got = %v, want empty", got)
+	}
+}
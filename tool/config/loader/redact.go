@@ -0,0 +1,117 @@
+package loader
+
+import (
+	"reflect"
+	"strings"
+)
+
+const (
+	secretTag = "secret"
+
+	// RedactedValue 脱敏后的配置值
+	RedactedValue = "******"
+)
+
+// Redact 按 schema 结构体中的 secret tag 对配置脱敏, 返回脱敏后的副本, 不修改 settings
+// schema 为配置对应的结构体（或其指针），字段与配置 key 的对应规则与 Unmarshal 一致, 例如
+//
+//	type AppConf struct {
+//		Mysql config.MysqlConfig // mysql.master.password 带有 `secret:"true"`, 会被替换为 ******
+//	}
+//	loader.Redact(settings, &AppConf{})
+func Redact(settings map[string]interface{}, schema interface{}) map[string]interface{} {
+	res, _ := redactValue(settings, reflect.TypeOf(schema)).(map[string]interface{})
+	return res
+}
+
+func redactValue(v interface{}, t reflect.Type) interface{} {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch val := v.(type) {
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			res[k] = redactValue(item, fieldType(t, k))
+		}
+		if t != nil && t.Kind() == reflect.Struct {
+			redactFields(res, t)
+		}
+		return res
+	case []interface{}:
+		var elem reflect.Type
+		if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+			elem = t.Elem()
+		}
+		res := make([]interface{}, len(val))
+		for i, item := range val {
+			res[i] = redactValue(item, elem)
+		}
+		return res
+	}
+	return v
+}
+
+// 替换结构体中 secret tag 为 true 的字段, 未设置或为空的字段保持原样
+func redactFields(settings map[string]interface{}, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, squash := fieldKey(field)
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if squash {
+			ft := field.Type
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				redactFields(settings, ft)
+			}
+			continue
+		}
+		if field.Tag.Get(secretTag) != "true" {
+			continue
+		}
+		for k, val := range settings {
+			if strings.EqualFold(k, name) && !isEmptyValue(val) {
+				settings[k] = RedactedValue
+			}
+		}
+	}
+}
+
+// 配置 key 在结构体中对应字段的类型, 找不到返回 nil
+func fieldType(t reflect.Type, key string) reflect.Type {
+	if t == nil {
+		return nil
+	}
+	switch t.Kind() {
+	case reflect.Map:
+		return t.Elem()
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, squash := fieldKey(field)
+			if squash {
+				if ft := fieldType(field.Type, key); ft != nil {
+					return ft
+				}
+				continue
+			}
+			if strings.EqualFold(name, key) {
+				return field.Type
+			}
+		}
+	case reflect.Ptr:
+		return fieldType(t.Elem(), key)
+	}
+	return nil
+}
+
+func isEmptyValue(v interface{}) bool {
+	return v == nil || reflect.ValueOf(v).IsZero()
+}
@@ -0,0 +1,55 @@
+package loader
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/senyu-up/toolbox/tool/config"
+)
+
+func TestRedact(t *testing.T) {
+	type Base struct {
+		Token string `secret:"true"`
+	}
+	type AppConf struct {
+		Base    `mapstructure:",squash"`
+		App     config.App
+		Mysql   *config.MysqlConfig
+		QwRobot config.QwRobotConfig
+		Kafka   config.KafkaConfig
+		Dbs     map[string]config.MysqlSingleConfig
+	}
+	var settings = map[string]interface{}{
+		"token": "t",
+		"app":   map[string]interface{}{"name": "demo"},
+		"mysql": map[string]interface{}{
+			"dsn":    "root:pass@tcp(127.0.0.1:3306)/db",
+			"master": map[string]interface{}{"user": "root", "password": "pass"},
+			"slave":  []interface{}{map[string]interface{}{"user": "ro", "password": "ro_pass"}},
+		},
+		"qwrobot": map[string]interface{}{"webhook": "https://qyapi.weixin.qq.com/cgi-bin/webhook/send?key=xxx", "prefix": "demo"},
+		"kafka":   map[string]interface{}{"password": "", "sasl": map[string]interface{}{"user": "u", "password": "p"}},
+		"dbs":     map[string]interface{}{"center": map[string]interface{}{"addr": "127.0.0.1", "password": "c"}},
+		"unknown": map[string]interface{}{"password": "keep"},
+	}
+	var want = map[string]interface{}{
+		"token": RedactedValue,
+		"app":   map[string]interface{}{"name": "demo"},
+		"mysql": map[string]interface{}{
+			"dsn":    RedactedValue,
+			"master": map[string]interface{}{"user": "root", "password": RedactedValue},
+			"slave":  []interface{}{map[string]interface{}{"user": "ro", "password": RedactedValue}},
+		},
+		"qwrobot": map[string]interface{}{"webhook": RedactedValue, "prefix": "demo"},
+		"kafka":   map[string]interface{}{"password": "", "sasl": map[string]interface{}{"user": "u", "password": RedactedValue}},
+		"dbs":     map[string]interface{}{"center": map[string]interface{}{"addr": "127.0.0.1", "password": RedactedValue}},
+		"unknown": map[string]interface{}{"password": "keep"},
+	}
+	got := Redact(settings, &AppConf{})
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Redact() got = %v, want %v", got, want)
+	}
+	if settings["mysql"].(map[string]interface{})["dsn"] == RedactedValue {
+		t.Errorf("Redact() modified settings")
+	}
+}
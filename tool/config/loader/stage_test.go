@@ -0,0 +1,141 @@
+package loader
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/senyu-up/toolbox/enum"
+	"github.com/senyu-up/toolbox/tool/config"
+)
+
+func TestFile_Stage(t *testing.T) {
+	type AppConf struct {
+		App   config.App
+		Redis config.RedisConfig
+		Mysql config.MysqlConfig
+	}
+	var dir = t.TempDir()
+	writeFile(t, filepath.Join(dir, "config.yaml"), "app:\n  name: demo\n  stage: develop\nredis:\n  addrs: [localhost:6379]\n  poolsize: 10\nmysql:\n  dsn: base_dsn\n")
+	writeFile(t, filepath.Join(dir, "config.develop.yaml"), "redis:\n  addrs: [develop:6379]\n")
+	writeFile(t, filepath.Join(dir, "config.production.yaml"), "app:\n  dev: false\nredis:\n  addrs: [production:6379]\n  iscluster: true\nmysql:\n  dsn: production_dsn\n")
+	writeFile(t, filepath.Join(dir, "config.release.yaml"), "redis: [")
+	writeFile(t, filepath.Join(dir, "nostage.yaml"), "app:\n  name: demo\nredis:\n  addrs: [localhost:6379]\n")
+
+	tests := []struct {
+		name    string
+		env     string
+		param   []ConfOption
+		want    *AppConf
+		wantErr bool
+	}{
+		{
+			name:  "app.stage",
+			param: []ConfOption{ConfOptWithPath(filepath.Join(dir, "config.yaml"))},
+			want: &AppConf{
+				App:   config.App{Name: "demo", Stage: "develop"},
+				Redis: config.RedisConfig{Addrs: []string{"develop:6379"}, PoolSize: 10},
+				Mysql: config.MysqlConfig{Dsn: "base_dsn"},
+			},
+		},
+		{
+			name:  "env",
+			env:   enum.EvnStageProduction,
+			param: []ConfOption{ConfOptWithPath(filepath.Join(dir, "config.yaml"))},
+			want: &AppConf{
+				App:   config.App{Name: "demo", Stage: "production"},
+				Redis: config.RedisConfig{Addrs: []string{"production:6379"}, PoolSize: 10, IsCluster: true},
+				Mysql: config.MysqlConfig{Dsn: "production_dsn"},
+			},
+		},
+		{
+			name:  "option",
+			env:   enum.EvnStageDevelop,
+			param: []ConfOption{ConfOptWithPath(filepath.Join(dir, "config.yaml")), ConfOptWithStage(enum.EvnStageProduction)},
+			want: &AppConf{
+				App:   config.App{Name: "demo", Stage: "production"},
+				Redis: config.RedisConfig{Addrs: []string{"production:6379"}, PoolSize: 10, IsCluster: true},
+				Mysql: config.MysqlConfig{Dsn: "production_dsn"},
+			},
+		},
+		{
+			name:  "stage file not exist",
+			param: []ConfOption{ConfOptWithPath(filepath.Join(dir, "config.yaml")), ConfOptWithStage(enum.EvnStageLocal)},
+			want: &AppConf{
+				App:   config.App{Name: "demo", Stage: "local"},
+				Redis: config.RedisConfig{Addrs: []string{"localhost:6379"}, PoolSize: 10},
+				Mysql: config.MysqlConfig{Dsn: "base_dsn"},
+			},
+		},
+		{
+			name:  "no stage",
+			param: []ConfOption{ConfOptWithPath(filepath.Join(dir, "nostage.yaml"))},
+			want: &AppConf{
+				App:   config.App{Name: "demo"},
+				Redis: config.RedisConfig{Addrs: []string{"localhost:6379"}},
+			},
+		},
+		{
+			name:    "stage file invalid",
+			param:   []ConfOption{ConfOptWithPath(filepath.Join(dir, "config.yaml")), ConfOptWithStage(enum.EvnStageRelease)},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(enum.StageKey, tt.env)
+			if tt.env == "" {
+				os.Unsetenv(enum.StageKey)
+			}
+			c, err := InitConf(&File{}, tt.param...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("InitConf() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			var dst = &AppConf{}
+			if err = c.Unmarshal(dst); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if !reflect.DeepEqual(dst, tt.want) {
+				t.Errorf("Unmarshal() got = %+v, want %+v", dst, tt.want)
+			}
+		})
+	}
+}
+
+func TestFile_WatchStage(t *testing.T) {
+	var (
+		dir         = t.TempDir()
+		stageFile   = filepath.Join(dir, "config.develop.yaml")
+		ctx, cancel = context.WithCancel(context.Background())
+		changes     = make(chan interface{}, 10)
+	)
+	defer cancel()
+	writeFile(t, filepath.Join(dir, "config.yaml"), "redis:\n  addr: localhost:6379\n")
+	writeFile(t, stageFile, "redis:\n  addr: develop:6379\n")
+	c, err := InitConf(&File{}, ConfOptWithPath(filepath.Join(dir, "config.yaml")),
+		ConfOptWithStage(enum.EvnStageDevelop), ConfOptWithDebounce(50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("InitConf() error = %v", err)
+	}
+	c.OnChange("redis.addr", func(old, new interface{}) {
+		changes <- new
+	})
+	if err = c.Watch(ctx); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	writeFile(t, stageFile, "redis:\n  addr: develop:6380\n")
+	select {
+	case got := <-changes:
+		if got != "develop:6380" {
+			t.Errorf("OnChange() got = %v, want develop:6380", got)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("OnChange() not called")
+	}
+}
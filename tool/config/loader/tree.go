@@ -0,0 +1,124 @@
+package loader
+
+import (
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// 基于 viper 的配置树, 各 loader 共用; 重新加载时整体替换, 读写无需加锁
+type tree struct {
+	driver atomic.Pointer[viper.Viper]
+}
+
+func (t *tree) Get(key string) (interface{}, error) {
+	var (
+		err    error = nil
+		driver       = t.driver.Load()
+	)
+	if !driver.IsSet(key) {
+		err = ErrConfigKeyNotSet
+	}
+	return driver.Get(key), err
+}
+
+func (t *tree) Unmarshal(dst interface{}) error {
+	driver := t.driver.Load()
+	keys := driver.AllKeys()
+	if 1 > len(keys) {
+		// 如果配置为空，报错
+		return ErrConfigEmpty
+	}
+	return driver.Unmarshal(dst)
+}
+
+// AllSettings 返回嵌套 map 形式的全部配置, key 均为小写
+func (t *tree) AllSettings() map[string]interface{} {
+	return t.driver.Load().AllSettings()
+}
+
+// 由嵌套 map 生成 viper 实例
+func newDriver(settings map[string]interface{}) *viper.Viper {
+	driver := viper.New()
+	_ = driver.MergeConfigMap(settings)
+	return driver
+}
+
+// 单个字符串值按 yaml 解析, 以便 "true", "1", "[a, b]" 这类值还原为对应类型，解析失败则原样作为字符串
+func parseValue(raw []byte) interface{} {
+	var val interface{}
+	if err := yaml.Unmarshal(raw, &val); err != nil || val == nil {
+		return string(raw)
+	}
+	return val
+}
+
+// 按路径把 val 写入嵌套 map, 中间节点不是 map 时会被覆盖
+func setByPath(tree map[string]interface{}, path []string, val interface{}) {
+	for i, seg := range path {
+		seg = strings.ToLower(seg)
+		if i == len(path)-1 {
+			tree[seg] = val
+			return
+		}
+		next, ok := tree[seg].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			tree[seg] = next
+		}
+		tree = next
+	}
+}
+
+// 把 src 深度合并到 dst, src 中的叶子节点覆盖 dst, 每个被覆盖的叶子节点回调 onLeaf
+func mergeTree(dst, src map[string]interface{}, prefix string, onLeaf func(key string)) {
+	for k, sv := range src {
+		k = strings.ToLower(k)
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		if sm, ok := toStringMap(sv); ok {
+			dm, ok := dst[k].(map[string]interface{})
+			if !ok {
+				dm = map[string]interface{}{}
+				dst[k] = dm
+			}
+			mergeTree(dm, sm, key, onLeaf)
+			continue
+		}
+		dst[k] = sv
+		if onLeaf != nil {
+			onLeaf(key)
+		}
+	}
+}
+
+// 列出嵌套 map 所有叶子节点的路径, 按字典序排序
+func leafKeys(settings map[string]interface{}) []string {
+	var keys []string
+	mergeTree(map[string]interface{}{}, settings, "", func(key string) {
+		keys = append(keys, key)
+	})
+	sort.Strings(keys)
+	return keys
+}
+
+func toStringMap(v interface{}) (map[string]interface{}, bool) {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		return m, true
+	case map[interface{}]interface{}:
+		res := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			if s, ok := k.(string); ok {
+				res[s] = v
+			}
+		}
+		return res, true
+	}
+	return nil, false
+}
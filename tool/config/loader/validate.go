@@ -0,0 +1,195 @@
+package loader
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	playground "github.com/go-playground/validator/v10"
+	"github.com/mitchellh/mapstructure"
+	"github.com/senyu-up/toolbox/tool/validator"
+)
+
+var (
+	ErrConfigInvalid = errors.New("Config invalid ")
+)
+
+const (
+	defaultTag  = "default"
+	validateTag = "validate"
+)
+
+// 配置结构体使用的 validator, 报错的字段名为配置路径, 例如 redis.addrs
+var confValidator = newConfValidator()
+
+func newConfValidator() *playground.Validate {
+	v := validator.NewValidator()
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _ := fieldKey(field)
+		return name
+	})
+	return v
+}
+
+// InvalidField 未通过校验的配置项
+type InvalidField struct {
+	Key   string      // 配置路径, 例如 redis.addrs, mysql.slaves[0].dsn
+	Rule  string      // 未通过的规则, 例如 required, min=1
+	Value interface{} // 配置值
+}
+
+// InvalidConfigError Unmarshal 校验失败时返回, 包含所有未通过校验的配置项
+// 可以用 errors.Is(err, ErrConfigInvalid) 判断
+type InvalidConfigError struct {
+	Fields []InvalidField
+}
+
+func (e *InvalidConfigError) Error() string {
+	items := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		items = append(items, fmt.Sprintf("%s(%s)", f.Key, f.Rule))
+	}
+	return fmt.Sprintf("config invalid: %s", strings.Join(items, ", "))
+}
+
+func (e *InvalidConfigError) Unwrap() error {
+	return ErrConfigInvalid
+}
+
+// 按 validate tag 校验配置结构体, 所有未通过的配置项合并成一个 InvalidConfigError 返回
+func validateStruct(dst interface{}) error {
+	if !isStructPtr(dst) {
+		return nil
+	}
+	err := confValidator.Struct(dst)
+	var fieldErrs playground.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return err
+	}
+	invalid := &InvalidConfigError{Fields: make([]InvalidField, 0, len(fieldErrs))}
+	for _, fe := range fieldErrs {
+		// Namespace 以结构体类型名开头, 例如 AppConf.redis.addrs
+		key := fe.Namespace()
+		if i := strings.Index(key, "."); i >= 0 {
+			key = key[i+1:]
+		}
+		rule := fe.Tag()
+		if fe.Param() != "" {
+			rule += "=" + fe.Param()
+		}
+		invalid.Fields = append(invalid.Fields, InvalidField{Key: key, Rule: rule, Value: fe.Value()})
+	}
+	return invalid
+}
+
+// 给配置中未设置的字段赋上 default tag 的值, 例如 `default:"10"`, `default:"5s"`, `default:"a,b"`
+// isSet 判断配置路径是否已设置; slice, map 中的元素无法对应到配置路径, 字段为零值时才赋默认值
+func applyDefaults(dst interface{}, isSet func(key string) bool) error {
+	if !isStructPtr(dst) {
+		return nil
+	}
+	return setDefaults(reflect.ValueOf(dst).Elem(), "", true, isSet)
+}
+
+func setDefaults(v reflect.Value, path string, known bool, isSet func(key string) bool) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		return setDefaults(v.Elem(), path, known, isSet)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !v.Field(i).CanSet() {
+				continue
+			}
+			name, squash := fieldKey(field)
+			if name == "-" {
+				continue
+			}
+			key := joinKey(path, name)
+			if squash {
+				key = path
+			}
+			if def, ok := field.Tag.Lookup(defaultTag); ok {
+				unset := v.Field(i).IsZero()
+				if known {
+					unset = !isSet(key)
+				}
+				if unset {
+					if err := setDefault(v.Field(i), def); err != nil {
+						return fmt.Errorf("config %s default value %q invalid: %w", key, def, err)
+					}
+				}
+			}
+			if err := setDefaults(v.Field(i), key, known, isSet); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := setDefaults(v.Index(i), fmt.Sprintf("%s[%d]", path, i), false, isSet); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			// map 的值不可寻址, 复制后再设置回去
+			cp := reflect.New(v.Type().Elem()).Elem()
+			cp.Set(iter.Value())
+			if err := setDefaults(cp, joinKey(path, fmt.Sprint(iter.Key().Interface())), false, isSet); err != nil {
+				return err
+			}
+			v.SetMapIndex(iter.Key(), cp)
+		}
+	}
+	return nil
+}
+
+// 与 viper 反序列化规则一致, 把 tag 中的字符串转换成字段的类型
+func setDefault(field reflect.Value, def string) error {
+	ptr := reflect.New(field.Type())
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		Result:           ptr.Interface(),
+		WeaklyTypedInput: true,
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(
+			mapstructure.StringToTimeDurationHookFunc(),
+			mapstructure.StringToSliceHookFunc(","),
+		),
+	})
+	if err != nil {
+		return err
+	}
+	if err = decoder.Decode(def); err != nil {
+		return err
+	}
+	field.Set(ptr.Elem())
+	return nil
+}
+
+// 字段对应的配置 key, 与 viper 一致: 优先使用 mapstructure tag, 否则为小写的字段名
+func fieldKey(field reflect.StructField) (name string, squash bool) {
+	name = strings.ToLower(field.Name)
+	tag, ok := field.Tag.Lookup("mapstructure")
+	if !ok {
+		return name, false
+	}
+	parts := strings.Split(tag, ",")
+	if parts[0] != "" {
+		name = strings.ToLower(parts[0])
+	}
+	for _, opt := range parts[1:] {
+		if opt == "squash" {
+			squash = true
+		}
+	}
+	return name, squash
+}
+
+func isStructPtr(dst interface{}) bool {
+	v := reflect.ValueOf(dst)
+	return v.Kind() == reflect.Ptr && !v.IsNil() && v.Elem().Kind() == reflect.Struct
+}
@@ -0,0 +1,113 @@
+package loader
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestConfig_UnmarshalDefaultsAndValidate(t *testing.T) {
+	type Slave struct {
+		Dsn     string `validate:"required"`
+		MaxConn int    `default:"5"`
+	}
+	type Redis struct {
+		Addrs    []string      `validate:"required,min=1"`
+		PoolSize int           `default:"10" validate:"min=1"`
+		Timeout  time.Duration `default:"3s"`
+		Tags     []string      `default:"a,b"`
+	}
+	type AppConf struct {
+		Name   string `mapstructure:"app_name" default:"demo"`
+		Port   int    `default:"8080" validate:"min=1,max=65535"`
+		Debug  *bool  `default:"true"`
+		Redis  Redis
+		Slaves []Slave `validate:"dive"`
+	}
+	var (
+		dir   = t.TempDir()
+		debug = true
+		off   = false
+	)
+	writeFile(t, filepath.Join(dir, "ok.yaml"), "redis:\n  addrs: [localhost:6379]\nslaves:\n  - dsn: slave1\n  - dsn: slave2\n    maxconn: 10\n")
+	writeFile(t, filepath.Join(dir, "zero.yaml"), "app_name: app\nport: 80\ndebug: false\nredis:\n  addrs: [localhost:6379]\n  timeout: 1s\n  tags: []\n")
+	writeFile(t, filepath.Join(dir, "invalid.yaml"), "port: 70000\nredis:\n  poolsize: 0\nslaves:\n  - maxconn: 1\n")
+
+	tests := []struct {
+		name     string
+		path     string
+		want     *AppConf
+		wantKeys []string
+	}{
+		{
+			name: "defaults",
+			path: "ok.yaml",
+			want: &AppConf{
+				Name: "demo", Port: 8080, Debug: &debug,
+				Redis:  Redis{Addrs: []string{"localhost:6379"}, PoolSize: 10, Timeout: 3 * time.Second, Tags: []string{"a", "b"}},
+				Slaves: []Slave{{Dsn: "slave1", MaxConn: 5}, {Dsn: "slave2", MaxConn: 10}},
+			},
+		},
+		{
+			name: "set in config",
+			path: "zero.yaml",
+			want: &AppConf{
+				Name: "app", Port: 80, Debug: &off,
+				Redis: Redis{Addrs: []string{"localhost:6379"}, PoolSize: 10, Timeout: time.Second, Tags: []string{}},
+			},
+		},
+		{
+			name:     "invalid",
+			path:     "invalid.yaml",
+			wantKeys: []string{"port", "redis.addrs", "redis.poolsize", "slaves[0].dsn"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := InitConf(&File{}, ConfOptWithPath(filepath.Join(dir, tt.path)))
+			if err != nil {
+				t.Fatalf("InitConf() error = %v", err)
+			}
+			var dst = &AppConf{}
+			err = c.Unmarshal(dst)
+			if tt.wantKeys == nil {
+				if err != nil {
+					t.Fatalf("Unmarshal() error = %v", err)
+				}
+				if !reflect.DeepEqual(dst, tt.want) {
+					t.Errorf("Unmarshal() got = %+v, want %+v", dst, tt.want)
+				}
+				return
+			}
+			var invalid *InvalidConfigError
+			if !errors.Is(err, ErrConfigInvalid) || !errors.As(err, &invalid) {
+				t.Fatalf("Unmarshal() error = %v, want %v", err, ErrConfigInvalid)
+			}
+			var keys []string
+			for _, f := range invalid.Fields {
+				keys = append(keys, f.Key)
+			}
+			if !reflect.DeepEqual(keys, tt.wantKeys) {
+				t.Errorf("Unmarshal() invalid keys = %v, want %v, err %v", keys, tt.wantKeys, err)
+			}
+		})
+	}
+}
+
+func TestConfig_UnmarshalDefaultInvalid(t *testing.T) {
+	var p = filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, p, "name: demo\n")
+	c, err := InitConf(&File{}, ConfOptWithPath(p))
+	if err != nil {
+		t.Fatalf("InitConf() error = %v", err)
+	}
+	var dst = &struct {
+		Name string
+		Port int `default:"http"`
+	}{}
+	if err = c.Unmarshal(dst); err == nil {
+		t.Errorf("Unmarshal() error = nil, want err")
+	}
+}
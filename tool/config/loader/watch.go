@@ -0,0 +1,90 @@
+package loader
+
+import (
+	"reflect"
+	"sync"
+	"time"
+)
+
+const defaultDebounce = 200 * time.Millisecond
+
+type subscriber struct {
+	key string
+	old interface{}
+	fn  func(old, new interface{})
+}
+
+// 配置变更的订阅者列表
+type subscription struct {
+	lock    sync.Mutex
+	subs    []*subscriber
+	onError func(err error)
+}
+
+func (s *subscription) add(key string, val interface{}, fn func(old, new interface{})) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.subs = append(s.subs, &subscriber{key: key, old: val, fn: fn})
+}
+
+func (s *subscription) setErrHandler(fn func(err error)) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.onError = fn
+}
+
+// 逐个比较订阅的 key, 值有变化则回调
+func (s *subscription) notify(get func(key string) (interface{}, error)) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, sub := range s.subs {
+		val, _ := get(sub.key)
+		if reflect.DeepEqual(sub.old, val) {
+			continue
+		}
+		old := sub.old
+		sub.old = val
+		sub.fn(old, val)
+	}
+}
+
+func (s *subscription) fail(err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.onError != nil {
+		s.onError(err)
+	}
+}
+
+// 防抖, 在 wait 时间内多次 trigger 只会执行一次 fn
+type debouncer struct {
+	lock  sync.Mutex
+	wait  time.Duration
+	timer *time.Timer
+	fn    func()
+}
+
+func newDebouncer(wait time.Duration, fn func()) *debouncer {
+	if wait <= 0 {
+		wait = defaultDebounce
+	}
+	return &debouncer{wait: wait, fn: fn}
+}
+
+func (d *debouncer) trigger() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.timer == nil {
+		d.timer = time.AfterFunc(d.wait, d.fn)
+		return
+	}
+	d.timer.Reset(d.wait)
+}
+
+func (d *debouncer) stop() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+}
@@ -0,0 +1,150 @@
+package loader
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type change struct {
+	old, new interface{}
+}
+
+func writeFile(t *testing.T, p string, content string) {
+	if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+		t.Fatalf("write file err %v", err)
+	}
+}
+
+func TestConfig_Watch(t *testing.T) {
+	var (
+		p           = filepath.Join(t.TempDir(), "config.yaml")
+		ctx, cancel = context.WithCancel(context.Background())
+		changes     = make(chan change, 10)
+		errs        = make(chan error, 10)
+	)
+	defer cancel()
+	writeFile(t, p, "redisdb:\n  addr: localhost:6379\nrun_env: local\n")
+
+	c, err := InitConf(&File{}, ConfOptWithPath(p), ConfOptWithDebounce(50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("InitConf() error = %v", err)
+	}
+	c.OnChange("redisdb.addr", func(old, new interface{}) {
+		changes <- change{old: old, new: new}
+	})
+	c.OnChange("run_env", func(old, new interface{}) {
+		t.Errorf("run_env should not change, old %v new %v", old, new)
+	})
+	c.OnWatchError(func(err error) {
+		errs <- err
+	})
+	if err = c.Watch(ctx); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+
+	// 防抖时间内的多次修改只会触发一次回调
+	for _, addr := range []string{"localhost:6378", "localhost:6377", "localhost:6376"} {
+		writeFile(t, p, "redisdb:\n  addr: "+addr+"\nrun_env: local\n")
+	}
+	select {
+	case got := <-changes:
+		if got.old != "localhost:6379" || got.new != "localhost:6376" {
+			t.Errorf("OnChange() got old %v new %v", got.old, got.new)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("OnChange() not called")
+	}
+	select {
+	case got := <-changes:
+		t.Errorf("OnChange() called more than once, got %+v", got)
+	case <-time.After(200 * time.Millisecond):
+	}
+	if got, _ := c.Get("redisdb.addr"); got != "localhost:6376" {
+		t.Errorf("Get() got = %v, want localhost:6376", got)
+	}
+
+	// 配置解析失败时保留旧配置
+	writeFile(t, p, "redisdb: [")
+	select {
+	case <-errs:
+	case <-time.After(3 * time.Second):
+		t.Fatalf("OnWatchError() not called")
+	}
+	if got, _ := c.Get("redisdb.addr"); got != "localhost:6376" {
+		t.Errorf("Get() got = %v, want localhost:6376", got)
+	}
+
+	// 编辑器 rename 方式保存
+	tmp := p + ".tmp"
+	writeFile(t, tmp, "redisdb:\n  addr: localhost:6375\nrun_env: local\n")
+	if err = os.Rename(tmp, p); err != nil {
+		t.Fatalf("rename err %v", err)
+	}
+	select {
+	case got := <-changes:
+		if got.old != "localhost:6376" || got.new != "localhost:6375" {
+			t.Errorf("OnChange() got old %v new %v", got.old, got.new)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("OnChange() not called after rename")
+	}
+
+	// 取消后不再监听
+	cancel()
+	time.Sleep(100 * time.Millisecond)
+	writeFile(t, p, "redisdb:\n  addr: localhost:6374\nrun_env: local\n")
+	select {
+	case got := <-changes:
+		t.Errorf("OnChange() called after cancel, got %+v", got)
+	case <-time.After(300 * time.Millisecond):
+	}
+}
+
+func TestConfig_WatchConcurrentGet(t *testing.T) {
+	var (
+		p           = filepath.Join(t.TempDir(), "config.yaml")
+		ctx, cancel = context.WithCancel(context.Background())
+		wg          sync.WaitGroup
+	)
+	defer cancel()
+	writeFile(t, p, "redisdb:\n  addr: localhost:6379\n")
+	c, err := InitConf(&File{}, ConfOptWithPath(p), ConfOptWithDebounce(time.Millisecond))
+	if err != nil {
+		t.Fatalf("InitConf() error = %v", err)
+	}
+	if err = c.Watch(ctx); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			writeFile(t, p, "redisdb:\n  addr: localhost:6379\n")
+			time.Sleep(5 * time.Millisecond)
+		}
+	}()
+	for i := 0; i < 1000; i++ {
+		if _, err = c.Get("redisdb.addr"); err != nil {
+			t.Errorf("Get() error = %v", err)
+			break
+		}
+	}
+	wg.Wait()
+}
+
+func TestConfig_WatchUnsupport(t *testing.T) {
+	c := Config{loader: &mockLoader{}, subs: &subscription{}}
+	if err := c.Watch(context.Background()); err != ErrConfigWatchUnsupport {
+		t.Errorf("Watch() error = %v, want %v", err, ErrConfigWatchUnsupport)
+	}
+}
+
+type mockLoader struct{}
+
+func (m *mockLoader) Init(...ConfOption) error            { return nil }
+func (m *mockLoader) Get(key string) (interface{}, error) { return nil, ErrConfigKeyNotSet }
+func (m *mockLoader) Unmarshal(dst interface{}) error     { return ErrConfigEmpty }
@@ -18,6 +18,34 @@ type LogConfig struct {
 	File       *FileConfig    `json:"File,omitempty"       yaml:"file,omitempty"`
 	Conn       *ConnConfig    `json:"Conn,omitempty"       yaml:"conn,omitempty"`
 	Zap        *ZapConfig     `json:"zap,omitempty"        yaml:"zap,omitempty"`
+	Syslog     *SyslogConfig  `json:"Syslog,omitempty"     yaml:"syslog,omitempty"`
+	Loki       *LokiConfig    `json:"Loki,omitempty"       yaml:"loki,omitempty"`
+
+	// 异步写入, 配置后默认 driver 由后台协程写入, 避免较慢的 driver 阻塞业务协程
+	Async *AsyncConfig `json:"Async,omitempty" yaml:"async,omitempty"`
+	// 日志采样、去重, 避免依赖故障时大量重复的日志
+	Sampling *SamplingConfig `json:"Sampling,omitempty" yaml:"sampling,omitempty"`
+	// 日志脱敏, 避免密码、手机号等敏感信息写入日志
+	Redact *RedactConfig `json:"Redact,omitempty" yaml:"redact,omitempty"`
+}
+
+type RedactConfig struct {
+	Mode  string   `json:"mode"`  // 脱敏方式, 选项：mask(默认, 保留首尾), hash, drop
+	Keys  []string `json:"keys"`  // 需要脱敏的 Extras 字段, 字段名包含其中之一即脱敏, 默认 password, token, phone, email
+	Words []string `json:"words"` // 消息中需要脱敏的敏感词
+}
+
+type SamplingConfig struct {
+	First      int           `json:"first"`      // 相同级别、format、调用位置的日志, 每个 interval 内前 first 条正常输出, 为 0 时不采样
+	Thereafter int           `json:"thereafter"` // 超过 first 后每 thereafter 条输出一条, 为 0 时全部抑制
+	Interval   time.Duration `json:"interval"`   // 统计周期, 默认 1s, 周期结束后输出被抑制条数的汇总
+}
+
+type AsyncConfig struct {
+	QueueSize    int           `json:"queuesize"`    // 队列长度, 默认 4096
+	Overflow     string        `json:"overflow"`     // 队列满时的处理方式, 选项：block(默认), drop_newest, drop_oldest, sample
+	SampleRate   int           `json:"samplerate"`   // overflow 为 sample 时, 每多少条写入一条, 默认 100
+	FlushTimeout time.Duration `json:"flushtimeout"` // Destroy 时等待队列写完的最长时间, 默认 5s
 }
 
 type FileConfig struct {
@@ -30,6 +58,10 @@ type FileConfig struct {
 	Level      string `json:"level"` // 日志打印登记，选项：EMER，ALRT，CRIT，EROR，WARN，INFO，DEBG，TRAC
 	PermitMask string `json:"permit"`
 
+	Compress     bool `json:"compress"`     // 是否在后台把切分出的旧日志文件压缩为 .gz
+	MaxBackups   int  `json:"maxbackups"`   // 最多保留的旧日志文件个数, 0 为不限制
+	MaxTotalSize int  `json:"maxtotalsize"` // 旧日志文件的总大小上限, 单位 MB, 超过后从最旧的开始删除, 0 为不限制
+
 	MaxSizeCurSize   int
 	MaxLinesCurLines int
 	DailyOpenDate    int
@@ -52,6 +84,37 @@ type ConnConfig struct {
 	illNetFlag     bool   //网络异常标记
 }
 
+type SyslogConfig struct {
+	Net      string        `json:"net"`      // 选项：udp, tcp, unix, unixgram, tcp, unix 使用 octet counting 分帧
+	Addr     string        `json:"addr"`     // 地址, 如 127.0.0.1:514, unixgram 时为 /dev/log
+	Level    string        `json:"level"`    // 日志打印登记，选项：EMER，ALRT，CRIT，EROR，WARN，INFO，DEBG，TRAC
+	Facility string        `json:"facility"` // 选项：kern, user, daemon, local0~local7 等, 默认 local0
+	AppName  string        `json:"appname"`  // 默认为 env.AppInfo 中的应用名
+	Hostname string        `json:"hostname"` // 默认为 env.AppInfo 中的主机名
+	SdId     string        `json:"sdid"`     // Extras 所在 structured data 的 SD-ID, 默认 extras@32473
+	Timeout  time.Duration `json:"timeout"`  // 连接、写入超时, 默认 5s
+	Retry    time.Duration `json:"retry"`    // 连接失败后, 多久之后再重连, 期间的日志丢弃, 默认 1s
+}
+
+type LokiConfig struct {
+	Url          string            `json:"url"`          // push 地址, 如 http://127.0.0.1:3100/loki/api/v1/push
+	Level        string            `json:"level"`        // 日志打印登记，选项：EMER，ALRT，CRIT，EROR，WARN，INFO，DEBG，TRAC
+	TenantId     string            `json:"tenantid"`     // 多租户时的 X-Scope-OrgID
+	Headers      map[string]string `json:"headers"`      // 额外的请求头, 如 Authorization
+	Labels       map[string]string `json:"labels"`       // 固定的 label, app, stage, host, level 由 env.AppInfo 与日志级别生成
+	LabelKeys    []string          `json:"labelkeys"`    // 作为 label 的 Extras 字段, 其余字段以 key=value 追加到日志末尾
+	Gzip         bool              `json:"gzip"`         // 请求体是否 gzip 压缩
+	QueueSize    int               `json:"queuesize"`    // 等待发送的日志条数上限, 超过时丢弃, 默认 4096
+	BatchSize    int               `json:"batchsize"`    // 每批最多多少条, 默认 1000
+	BatchWait    time.Duration     `json:"batchwait"`    // 每批最长等待时间, 默认 1s
+	Timeout      time.Duration     `json:"timeout"`      // 请求超时, 默认 5s
+	MaxRetries   int               `json:"maxretries"`   // 失败后最多重试次数, 默认 3
+	MinBackoff   time.Duration     `json:"minbackoff"`   // 首次重试等待时间, 之后每次翻倍, 默认 500ms
+	MaxBackoff   time.Duration     `json:"maxbackoff"`   // 重试等待时间上限, 默认 5s
+	SpillDir     string            `json:"spilldir"`     // 重试仍失败时写入的目录, 恢复后补发, 为空时丢弃
+	MaxSpillSize int               `json:"maxspillsize"` // 目录中文件总大小上限, 单位 MB, 超过时删除最旧的, 默认 100
+}
+
 type ZapConfig struct {
 	Level    string `json:"level"`
 	Colorful bool   `json:"color"`
@@ -9,7 +9,7 @@ import (
 // MysqlConfig
 // @Description: mysql 连接配置, 替代DbGroup配置, 封装读写分离逻辑
 type MysqlConfig struct {
-	Dsn         string
+	Dsn         string `secret:"true"`
 	MaxOpenConn int
 	MaxIdleConn int
 	// 最大空闲时间, 单位秒
@@ -33,9 +33,9 @@ type MysqlConfig struct {
 
 type MysqlSingleConfig struct {
 	// 可选
-	Dsn      string
+	Dsn      string `secret:"true"`
 	User     string
-	Password string
+	Password string `secret:"true"`
 	Addr     string
 	Db       string
 }
@@ -0,0 +1,126 @@
+package schema
+
+import (
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strings"
+)
+
+// 从源码中读取结构体与字段的注释, key 为 Type 或 Type.Field, 匿名结构体的字段为 Type.Field.SubField
+type comments struct {
+	enabled bool
+	pkgs    map[string]map[string]string
+}
+
+func newComments(enabled bool) *comments {
+	return &comments{enabled: enabled, pkgs: map[string]map[string]string{}}
+}
+
+func (c *comments) lookup(pkg, key string) string {
+	if !c.enabled || pkg == "" {
+		return ""
+	}
+	docs, ok := c.pkgs[pkg]
+	if !ok {
+		docs = loadComments(pkg)
+		c.pkgs[pkg] = docs
+	}
+	return docs[key]
+}
+
+// 找不到源码时返回空, 例如编译后的二进制在没有源码的机器上运行
+func loadComments(pkg string) map[string]string {
+	docs := map[string]string{}
+	bp, err := build.Import(pkg, ".", 0)
+	if err != nil {
+		return docs
+	}
+	fset := token.NewFileSet()
+	for _, name := range bp.GoFiles {
+		f, err := parser.ParseFile(fset, filepath.Join(bp.Dir, name), nil, parser.ParseComments)
+		if err != nil {
+			continue
+		}
+		for _, decl := range f.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				ts := spec.(*ast.TypeSpec)
+				doc := ts.Doc
+				if doc == nil && len(gen.Specs) == 1 {
+					doc = gen.Doc
+				}
+				if text := commentText(doc, ts.Comment); text != "" {
+					docs[ts.Name.Name] = text
+				}
+				if st, ok := ts.Type.(*ast.StructType); ok {
+					structComments(docs, ts.Name.Name, st)
+				}
+			}
+		}
+	}
+	return docs
+}
+
+func structComments(docs map[string]string, prefix string, st *ast.StructType) {
+	for _, field := range st.Fields.List {
+		text := commentText(field.Doc, field.Comment)
+		for _, name := range field.Names {
+			key := prefix + "." + name.Name
+			if text != "" {
+				docs[key] = text
+			}
+			if inner := innerStruct(field.Type); inner != nil {
+				structComments(docs, key, inner)
+			}
+		}
+		if len(field.Names) == 0 {
+			// 嵌入的匿名字段
+			if inner := innerStruct(field.Type); inner != nil {
+				structComments(docs, prefix, inner)
+			}
+		}
+	}
+}
+
+// 字段类型中的匿名结构体, 例如 struct{...}, *struct{...}, []struct{...}, map[string]struct{...}
+func innerStruct(expr ast.Expr) *ast.StructType {
+	switch t := expr.(type) {
+	case *ast.StructType:
+		return t
+	case *ast.StarExpr:
+		return innerStruct(t.X)
+	case *ast.ArrayType:
+		return innerStruct(t.Elt)
+	case *ast.MapType:
+		return innerStruct(t.Value)
+	}
+	return nil
+}
+
+// 合并字段上方与行尾的注释, 去掉 @Description: 之类的前缀
+func commentText(groups ...*ast.CommentGroup) string {
+	var lines []string
+	for _, group := range groups {
+		if group == nil {
+			continue
+		}
+		for _, line := range strings.Split(strings.TrimSpace(group.Text()), "\n") {
+			line = strings.TrimSpace(line)
+			if strings.HasPrefix(line, "@") {
+				if i := strings.IndexAny(line, ": "); i > 0 {
+					line = strings.TrimSpace(strings.TrimLeft(line[i:], ": "))
+				}
+			}
+			if line != "" {
+				lines = append(lines, line)
+			}
+		}
+	}
+	return strings.Join(lines, " ")
+}
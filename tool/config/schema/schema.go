@@ -0,0 +1,357 @@
+// Package schema 由配置结构体生成 JSON Schema, 编辑器可以据此补全、校验 config.yaml, CI 可以据此拒绝未知的配置项
+//
+// 配置项名称取自 yaml tag, 没有 yaml tag 时为小写的字段名; 描述取自结构体字段的注释
+//
+//	s := schema.Generate(&facade.Config{})
+//	out, _ := json.MarshalIndent(s, "", "  ")
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	Draft = "https://json-schema.org/draft/2020-12/schema"
+
+	// 本仓库的包, 其中的结构体都会展开
+	toolboxPkg = "github.com/senyu-up/toolbox"
+)
+
+// Schema JSON Schema 中用到的字段
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 interface{}        `json:"type,omitempty"` // string 或 []string
+	Format               string             `json:"format,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	PatternProperties    map[string]*Schema `json:"patternProperties,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"` // *Schema 或 false
+	Items                *Schema            `json:"items,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Default              interface{}        `json:"default,omitempty"`
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+}
+
+type option struct {
+	title           string
+	strict          bool
+	caseInsensitive bool
+	pkgs            []string
+	comments        bool
+}
+
+type Option func(*option)
+
+// OptWithTitle 指定 schema 的 title
+func OptWithTitle(title string) Option {
+	return func(o *option) {
+		o.title = title
+	}
+}
+
+// OptWithStrict 是否拒绝未知的配置项（additionalProperties: false）, 默认 true
+func OptWithStrict(strict bool) Option {
+	return func(o *option) {
+		o.strict = strict
+	}
+}
+
+// OptWithCaseInsensitive 配置项名称是否忽略大小写, 默认 true
+// viper 读取配置时忽略大小写, 例如 callDepth 与 calldepth 都能生效, 开启后用 patternProperties 匹配任意大小写
+func OptWithCaseInsensitive(caseInsensitive bool) Option {
+	return func(o *option) {
+		o.caseInsensitive = caseInsensitive
+	}
+}
+
+// OptWithPackages 需要展开的结构体所在的包前缀, 默认为本仓库与根结构体所在的仓库
+// 其他包中的结构体（例如 *gorm.DB）不是配置项, 不做限制
+func OptWithPackages(prefixes ...string) Option {
+	return func(o *option) {
+		o.pkgs = append(o.pkgs, prefixes...)
+	}
+}
+
+// OptWithComments 是否从源码中读取字段注释作为 description, 默认 true; 找不到源码时忽略
+func OptWithComments(comments bool) Option {
+	return func(o *option) {
+		o.comments = comments
+	}
+}
+
+type generator struct {
+	opt      *option
+	defs     map[string]*Schema
+	names    map[reflect.Type]string
+	comments *comments
+}
+
+// Generate 由配置结构体（或其指针）生成 JSON Schema
+func Generate(v interface{}, opts ...Option) *Schema {
+	o := &option{strict: true, caseInsensitive: true, comments: true}
+	for _, opt := range opts {
+		opt(o)
+	}
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	o.pkgs = append(o.pkgs, toolboxPkg, repoOf(t.PkgPath()))
+	g := &generator{opt: o, defs: map[string]*Schema{}, names: map[reflect.Type]string{}, comments: newComments(o.comments)}
+
+	root := g.structSchema(t, t.PkgPath(), t.Name())
+	root.Schema = Draft
+	root.Title = o.title
+	if root.Title == "" {
+		root.Title = t.Name()
+	}
+	if desc := g.comments.lookup(t.PkgPath(), t.Name()); desc != "" {
+		root.Description = desc
+	}
+	if 0 < len(g.defs) {
+		root.Defs = g.defs
+	}
+	return root
+}
+
+// Marshal 生成格式化后的 JSON Schema
+func Marshal(v interface{}, opts ...Option) ([]byte, error) {
+	out, err := json.MarshalIndent(Generate(v, opts...), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
+)
+
+// pkg, commentKey 用于查找匿名结构体的字段注释
+func (g *generator) schema(t reflect.Type, pkg, commentKey string) *Schema {
+	switch t {
+	case durationType:
+		// viper 支持 "5s" 形式的字符串, 也支持整数（纳秒）
+		return &Schema{Type: []string{"string", "integer"}}
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return g.schema(t.Elem(), pkg, commentKey)
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string"}
+		}
+		return &Schema{Type: "array", Items: g.schema(t.Elem(), pkg, commentKey)}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem(), pkg, commentKey)}
+	case reflect.Struct:
+		if !g.expandable(t) {
+			return &Schema{}
+		}
+		if t.Name() == "" {
+			// 匿名结构体直接展开, 注释以外层字段为前缀查找
+			return g.structSchema(t, pkg, commentKey)
+		}
+		return &Schema{Ref: "#/$defs/" + g.define(t)}
+	}
+	// interface{} 等任意类型
+	return &Schema{}
+}
+
+// 命名结构体放到 $defs 中, 返回名称
+func (g *generator) define(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	name := t.String()
+	for i := 2; g.defs[name] != nil; i++ {
+		name = t.String() + strings.Repeat("_", i-1)
+	}
+	g.names[t] = name
+	// 先占位, 避免结构体互相引用时无限递归
+	g.defs[name] = &Schema{}
+	*g.defs[name] = *g.structSchema(t, t.PkgPath(), t.Name())
+	if desc := g.comments.lookup(t.PkgPath(), t.Name()); desc != "" {
+		g.defs[name].Description = desc
+	}
+	return name
+}
+
+// 结构体的 schema, pkg 为注释所在的包, commentKey 为查找字段注释的前缀, 例如 KafkaConfig 或 KafkaConfig.SASL
+func (g *generator) structSchema(t reflect.Type, pkg, commentKey string) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	g.fields(s, t, pkg, commentKey)
+	if g.opt.strict {
+		s.AdditionalProperties = false
+	}
+	if 1 > len(s.PatternProperties) {
+		s.PatternProperties = nil
+	}
+	sort.Strings(s.Required)
+	return s
+}
+
+func (g *generator) fields(s *Schema, t reflect.Type, pkg, commentKey string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, inline, ok := fieldName(field)
+		if !ok || !configurable(field.Type) {
+			continue
+		}
+		ft := field.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if inline && ft.Kind() == reflect.Struct {
+			if ft.Name() != "" {
+				g.fields(s, ft, ft.PkgPath(), ft.Name())
+			} else {
+				g.fields(s, ft, pkg, commentKey+"."+field.Name)
+			}
+			continue
+		}
+
+		fieldKey := commentKey + "." + field.Name
+		prop := g.schema(field.Type, pkg, fieldKey)
+		// $ref 与 description 等关键字并列在 2020-12 中是允许的
+		prop.Description = g.comments.lookup(pkg, fieldKey)
+		if def, ok := field.Tag.Lookup("default"); ok {
+			prop.Default = defaultValue(def)
+		}
+		// 忽略大小写时 required 无法匹配其他大小写的写法, 只在区分大小写时生成
+		for _, rule := range strings.Split(field.Tag.Get("validate"), ",") {
+			if rule == "required" && !g.opt.caseInsensitive {
+				s.Required = append(s.Required, name)
+			}
+		}
+		s.Properties[name] = prop
+		if g.opt.caseInsensitive {
+			if s.PatternProperties == nil {
+				s.PatternProperties = map[string]*Schema{}
+			}
+			s.PatternProperties[caseInsensitivePattern(name, strings.ToLower(field.Name))] = prop
+		}
+	}
+}
+
+// 是否展开结构体, 只展开配置所在包中的结构体
+func (g *generator) expandable(t reflect.Type) bool {
+	if t.PkgPath() == "" {
+		return true
+	}
+	for _, prefix := range g.opt.pkgs {
+		if prefix != "" && strings.HasPrefix(t.PkgPath(), prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// 字段对应的配置项名称, 与 yaml.v3 一致: 优先使用 yaml tag, 否则为小写的字段名
+func fieldName(field reflect.StructField) (name string, inline, ok bool) {
+	tag := field.Tag.Get("yaml")
+	if tag == "-" {
+		return "", false, false
+	}
+	parts := strings.Split(tag, ",")
+	for _, opt := range parts[1:] {
+		if opt == "inline" {
+			inline = true
+		}
+	}
+	if field.Anonymous && parts[0] == "" {
+		inline = true
+	}
+	if !field.IsExported() && (parts[0] == "" || field.Anonymous) {
+		// 未导出字段只有显式指定 yaml tag 时才作为配置项, 例如 facade.Config
+		return "", false, false
+	}
+	name = parts[0]
+	if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+	return name, inline, true
+}
+
+// 函数、channel、接口（例如 redis.UniversalClient）不是配置项
+func configurable(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return false
+	case reflect.Interface:
+		return t.NumMethod() == 0
+	}
+	return true
+}
+
+// 忽略大小写匹配配置项名称的正则, JSON Schema 的正则不支持 (?i), 用字符集代替
+// 例如 poolsize => ^([pP][oO][oO][lL][sS][iI][zZ][eE])$
+func caseInsensitivePattern(names ...string) string {
+	var (
+		seen  = map[string]bool{}
+		items []string
+	)
+	for _, name := range names {
+		lower := strings.ToLower(name)
+		if seen[lower] {
+			continue
+		}
+		seen[lower] = true
+		var b strings.Builder
+		for _, r := range lower {
+			upper := unicode.ToUpper(r)
+			if upper != r {
+				b.WriteString("[" + string(r) + string(upper) + "]")
+				continue
+			}
+			if strings.ContainsRune(`\^$.|?*+()[]{}`, r) {
+				b.WriteRune('\\')
+			}
+			b.WriteRune(r)
+		}
+		items = append(items, b.String())
+	}
+	return "^(" + strings.Join(items, "|") + ")$"
+}
+
+// default tag 的值按 yaml 解析, 例如 "10" => 10, "true" => true
+func defaultValue(def string) interface{} {
+	var v interface{}
+	if err := yaml.Unmarshal([]byte(def), &v); err != nil || v == nil {
+		return def
+	}
+	return v
+}
+
+// 包路径所在的仓库, 例如 github.com/a/b/c => github.com/a/b
+func repoOf(pkg string) string {
+	parts := strings.Split(pkg, "/")
+	if len(parts) > 3 {
+		parts = parts[:3]
+	}
+	return strings.Join(parts, "/")
+}
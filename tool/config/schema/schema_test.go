@@ -0,0 +1,111 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/senyu-up/toolbox/tool/config"
+	"gopkg.in/yaml.v3"
+)
+
+type appConf struct {
+	App     config.App          `yaml:"app"`
+	Redis   *config.RedisConfig `yaml:"redis"`
+	Kafka   config.KafkaConfig  `yaml:"kafka"`
+	Mysql   config.MysqlConfig  `yaml:"mysql"`
+	Port    int                 `yaml:"port" default:"8080" validate:"required"`
+	Timeout time.Duration       `yaml:"timeout" default:"3s"`
+	Extra   map[string]string   `yaml:"extra"`
+	Hook    func()
+	secret  string
+}
+
+func compile(t *testing.T, opts ...Option) *jsonschema.Schema {
+	out, err := Marshal(&appConf{}, opts...)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	c := jsonschema.NewCompiler()
+	if err = c.AddResource("schema.json", strings.NewReader(string(out))); err != nil {
+		t.Fatalf("AddResource() error = %v", err)
+	}
+	s, err := c.Compile("schema.json")
+	if err != nil {
+		t.Fatalf("Compile() error = %v, schema %s", err, out)
+	}
+	return s
+}
+
+func TestGenerate_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []Option
+		doc     string
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			doc: `app: {name: demo, stage: local, dev: true}
+redis: {addrs: ["localhost:6379"], poolSize: 10, isCluster: false}
+kafka: {brokers: [a], sasl: {enable: true, user: u, password: p}, consumers: [{topic: t, group: g}]}
+mysql: {dsn: dsn, maxIdleTime: 10s, slave: [{dsn: slave}]}
+PORT: 80
+timeout: 5s
+extra: {a: b}`,
+		},
+		{name: "duration int", doc: "timeout: 1000\n"},
+		{name: "unknown key", doc: "redis: {addrs: [a], unknown: 1}\n", wantErr: true},
+		{name: "unknown root key", doc: "unknown: 1\n", wantErr: true},
+		{name: "unknown anonymous struct key", doc: "kafka: {sasl: {token: t}}\n", wantErr: true},
+		{name: "wrong type", doc: "redis: {poolsize: ten}\n", wantErr: true},
+		{name: "not config", doc: "hook: a\n", wantErr: true},
+		{name: "not strict", opts: []Option{OptWithStrict(false)}, doc: "unknown: 1\nredis: {unknown: 1}\n"},
+		{name: "case sensitive", opts: []Option{OptWithCaseInsensitive(false)}, doc: "PORT: 80\n", wantErr: true},
+		{name: "required", opts: []Option{OptWithCaseInsensitive(false)}, doc: "redis: {poolSize: 10}\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var doc interface{}
+			if err := yaml.Unmarshal([]byte(tt.doc), &doc); err != nil {
+				t.Fatalf("yaml.Unmarshal() error = %v", err)
+			}
+			err := compile(t, tt.opts...).Validate(doc)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGenerate_Comments(t *testing.T) {
+	s := Generate(&appConf{})
+	if s.Title != "appConf" || s.Schema != Draft {
+		t.Errorf("Generate() title = %s, schema = %s", s.Title, s.Schema)
+	}
+	if got := s.Properties["redis"].Ref; got != "#/$defs/config.RedisConfig" {
+		t.Errorf("Generate() redis ref = %s", got)
+	}
+	if got := s.Properties["port"].Default; got != 8080 {
+		t.Errorf("Generate() port default = %v", got)
+	}
+	tests := []struct {
+		def, field, want string
+	}{
+		{def: "config.RedisConfig", field: "addrs", want: "Redis 集群地址列表"},
+		{def: "config.KafkaConfig", field: "sasl", want: "consumer sasl 配置"},
+		{def: "config.MysqlConfig", field: "maxidletime", want: "最大空闲时间, 单位秒"},
+	}
+	for _, tt := range tests {
+		if got := s.Defs[tt.def].Properties[tt.field].Description; !strings.Contains(got, tt.want) {
+			t.Errorf("Generate() %s.%s description = %q, want %q", tt.def, tt.field, got, tt.want)
+		}
+	}
+	if _, ok := s.Defs["config.MysqlConfig"].Properties["logger"]; ok {
+		t.Errorf("Generate() interface field logger should be skipped")
+	}
+	if _, ok := s.Properties["secret"]; ok {
+		t.Errorf("Generate() unexported field without yaml tag should be skipped")
+	}
+}
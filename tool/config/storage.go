@@ -2,7 +2,7 @@ package config
 
 type ImageAudit struct {
 	SecretId  string `yaml:"secretid"`
-	SecretKey string `yaml:"secretkey"`
+	SecretKey string `yaml:"secretkey" secret:"true"`
 
 	Bucket string `yaml:"bucket"` // 桶
 	Regin  string `yaml:"regin"`  // 地区
@@ -2,10 +2,32 @@ package config
 
 import "github.com/go-redis/redis"
 
+// LeakyBucketConf
+// @Description: 漏桶的配置, 请求先进入桶中, 按固定速率流出, 桶满时拒绝
 type LeakyBucketConf struct {
-	// redis key name
+	// 必填, redis key
+	Key string
+	// 必填, 桶的容量
+	Capacity uint64
+	// 必填, 每个周期流出的请求数
+	NumPerPeriod uint64
+	// 可选,周期, 单位秒, 默认1秒
+	Period uint32
+	// 可选, redis cli, 为空时只使用本地限流
+	Redis redis.UniversalClient
+}
+
+// SlidingWindowConf
+// @Description: 滑动窗口(日志)配置, 任意一个周期内的请求数不超过 NumPerPeriod
+type SlidingWindowConf struct {
+	// 必填, redis key
 	Key string
-	//
+	// 必填, 每个周期允许的请求数
+	NumPerPeriod uint64
+	// 可选,周期, 单位秒, 默认1秒
+	Period uint32
+	// 可选, redis cli, 为空时只使用本地限流
+	Redis redis.UniversalClient
 }
 
 // StickyWindowConf
@@ -17,7 +39,7 @@ type StickyWindowConf struct {
 	NumPerPeriod uint64
 	// 可选,周期, 单位秒, 默认1秒
 	Period uint32
-	// 必填, redis cli
+	// 可选, redis cli, 为空时只使用本地限流
 	Redis redis.UniversalClient
 }
 
@@ -32,6 +54,6 @@ type TokenBucketConf struct {
 	NumPerPeriod uint64
 	// 可选,周期, 单位秒, 默认1秒
 	Period uint32
-	// 必填, redis cli
+	// 可选, redis cli, 为空时只使用本地限流
 	Redis redis.UniversalClient
 }
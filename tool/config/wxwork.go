@@ -4,7 +4,7 @@ import "github.com/go-redis/redis"
 
 type WeWorkConfig struct {
 	CorpId  string `yaml:"corpId"`
-	Secret  string `yaml:"secret"`
+	Secret  string `yaml:"secret" secret:"true"`
 	AgentId string `yaml:"agentId"`
 
 	//deprecated
@@ -15,7 +15,7 @@ type WeWorkConfig struct {
 
 type QwRobotConfig struct {
 	// 机器人地址
-	Webhook string `yaml:"webhook"`
+	Webhook string `yaml:"webhook" secret:"true"`
 	// 常规频率限制, 支持 n/S n/M n/H
 	InfoFreqLimit string `yaml:"infoFreqLimit"`
 	// 警告消息频率限制, 支持 n/S n/M n/H
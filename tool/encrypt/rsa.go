@@ -265,3 +265,42 @@ func AesCBCDecrypt(data string, key []byte) ([]byte, error) {
 	unPadding := int(crypted[len(crypted)-1])
 	return crypted[:(len(crypted) - unPadding)], err
 }
+
+// AesGCMEncrypt aes gcm模式加密后转base64, 随机 nonce 拼接在密文前面
+// key 长度必须为 16, 24 或 32 字节
+func AesGCMEncrypt(data, key []byte) (string, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, data, nil)), nil
+}
+
+// AesGCMDecrypt base64解码后 aes gcm模式解密, 密文格式与 AesGCMEncrypt 一致
+func AesGCMDecrypt(data string, key []byte) ([]byte, error) {
+	dataByte, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(dataByte) < gcm.NonceSize() {
+		return nil, errors.New("crypto/cipher: ciphertext too short")
+	}
+	nonce, ciphertext := dataByte[:gcm.NonceSize()], dataByte[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
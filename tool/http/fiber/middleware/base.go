@@ -5,7 +5,6 @@ import (
 	"github.com/arsmn/fiber-swagger/v2"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
-	"github.com/gofiber/fiber/v2/middleware/limiter"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/monitor"
 	"github.com/gofiber/fiber/v2/middleware/recover"
@@ -104,11 +103,6 @@ func Monitor() func(c *fiber.Ctx) error {
 //	return pprof.New()
 //}
 
-// Limiter 限频器
-func Limiter() func(c *fiber.Ctx) error {
-	return limiter.New()
-}
-
 var defaultTrustIp = map[string]struct{}{
 	"127.0.0.1": {},
 	"0.0.0.0":   {},
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+	"github.com/senyu-up/toolbox/enum"
+	"github.com/senyu-up/toolbox/tool/config"
+	"github.com/senyu-up/toolbox/tool/http/fiber/controller"
+	"github.com/senyu-up/toolbox/tool/traffic_limit"
+	"github.com/spf13/cast"
+)
+
+// Limiter 限频器, 按 config.Limiter 的规则限流, 不传配置时使用 fiber 默认的限流器
+// 命中规则的请求带有 RateLimit-* 响应头, 超限时返回 429; 配置错误时 panic
+// Taker 的 input 为 *fiber.Ctx, 未配置 Taker 时按 SourceKey 依次从 header、query、路由参数获取, SourceKey 为 ip 时使用客户端 ip
+//
+//	app.Use(middleware.Limiter(config.Limiter{
+//		AppName: "user",
+//		Rules: []config.LimiterRule{{
+//			Group: []config.LimiterGroup{{Name: "login", Uris: []string{"/user/login", "/user/sms/*"}}},
+//			Burst: "100/m",
+//		}},
+//	}))
+func Limiter(conf ...config.Limiter) func(c *fiber.Ctx) error {
+	if len(conf) == 0 {
+		return limiter.New()
+	}
+	rl, err := traffic_limit.NewRuleLimiter(conf[0])
+	if err != nil {
+		panic(err)
+	}
+	return func(c *fiber.Ctx) error {
+		take := func(key string) string {
+			if key == "ip" {
+				return c.IP()
+			}
+			if v := c.Get(key); v != "" {
+				return v
+			}
+			if v := c.Query(key); v != "" {
+				return v
+			}
+			return c.Params(key)
+		}
+		d := rl.Check(c.Context(), c, c.Path(), c.IP(), take)
+		for k, v := range d.Headers() {
+			c.Set(k, v)
+		}
+		if !d.Matched || d.Allowed {
+			return c.Next()
+		}
+		return c.Status(fiber.StatusTooManyRequests).JSON(controller.JsonResponse{
+			Code:      fiber.StatusTooManyRequests,
+			Msg:       "请求过于频繁, 请稍后重试",
+			RequestId: cast.ToString(c.Context().UserValue(enum.RequestId)),
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/senyu-up/toolbox/tool/config"
+	"github.com/senyu-up/toolbox/tool/http/fiber/controller"
+)
+
+func TestLimiter(t *testing.T) {
+	newApp := func(name string, trust ...string) *fiber.App {
+		app := fiber.New()
+		app.Get("/order/:uid", Limiter(config.Limiter{
+			AppName: name,
+			Trust:   config.Trust{SubnetMask: trust},
+			Rules: []config.LimiterRule{
+				{Group: []config.LimiterGroup{{Name: "order", Uris: []string{"/order/*"}}}, Burst: "1/m", SourceKey: "uid"},
+			},
+		}), func(c *fiber.Ctx) error { return c.SendString("ok") })
+		return app
+	}
+	do := func(app *fiber.App, target string, header map[string]string) *http.Response {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("Test(%s) error = %v", target, err)
+		}
+		return resp
+	}
+
+	app := newApp("fiber_limiter", "10.0.0.0/8")
+	// 依次从 header、query、路由参数获取 uid
+	resp := do(app, "/order/3?uid=2", map[string]string{"uid": "1"})
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("RateLimit-Limit") != "1" || resp.Header.Get("RateLimit-Remaining") != "0" {
+		t.Fatalf("first = %d %v", resp.StatusCode, resp.Header)
+	}
+	if resp = do(app, "/order/3?uid=1", nil); resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("query uid = %d, should share the header uid bucket", resp.StatusCode)
+	}
+	if resp.Header.Get("Retry-After") == "" {
+		t.Errorf("limited headers = %v", resp.Header)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	var jr controller.JsonResponse
+	if err := json.Unmarshal(body, &jr); err != nil || jr.Code != http.StatusTooManyRequests {
+		t.Errorf("limited body = %s, error %v", body, err)
+	}
+	if resp = do(app, "/order/1", nil); resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("param uid = %d, should share the header uid bucket", resp.StatusCode)
+	}
+	if resp = do(app, "/order/2", nil); resp.StatusCode != http.StatusOK {
+		t.Errorf("other uid = %d, want 200", resp.StatusCode)
+	}
+	// 未配置 ProxyHeader 时不信任 X-Forwarded-For
+	if resp = do(app, "/order/1", map[string]string{"X-Forwarded-For": "10.0.0.1"}); resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("spoofed trusted ip = %d, want 429", resp.StatusCode)
+	}
+
+	// app.Test 的对端地址为 0.0.0.0, 在可信子网内不限流
+	trusted := newApp("fiber_limiter_trust", "0.0.0.0")
+	for i := 0; i < 2; i++ {
+		if resp = do(trusted, "/order/1", nil); resp.StatusCode != http.StatusOK || resp.Header.Get("RateLimit-Limit") != "" {
+			t.Errorf("trusted ip %d = %d %v", i, resp.StatusCode, resp.Header)
+		}
+	}
+}
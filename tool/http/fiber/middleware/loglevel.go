@@ -0,0 +1,16 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/senyu-up/toolbox/tool/http/http_health"
+)
+
+// LogLevel 运行时查看、修改日志级别, 与 HealthChecker 的 /debug/loglevel 一致
+// 建议配合 TrustIp 只开放给内网
+//
+//	app.Add("GET", "/debug/loglevel", middleware.TrustIp(), middleware.LogLevel())
+//	app.Add("PUT", "/debug/loglevel", middleware.TrustIp(), middleware.LogLevel())
+func LogLevel() func(c *fiber.Ctx) error {
+	return adaptor.HTTPHandlerFunc(http_health.LogLevelHandler)
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/senyu-up/toolbox/enum"
+	"github.com/senyu-up/toolbox/tool/config"
+	"github.com/senyu-up/toolbox/tool/http/gin_server/controller"
+	"github.com/senyu-up/toolbox/tool/traffic_limit"
+)
+
+// Limiter 按 config.Limiter 的规则限流, 命中规则的请求带有 RateLimit-* 响应头, 超限时返回 429; 配置错误时 panic
+// Taker 的 input 为 *gin.Context, 未配置 Taker 时按 SourceKey 依次从 header、query、路由参数获取, SourceKey 为 ip 时使用客户端 ip
+// 可信子网与按 ip 限流都使用连接的对端地址(RemoteIP), 不信任可伪造的 X-Forwarded-For;
+// 部署在代理之后时, 通过 Taker 或代理设置的 header 获取真实 ip
+//
+//	engine.Use(middleware.Limiter(config.Limiter{
+//		AppName:  "user",
+//		Category: config.LimiterTypeDistributed,
+//		Redis:    redisCli,
+//		Rules: []config.LimiterRule{{
+//			Group:     []config.LimiterGroup{{Name: "order", Uris: []string{"/order/*"}}},
+//			Burst:     "10/s",
+//			SourceKey: "uid",
+//		}},
+//	}))
+func Limiter(conf config.Limiter) gin.HandlerFunc {
+	rl, err := traffic_limit.NewRuleLimiter(conf)
+	if err != nil {
+		panic(err)
+	}
+	return func(c *gin.Context) {
+		take := func(key string) string {
+			if key == "ip" {
+				return c.RemoteIP()
+			}
+			if v := c.GetHeader(key); v != "" {
+				return v
+			}
+			if v := c.Query(key); v != "" {
+				return v
+			}
+			return c.Param(key)
+		}
+		d := rl.Check(c, c, c.Request.URL.Path, c.RemoteIP(), take)
+		for k, v := range d.Headers() {
+			c.Header(k, v)
+		}
+		if !d.Matched || d.Allowed {
+			c.Next()
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, controller.CommonResp{
+			Code:      http.StatusTooManyRequests,
+			Msg:       "请求过于频繁, 请稍后重试",
+			RequestId: c.GetString(enum.RequestId),
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/senyu-up/toolbox/tool/config"
+	"github.com/senyu-up/toolbox/tool/http/gin_server/controller"
+)
+
+func TestLimiter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(Limiter(config.Limiter{
+		AppName: "gin_limiter",
+		Trust:   config.Trust{SubnetMask: []string{"10.0.0.0/8"}},
+		Rules: []config.LimiterRule{
+			{Group: []config.LimiterGroup{{Name: "order", Uris: []string{"/order/*"}}}, Burst: "1/m", SourceKey: "uid"},
+			{Group: []config.LimiterGroup{{Name: "sms", Uris: []string{"/sms"}}}, Burst: "1/m", SourceKey: "ip"},
+		},
+	}))
+	ok := func(c *gin.Context) { c.String(http.StatusOK, "ok") }
+	engine.GET("/order/:uid", ok)
+	engine.GET("/sms", ok)
+
+	do := func(target, remote string, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.RemoteAddr = remote + ":12345"
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	// 依次从 header、query、路由参数获取 uid
+	w := do("/order/3?uid=2", "1.1.1.1", map[string]string{"uid": "1"})
+	if w.Code != http.StatusOK || w.Header().Get("RateLimit-Limit") != "1" || w.Header().Get("RateLimit-Remaining") != "0" {
+		t.Fatalf("first = %d %v", w.Code, w.Header())
+	}
+	if w = do("/order/3?uid=1", "1.1.1.1", nil); w.Code != http.StatusTooManyRequests {
+		t.Errorf("query uid = %d, should share the header uid bucket", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Errorf("limited headers = %v", w.Header())
+	}
+	var resp controller.CommonResp
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Code != http.StatusTooManyRequests {
+		t.Errorf("limited body = %s, error %v", w.Body, err)
+	}
+	if w = do("/order/1", "1.1.1.1", nil); w.Code != http.StatusTooManyRequests {
+		t.Errorf("param uid = %d, should share the header uid bucket", w.Code)
+	}
+	if w = do("/order/2", "1.1.1.1", nil); w.Code != http.StatusOK {
+		t.Errorf("other uid = %d, want 200", w.Code)
+	}
+
+	// 可信子网按对端地址判断, 伪造 X-Forwarded-For 不能绕过限流
+	if w = do("/order/1", "10.1.2.3", nil); w.Code != http.StatusOK || w.Header().Get("RateLimit-Limit") != "" {
+		t.Errorf("trusted ip = %d %v", w.Code, w.Header())
+	}
+	if w = do("/order/1", "1.1.1.1", map[string]string{"X-Forwarded-For": "10.0.0.1"}); w.Code != http.StatusTooManyRequests {
+		t.Errorf("spoofed trusted ip = %d, want 429", w.Code)
+	}
+
+	// 按 ip 限流时, 更换 X-Forwarded-For 不会得到新的限额
+	if w = do("/sms", "2.2.2.2", map[string]string{"X-Forwarded-For": "3.3.3.1"}); w.Code != http.StatusOK {
+		t.Fatalf("sms first = %d", w.Code)
+	}
+	if w = do("/sms", "2.2.2.2", map[string]string{"X-Forwarded-For": "3.3.3.2"}); w.Code != http.StatusTooManyRequests {
+		t.Errorf("spoofed source ip = %d, want 429", w.Code)
+	}
+}
@@ -0,0 +1,15 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/senyu-up/toolbox/tool/http/http_health"
+)
+
+// LogLevel 运行时查看、修改日志级别, 与 HealthChecker 的 /debug/loglevel 一致
+// 接口没有鉴权, 只注册在内网可访问的路由组上
+//
+//	engine.GET("/debug/loglevel", middleware.LogLevel())
+//	engine.PUT("/debug/loglevel", middleware.LogLevel())
+func LogLevel() gin.HandlerFunc {
+	return gin.WrapF(http_health.LogLevelHandler)
+}
@@ -0,0 +1,37 @@
+package http_health
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const debugPrefix = "/debug/"
+
+var (
+	debugHandlers = make(map[string]http.HandlerFunc)
+	debugLock     sync.RWMutex
+)
+
+// DebugHandle 注册额外的调试接口, path 需以 /debug/ 开头, 如 recent 包的 /debug/logs
+// HealthChecker 在收到请求时查找, 先创建 HealthChecker 再注册同样有效
+func DebugHandle(path string, handler http.HandlerFunc) {
+	if !strings.HasPrefix(path, debugPrefix) {
+		panic("http_health: debug handler path must start with " + debugPrefix)
+	}
+	debugLock.Lock()
+	defer debugLock.Unlock()
+	debugHandlers[path] = handler
+}
+
+// DebugHandler 按路径分发 DebugHandle 注册的调试接口, /debug/pprof/, /debug/loglevel 由 mux 优先匹配
+func DebugHandler(w http.ResponseWriter, r *http.Request) {
+	debugLock.RLock()
+	handler, ok := debugHandlers[r.URL.Path]
+	debugLock.RUnlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	handler(w, r)
+}
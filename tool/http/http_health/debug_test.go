@@ -0,0 +1,53 @@
+package http_health
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDebugHandle(t *testing.T) {
+	h, err := NewHttpHealthCheckServer(HealthOptionWithPort(8080))
+	if err != nil {
+		t.Fatalf("NewHttpHealthCheckServer() error = %v", err)
+	}
+	// 创建 HealthChecker 之后注册同样有效
+	DebugHandle("/debug/demo", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("demo"))
+	})
+	tests := []struct {
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{path: "/debug/demo", wantStatus: http.StatusOK, wantBody: "demo"},
+		{path: "/debug/unknown", wantStatus: http.StatusNotFound},
+		{path: LogLevelPath, wantStatus: http.StatusNotFound}, // 默认不开启
+		{path: "/debug/pprof/", wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		h.server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if w.Code != tt.wantStatus || tt.wantBody != "" && w.Body.String() != tt.wantBody {
+			t.Errorf("%s status = %d, body = %s", tt.path, w.Code, w.Body)
+		}
+	}
+
+
+	h, err = NewHttpHealthCheckServer(HealthOptionWithPort(8080), HealthOptionWithEnableLogLevel(true))
+	if err != nil {
+		t.Fatalf("NewHttpHealthCheckServer() error = %v", err)
+	}
+	w := httptest.NewRecorder()
+	h.server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, LogLevelPath, nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("enabled %s status = %d, body = %s", LogLevelPath, w.Code, w.Body)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("DebugHandle() should panic when path is not under /debug/")
+		}
+	}()
+	DebugHandle("/logs", nil)
+}
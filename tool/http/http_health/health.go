@@ -64,6 +64,10 @@ func NewHttpHealthCheckServer(opts ...HealthOption) (*HealthChecker, error) {
 	} else {
 		logger.Info("http health checker pprof disabled")
 	}
+	if conf.EnableLogLevel {
+		mux.HandleFunc(LogLevelPath, LogLevelHandler)
+	}
+	mux.HandleFunc(debugPrefix, DebugHandler)
 
 	return &HealthChecker{
 		conf:   conf,
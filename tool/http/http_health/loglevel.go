@@ -0,0 +1,73 @@
+package http_health
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/senyu-up/toolbox/tool/logger"
+)
+
+// LogLevelPath 运行时查看、修改日志级别的路由
+const LogLevelPath = "/debug/loglevel"
+
+// LogLevelReq 修改日志级别的参数
+type LogLevelReq struct {
+	Adapter string `json:"adapter"` // driver 名称, 如 console, file, zap, 为空时修改全部
+	Level   string `json:"level"`   // 日志级别, 选项：EMER，ALRT，CRIT，EROR，WARN，INFO，DEBG，TRAC
+	TTL     string `json:"ttl"`     // 临时调整的时长, 如 5m, 到期后恢复, 为空时永久修改
+}
+
+// LogLevelHandler GET 返回各 driver 当前的日志级别, PUT 修改日志级别
+//
+//	curl localhost/debug/loglevel
+//	curl -X PUT localhost/debug/loglevel -d '{"level":"DEBG","ttl":"5m"}'
+func LogLevelHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var req LogLevelReq
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJson(w, http.StatusBadRequest, map[string]string{"error": "invalid body: " + err.Error()})
+			return
+		}
+		if err := SetLogLevel(req); err != nil {
+			status := http.StatusBadRequest
+			if errors.Is(err, logger.ErrAdapterNotFound) {
+				status = http.StatusNotFound
+			}
+			writeJson(w, status, map[string]string{"error": err.Error()})
+			return
+		}
+		logger.Warn("log level changed, adapter: %q, level: %s, ttl: %q", req.Adapter, req.Level, req.TTL)
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		writeJson(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	writeJson(w, http.StatusOK, logger.Levels())
+}
+
+// SetLogLevel 按请求参数修改日志级别, 供 fiber, gin 的 handler 复用
+func SetLogLevel(req LogLevelReq) error {
+	level, ok := logger.LevelMap[req.Level]
+	if !ok || req.Level == "" {
+		return fmt.Errorf("%w: %q", logger.ErrInvalidLogLevel, req.Level)
+	}
+	var ttl time.Duration
+	if req.TTL != "" {
+		var err error
+		if ttl, err = time.ParseDuration(req.TTL); err != nil {
+			return fmt.Errorf("invalid ttl %q: %w", req.TTL, err)
+		}
+	}
+	return logger.SetLevel(req.Adapter, level, ttl)
+}
+
+func writeJson(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,55 @@
+package http_health
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/senyu-up/toolbox/tool/config"
+	"github.com/senyu-up/toolbox/tool/logger"
+)
+
+func TestLogLevelHandler(t *testing.T) {
+	if _, err := logger.InitDefaultLoggerByConf(&config.LogConfig{Console: &config.ConsoleConfig{Level: "INFO"}}); err != nil {
+		t.Fatalf("InitDefaultLoggerByConf() error = %v", err)
+	}
+	tests := []struct {
+		name       string
+		method     string
+		body       string
+		wantStatus int
+		wantLevel  string
+	}{
+		{name: "get", method: http.MethodGet, wantStatus: http.StatusOK, wantLevel: "INFO"},
+		{name: "put", method: http.MethodPut, body: `{"adapter":"console","level":"WARN"}`, wantStatus: http.StatusOK, wantLevel: "WARN"},
+		{name: "put ttl", method: http.MethodPut, body: `{"level":"DEBG","ttl":"5m"}`, wantStatus: http.StatusOK, wantLevel: "DEBG"},
+		{name: "invalid level", method: http.MethodPut, body: `{"level":"debug"}`, wantStatus: http.StatusBadRequest},
+		{name: "invalid ttl", method: http.MethodPut, body: `{"level":"DEBG","ttl":"5"}`, wantStatus: http.StatusBadRequest},
+		{name: "invalid body", method: http.MethodPut, body: `level=DEBG`, wantStatus: http.StatusBadRequest},
+		{name: "unknown adapter", method: http.MethodPut, body: `{"adapter":"nsq","level":"DEBG"}`, wantStatus: http.StatusNotFound},
+		{name: "method not allowed", method: http.MethodPost, wantStatus: http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			LogLevelHandler(w, httptest.NewRequest(tt.method, LogLevelPath, strings.NewReader(tt.body)))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantLevel == "" {
+				return
+			}
+			var levels map[string]logger.LevelInfo
+			if err := json.Unmarshal(w.Body.Bytes(), &levels); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if got := levels[logger.AdapterConsole].Level; got != tt.wantLevel {
+				t.Errorf("console level = %s, want %s", got, tt.wantLevel)
+			}
+		})
+	}
+	// 恢复临时调整前的级别
+	logger.SetLevel("", logger.LevelInformational, 0)
+}
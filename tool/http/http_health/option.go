@@ -33,3 +33,10 @@ func HealthOptionWithDisableLog(disable bool) HealthOption {
 		option.DisableLog = disable
 	}
 }
+
+// HealthOptionWithEnableLogLevel 开启 /debug/loglevel, 默认关闭
+func HealthOptionWithEnableLogLevel(enable bool) HealthOption {
+	return func(option *config.HealthCheck) {
+		option.EnableLogLevel = enable
+	}
+}
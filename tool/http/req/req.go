@@ -158,6 +158,19 @@ func (c *Client) Context(ctx context.Context) *Client {
 	return c
 }
 
+// GetContext
+// @description 请求当前使用的context
+func (c *Client) GetContext() context.Context {
+	return c.r.Context()
+}
+
+// SetContext
+// @description 只替换请求的context, 用于超时取消, 不修改 request id 等请求头
+func (c *Client) SetContext(ctx context.Context) *Client {
+	c.r.SetContext(ctx)
+	return c
+}
+
 // RequestId
 // @description 使用脚本触发, 自定义requestId
 func (c *Client) RequestId(id string) *Client {
@@ -112,6 +112,20 @@ func SetLogger(adapterName string, logDriver Driver) error {
 	return nil
 }
 
+// WrapDriver 包装全局 logger 当前使用的 driver, 并以相同的名称注册, 用于 recent.Install 之类的扩展
+// 调用前通过 Ctx, TraceId 等 clone 出的 logger 仍使用原 driver
+func WrapDriver(wrap func(Driver) Driver) Driver {
+	if logInst == nil {
+		initDefaultLogger()
+	}
+	logLock.Lock()
+	defer logLock.Unlock()
+	d := wrap(logInst.adapter)
+	register(logInst.adapterName, d)
+	logInst.adapter = d
+	return d
+}
+
 func SetCallBack(callBack Driver) error {
 	if logInst == nil {
 		initDefaultLogger()
@@ -180,6 +194,22 @@ func InitLoggerByConf(conf *config.LogConfig) (log Log, err error) {
 		register(AdapterConn, connLogger)
 		adapter = AdapterConn
 	}
+	if conf.Syslog != nil {
+		var syslogLogger = &Syslog{LogLevel: Level()}
+		if err = syslogLogger.InitByConf(*conf.Syslog); err != nil {
+			return
+		}
+		register(AdapterSyslog, syslogLogger)
+		adapter = AdapterSyslog
+	}
+	if conf.Loki != nil {
+		var lokiLogger = &Loki{LogLevel: Level()}
+		if err = lokiLogger.InitByConf(*conf.Loki); err != nil {
+			return
+		}
+		register(AdapterLoki, lokiLogger)
+		adapter = AdapterLoki
+	}
 
 	if driver, ok = adapters[conf.DefaultLog]; ok {
 		// 如果设置了 default，按照 default 得取
@@ -193,11 +223,31 @@ func InitLoggerByConf(conf *config.LogConfig) (log Log, err error) {
 		}
 	}
 
+	if _, isAsync := driver.(*Async); conf.Async != nil && driver != nil && !isAsync {
+		// 包装为异步 driver, 注册时替换原 driver, SwitchLogger 切换回来时同样为异步
+		var async *Async
+		if async, err = NewAsyncByConf(driver, *conf.Async); err != nil {
+			return
+		}
+		driver = async
+		register(adapter, driver)
+	}
+
 	// 初始化 baseLogger
 	var opts = []LogOption{LogOptWithTimeFormat(conf.TimeFormat),
 		LogOptWithAppName(conf.AppName),
 		LogOptWithCallDepth(conf.CallDepth),
 		LogOptWithUsePath(conf.UsePath)}
+	if conf.Redact != nil {
+		var redactor *Redactor
+		if redactor, err = NewRedactorByConf(*conf.Redact); err != nil {
+			return
+		}
+		opts = append(opts, LogOptWithRedactor(redactor))
+	}
+	if conf.Sampling != nil {
+		opts = append(opts, LogOptWithSampling(conf.Sampling.First, conf.Sampling.Thereafter, conf.Sampling.Interval))
+	}
 	if 0 < len(adapter) {
 		// 如果有效
 		theLog = newBaseLogger(adapter, driver, opts...)
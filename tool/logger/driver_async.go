@@ -0,0 +1,275 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/senyu-up/toolbox/tool/config"
+)
+
+// OverflowPolicy 异步日志队列满时的处理方式
+type OverflowPolicy int
+
+const (
+	OverflowBlock      OverflowPolicy = iota // 阻塞调用方, 直到队列有空位
+	OverflowDropNewest                       // 丢弃当前这条日志
+	OverflowDropOldest                       // 丢弃队列中最旧的日志, 写入当前这条
+	OverflowSample                           // 每 sampleRate 条阻塞写入一条, 其余丢弃
+)
+
+// OverflowMap 配置中的 overflow 与 OverflowPolicy 映射关系
+var OverflowMap = map[string]OverflowPolicy{
+	"block":       OverflowBlock,
+	"drop_newest": OverflowDropNewest,
+	"drop_oldest": OverflowDropOldest,
+	"sample":      OverflowSample,
+	"":            OverflowBlock,
+}
+
+const (
+	defaultAsyncQueueSize    = 4096
+	defaultAsyncSampleRate   = 100
+	defaultAsyncFlushTimeout = 5 * time.Second
+)
+
+type asyncEntry struct {
+	driver Driver // 写入的 Driver, AddCallerSkip 的副本与原 Async 共用队列, 各自写入自己的 Driver
+	when   time.Time
+	msg    string
+	level  LogLevel
+	extra  []Field
+}
+
+// Async 异步日志 driver, 包装任意 Driver, 日志先写入有界队列, 由后台协程写入被包装的 Driver,
+// 避免 ConnLogger, QWRobot 之类较慢的 driver 阻塞业务协程
+//
+// Emer, Alert 级别的日志仍同步写入, zap driver 在这两个级别会退出进程或 panic
+// zap driver 的 caller 由调用栈计算, 异步写入时不准确, 不建议包装
+type Async struct {
+	sampled uint64 // 64 位原子操作的字段放在最前, 保证 32 位平台上对齐
+	dropped uint64
+
+	driver       Driver
+	queue        chan asyncEntry
+	queueSize    int
+	overflow     OverflowPolicy
+	sampleRate   uint64
+	flushTimeout time.Duration
+
+	lock    sync.Mutex // 保护 closed, 避免重复 Destroy
+	closed  bool
+	closing chan struct{} // Destroy 时关闭, 不再接收日志, 阻塞在入队的调用方直接返回
+	done    chan struct{}
+}
+
+func NewAsync(d Driver, opts ...AsyncOption) *Async {
+	a := &Async{
+		driver:       d,
+		queueSize:    defaultAsyncQueueSize,
+		overflow:     OverflowBlock,
+		sampleRate:   defaultAsyncSampleRate,
+		flushTimeout: defaultAsyncFlushTimeout,
+		closing:      make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	a.queue = make(chan asyncEntry, a.queueSize)
+	go a.run()
+	return a
+}
+
+// NewAsyncByConf 按配置包装 Driver
+func NewAsyncByConf(d Driver, conf config.AsyncConfig) (*Async, error) {
+	overflow, ok := OverflowMap[strings.ToLower(conf.Overflow)]
+	if !ok {
+		return nil, fmt.Errorf("invalid async log overflow policy: %s", conf.Overflow)
+	}
+	return NewAsync(d,
+		AsyncOptWithQueueSize(conf.QueueSize),
+		AsyncOptWithOverflow(overflow),
+		AsyncOptWithSampleRate(conf.SampleRate),
+		AsyncOptWithFlushTimeout(conf.FlushTimeout)), nil
+}
+
+func (a *Async) run() {
+	defer close(a.done)
+	for {
+		select {
+		case e := <-a.queue:
+			a.write(e)
+		case <-a.closing:
+			// 写完 Destroy 前已入队的日志
+			for {
+				select {
+				case e := <-a.queue:
+					a.write(e)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (a *Async) write(e asyncEntry) {
+	if err := e.driver.LogWrite(e.when, e.msg, e.level, e.extra); err != nil {
+		fmt.Fprintf(os.Stderr, "unable to WriteMsg to adapter:%v,error:%v\n", e.driver.Name(), err)
+	}
+}
+
+func (a *Async) LogWrite(when time.Time, msg string, level LogLevel, extra []Field) error {
+	return a.logWrite(a.driver, when, msg, level, extra)
+}
+
+func (a *Async) logWrite(d Driver, when time.Time, msg string, level LogLevel, extra []Field) error {
+	if level > d.CurrentLevel() {
+		return nil
+	}
+	if level <= LevelAlert {
+		return d.LogWrite(when, msg, level, extra)
+	}
+	// 调用方可能复用 extra 的底层数组, 入队前复制
+	e := asyncEntry{driver: d, when: when, msg: msg, level: level, extra: append([]Field(nil), extra...)}
+
+	select {
+	case <-a.closing:
+		atomic.AddUint64(&a.dropped, 1)
+		return nil
+	default:
+	}
+	select {
+	case a.queue <- e:
+		return nil
+	default:
+	}
+
+	switch a.overflow {
+	case OverflowDropNewest:
+		atomic.AddUint64(&a.dropped, 1)
+	case OverflowDropOldest:
+		for {
+			select {
+			case <-a.closing:
+				atomic.AddUint64(&a.dropped, 1)
+				return nil
+			case a.queue <- e:
+				return nil
+			default:
+			}
+			select {
+			case <-a.queue:
+				atomic.AddUint64(&a.dropped, 1)
+			default:
+			}
+		}
+	case OverflowSample:
+		if atomic.AddUint64(&a.sampled, 1)%a.sampleRate != 0 {
+			atomic.AddUint64(&a.dropped, 1)
+			return nil
+		}
+		a.send(e)
+	default:
+		a.send(e)
+	}
+	return nil
+}
+
+// send 阻塞入队, Destroy 时放弃并计入 Dropped
+func (a *Async) send(e asyncEntry) {
+	select {
+	case a.queue <- e:
+	case <-a.closing:
+		atomic.AddUint64(&a.dropped, 1)
+	}
+}
+
+// Dropped 因队列满或已关闭而丢弃的日志条数
+func (a *Async) Dropped() uint64 {
+	return atomic.LoadUint64(&a.dropped)
+}
+
+// Len 队列中等待写入的日志条数
+func (a *Async) Len() int {
+	return len(a.queue)
+}
+
+// Destroy 停止接收日志, 等待队列中的日志写入后关闭被包装的 Driver
+// 超过 flushTimeout 仍未写完时, 剩余的日志计入 Dropped
+func (a *Async) Destroy() {
+	timer := time.NewTimer(a.flushTimeout)
+	defer timer.Stop()
+	a.lock.Lock()
+	if a.closed {
+		a.lock.Unlock()
+		return
+	}
+	a.closed = true
+	close(a.closing)
+	a.lock.Unlock()
+
+	select {
+	case <-a.done:
+		a.driver.Destroy()
+	case <-timer.C:
+		atomic.AddUint64(&a.dropped, uint64(len(a.queue)))
+		fmt.Fprintf(os.Stderr, "async logger flush timeout, %d entries dropped\n", len(a.queue))
+	}
+}
+
+func (a *Async) CurrentLevel() LogLevel {
+	return a.driver.CurrentLevel()
+}
+
+// SetLevel 修改被包装的 Driver 的日志级别, 被包装的 Driver 不支持时忽略
+func (a *Async) SetLevel(level LogLevel) {
+	if setter, ok := a.driver.(LevelSetter); ok {
+		setter.SetLevel(level)
+	}
+}
+
+// Unwrap 返回被包装的 Driver
+func (a *Async) Unwrap() Driver {
+	return a.driver
+}
+
+// WithCallerSkip 返回与 a 共用队列的副本, 写入被包装的 Driver 跳过 skip 层后的副本
+// 被包装的 Driver 不自行计算调用位置时返回 a
+func (a *Async) WithCallerSkip(skip int) Driver {
+	s, ok := a.driver.(CallerSkipper)
+	if !ok {
+		return a
+	}
+	return &asyncCallerSkip{Async: a, driver: s.WithCallerSkip(skip)}
+}
+
+// asyncCallerSkip Async 的 WithCallerSkip 副本, Destroy, Dropped 等与原 Async 一致
+type asyncCallerSkip struct {
+	*Async
+	driver Driver
+}
+
+func (s *asyncCallerSkip) LogWrite(when time.Time, msg string, level LogLevel, extra []Field) error {
+	return s.logWrite(s.driver, when, msg, level, extra)
+}
+
+func (s *asyncCallerSkip) WithCallerSkip(skip int) Driver {
+	if d, ok := s.driver.(CallerSkipper); ok {
+		return &asyncCallerSkip{Async: s.Async, driver: d.WithCallerSkip(skip)}
+	}
+	return s
+}
+
+func (s *asyncCallerSkip) Unwrap() Driver {
+	return s.driver
+}
+
+// Name 返回被包装的 Driver 的名称, 日志格式与直接使用该 Driver 时一致
+func (a *Async) Name() string {
+	return a.driver.Name()
+}
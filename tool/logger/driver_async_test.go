@@ -0,0 +1,182 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/senyu-up/toolbox/tool/config"
+)
+
+// 记录写入内容的 driver, 写入前等待 gate 放行, 模拟较慢的 driver
+type memDriver struct {
+	sync.Mutex
+	gate      chan struct{}
+	msgs      []string
+	extras    [][]Field
+	destroyed bool
+}
+
+func (m *memDriver) LogWrite(when time.Time, msg string, level LogLevel, extra []Field) error {
+	if m.gate != nil {
+		<-m.gate
+	}
+	m.Lock()
+	m.msgs = append(m.msgs, msg)
+	m.extras = append(m.extras, extra)
+	m.Unlock()
+	return nil
+}
+
+func (m *memDriver) Destroy() {
+	m.Lock()
+	m.destroyed = true
+	m.Unlock()
+}
+
+func (m *memDriver) CurrentLevel() LogLevel { return LevelDebug }
+
+func (m *memDriver) Name() string { return "mem" }
+
+func (m *memDriver) messages() []string {
+	m.Lock()
+	defer m.Unlock()
+	return append([]string(nil), m.msgs...)
+}
+
+func TestAsync_Overflow(t *testing.T) {
+	tests := []struct {
+		name        string
+		overflow    OverflowPolicy
+		writes      int // 队列满后继续写入的条数
+		want        []string
+		wantDropped uint64
+	}{
+		{name: "block", overflow: OverflowBlock, writes: 3, want: []string{"0", "1", "2", "3", "4", "5"}},
+		{name: "drop newest", overflow: OverflowDropNewest, writes: 3, want: []string{"0", "1", "2"}, wantDropped: 3},
+		{name: "drop oldest", overflow: OverflowDropOldest, writes: 3, want: []string{"0", "4", "5"}, wantDropped: 3},
+		{name: "sample", overflow: OverflowSample, writes: 2, want: []string{"0", "1", "2", "4"}, wantDropped: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &memDriver{gate: make(chan struct{})}
+			a := NewAsync(d, AsyncOptWithQueueSize(2), AsyncOptWithOverflow(tt.overflow), AsyncOptWithSampleRate(2))
+
+			// 第一条被后台协程取出后阻塞在 driver 上, 之后两条填满队列
+			a.LogWrite(time.Now(), "0", LevelInformational, nil)
+			for a.Len() != 0 {
+				time.Sleep(time.Millisecond)
+			}
+			a.LogWrite(time.Now(), "1", LevelInformational, nil)
+			a.LogWrite(time.Now(), "2", LevelInformational, nil)
+
+			done := make(chan struct{})
+			go func() {
+				for i := 3; i < 3+tt.writes; i++ {
+					a.LogWrite(time.Now(), fmt.Sprint(i), LevelInformational, nil)
+				}
+				close(done)
+			}()
+			if tt.overflow == OverflowBlock || tt.overflow == OverflowSample {
+				// 阻塞写入的在 driver 放行后才返回
+				time.Sleep(20 * time.Millisecond)
+				close(d.gate)
+				<-done
+			} else {
+				<-done
+				close(d.gate)
+			}
+			a.Destroy()
+
+			if got := d.messages(); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("messages = %v, want %v", got, tt.want)
+			}
+			if got := a.Dropped(); got != tt.wantDropped {
+				t.Errorf("Dropped() = %d, want %d", got, tt.wantDropped)
+			}
+			if !d.destroyed {
+				t.Errorf("driver not destroyed")
+			}
+		})
+	}
+}
+
+func TestAsync_Destroy(t *testing.T) {
+	d := &memDriver{}
+	a := NewAsync(d)
+	for i := 0; i < 100; i++ {
+		a.LogWrite(time.Now(), fmt.Sprint(i), LevelInformational, nil)
+	}
+	a.Destroy()
+	if got := len(d.messages()); got != 100 {
+		t.Errorf("flushed %d messages, want 100", got)
+	}
+	// 关闭后写入的日志计入丢弃
+	a.LogWrite(time.Now(), "closed", LevelInformational, nil)
+	if a.Dropped() != 1 {
+		t.Errorf("Dropped() = %d, want 1", a.Dropped())
+	}
+	// Emer, Alert 同步写入
+	a.LogWrite(time.Now(), "alert", LevelAlert, nil)
+	if got := d.messages(); got[len(got)-1] != "alert" {
+		t.Errorf("alert not written synchronously, messages %v", got)
+	}
+
+	// driver 卡住时, Destroy 等待 flushTimeout 后返回
+	d = &memDriver{gate: make(chan struct{})}
+	a = NewAsync(d, AsyncOptWithFlushTimeout(10*time.Millisecond))
+	a.LogWrite(time.Now(), "stuck", LevelInformational, nil)
+	a.LogWrite(time.Now(), "queued", LevelInformational, nil)
+	a.Destroy()
+	close(d.gate)
+	if d.destroyed {
+		t.Errorf("stuck driver should not be destroyed")
+	}
+
+	// 队列已满且 driver 卡住时, 阻塞入队的调用方不影响 Destroy 按时返回
+	d = &memDriver{gate: make(chan struct{})}
+	defer close(d.gate)
+	a = NewAsync(d, AsyncOptWithQueueSize(1), AsyncOptWithFlushTimeout(20*time.Millisecond))
+	for i := 0; i < 3; i++ {
+		go a.LogWrite(time.Now(), "blocked", LevelInformational, nil)
+	}
+	time.Sleep(10 * time.Millisecond)
+	destroyed := make(chan struct{})
+	go func() {
+		a.Destroy()
+		close(destroyed)
+	}()
+	select {
+	case <-destroyed:
+	case <-time.After(time.Second):
+		t.Fatal("Destroy() should return after flushTimeout")
+	}
+}
+
+func TestAsync_InitByConf(t *testing.T) {
+	_, err := InitLoggerByConf(&config.LogConfig{
+		Console: &config.ConsoleConfig{Level: "DEBG"},
+		Async:   &config.AsyncConfig{Overflow: "unknown"},
+	})
+	if err == nil {
+		t.Fatalf("InitLoggerByConf() should fail with invalid overflow")
+	}
+
+	log, err := InitLoggerByConf(&config.LogConfig{
+		DefaultLog: AdapterConsole,
+		Console:    &config.ConsoleConfig{Level: "DEBG"},
+		Async:      &config.AsyncConfig{QueueSize: 10, Overflow: "drop_oldest"},
+	})
+	if err != nil {
+		t.Fatalf("InitLoggerByConf() error = %v", err)
+	}
+	log.Info("async console")
+	ad, _ := GetAdapter(AdapterConsole)
+	async, ok := ad.(*Async)
+	if !ok || async.Name() != AdapterConsole || async.overflow != OverflowDropOldest {
+		t.Fatalf("adapter = %#v", ad)
+	}
+	async.Destroy()
+	register(AdapterConsole, async.driver)
+}
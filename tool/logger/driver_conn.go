@@ -23,6 +23,7 @@ type ConnLogger struct {
 	Addr           string `json:"addr"`
 	Level          string `json:"level"`
 	LogLevel       LogLevel
+	curLevel       atomicLevel
 	illNetFlag     bool //网络异常标记
 }
 
@@ -37,6 +38,7 @@ func (c *ConnLogger) Init(jsonConfig string) error {
 	}
 	if l, ok := LevelMap[c.Level]; ok {
 		c.LogLevel = l
+		c.curLevel.store(l)
 	}
 	if c.innerWriter != nil {
 		c.innerWriter.Close()
@@ -53,6 +55,7 @@ func (c *ConnLogger) InitByConf(conf config.ConnConfig) (err error) {
 	}
 	if l, ok := LevelMap[c.Level]; ok {
 		c.LogLevel = l
+		c.curLevel.store(l)
 	} else {
 		return ErrInvalidLogLevel
 	}
@@ -64,7 +67,7 @@ func (c *ConnLogger) InitByConf(conf config.ConnConfig) (err error) {
 }
 
 func (c *ConnLogger) LogWrite(when time.Time, msg string, level LogLevel, extras []Field) (err error) {
-	if level > c.LogLevel {
+	if level > c.CurrentLevel() {
 		return nil
 	}
 	msgObj := fieldsToLogInfo(extras)
@@ -145,7 +148,12 @@ func (c *ConnLogger) needToConnectOnMsg() bool {
 }
 
 func (c *ConnLogger) CurrentLevel() LogLevel {
-	return c.LogLevel
+	return c.curLevel.load(c.LogLevel)
+}
+
+// SetLevel 运行时修改日志级别
+func (c *ConnLogger) SetLevel(level LogLevel) {
+	c.curLevel.store(level)
 }
 
 func (c *ConnLogger) println(when time.Time, msg *loginfo) error {
@@ -36,6 +36,7 @@ type Console struct {
 	Level    string `json:"level"`
 	Colorful bool   `json:"color"`
 	LogLevel LogLevel
+	curLevel atomicLevel
 }
 
 func (c *Console) InitByConf(conf config.ConsoleConfig) (err error) {
@@ -46,6 +47,7 @@ func (c *Console) InitByConf(conf config.ConsoleConfig) (err error) {
 
 	if l, ok := LevelMap[c.Level]; ok {
 		c.LogLevel = l
+		c.curLevel.store(l)
 	} else {
 		return ErrInvalidLogLevel
 	}
@@ -54,7 +56,7 @@ func (c *Console) InitByConf(conf config.ConsoleConfig) (err error) {
 }
 
 func (c *Console) LogWrite(when time.Time, msg string, level LogLevel, extra []Field) error {
-	if level > c.LogLevel {
+	if level > c.CurrentLevel() {
 		return nil
 	}
 	if c.Colorful {
@@ -68,7 +70,12 @@ func (c *Console) WithCaller() {
 }
 
 func (c *Console) CurrentLevel() LogLevel {
-	return c.LogLevel
+	return c.curLevel.load(c.LogLevel)
+}
+
+// SetLevel 运行时修改日志级别
+func (c *Console) SetLevel(level LogLevel) {
+	c.curLevel.store(level)
 }
 
 func (c *Console) Destroy() {
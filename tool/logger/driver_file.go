@@ -28,12 +28,19 @@ type File struct {
 	Level      string `json:"level"`
 	PermitMask string `json:"permit"`
 
+	Compress     bool `json:"compress"`     // 是否压缩旧日志文件
+	MaxBackups   int  `json:"maxbackups"`   // 最多保留的旧日志文件个数
+	MaxTotalSize int  `json:"maxtotalsize"` // 旧日志文件的总大小上限, 单位 MB
+
 	LogLevel             LogLevel
+	curLevel             atomicLevel
 	maxSizeCurSize       int
 	maxLinesCurLines     int
 	DailyOpenDate        int
 	DailyOpenTime        time.Time
 	fileNameOnly, suffix string
+
+	cleaner *fileCleaner // 后台压缩、清理旧日志文件
 }
 
 func (f *File) InitByConf(conf config.FileConfig) (err error) {
@@ -44,17 +51,24 @@ func (f *File) InitByConf(conf config.FileConfig) (err error) {
 	}
 	f.suffix = filepath.Ext(f.Filename)
 	f.fileNameOnly = strings.TrimSuffix(f.Filename, f.suffix)
-	f.MaxSize *= 1024 * 1024 // 将单位转换成MB
+	f.MaxSize *= 1024 * 1024      // 将单位转换成MB
+	f.MaxTotalSize *= 1024 * 1024 // 将单位转换成MB
 	if f.suffix == "" {
 		f.suffix = ".log"
 	}
 	if l, ok := LevelMap[f.Level]; ok {
 		f.LogLevel = l
+		f.curLevel.store(l)
 	} else {
 		return ErrInvalidLogLevel
 	}
 	err = f.newFile()
-	return err
+	if err != nil {
+		return err
+	}
+	// 启动时处理上次进程退出时遗留的未压缩、压缩到一半的文件
+	f.startCleaner()
+	return nil
 }
 
 func (f *File) needCreateFresh(size int, day int) bool {
@@ -65,7 +79,7 @@ func (f *File) needCreateFresh(size int, day int) bool {
 
 // WriteMsg write logger message into file.
 func (f *File) LogWrite(when time.Time, msg string, level LogLevel, extra []Field) error {
-	if level > f.LogLevel {
+	if level > f.CurrentLevel() {
 		return nil
 	}
 
@@ -191,12 +205,12 @@ func (f *File) createFreshFile(logTime time.Time) error {
 	if f.DailyOpenDate != logTime.Day() {
 		for ; err == nil && num <= 999; num++ {
 			fName = f.fileNameOnly + fmt.Sprintf(".%s.%03d%s", f.DailyOpenTime.Format("2006-01-02"), num, f.suffix)
-			_, err = os.Lstat(fName)
+			err = backupExists(fName)
 		}
 	} else { //如果仅仅是文件大小或行数达到了限制，仅仅变更后缀序号即可
 		for ; err == nil && num <= 999; num++ {
 			fName = f.fileNameOnly + fmt.Sprintf(".%s.%03d%s", logTime.Format("2006-01-02"), num, f.suffix)
-			_, err = os.Lstat(fName)
+			err = backupExists(fName)
 		}
 	}
 
@@ -216,7 +230,7 @@ func (f *File) createFreshFile(logTime time.Time) error {
 RESTART_LOGGER:
 
 	startLoggerErr := f.newFile()
-	go f.deleteOldLog()
+	f.cleaner.trigger()
 
 	if startLoggerErr != nil {
 		return fmt.Errorf("Rotate StartLogger: %s", startLoggerErr)
@@ -227,35 +241,23 @@ RESTART_LOGGER:
 	return nil
 }
 
-func (f *File) deleteOldLog() {
-	dir := filepath.Dir(f.Filename)
-	filepath.Walk(dir, func(path string, info os.FileInfo, err error) (returnErr error) {
-		defer func() {
-			if r := recover(); r != nil {
-				fmt.Fprintf(os.Stderr, "Unable to delete old log '%s', error: %v\n", path, r)
-			}
-		}()
-
-		if info == nil {
-			return
-		}
-
-		if f.MaxDays != -1 && !info.IsDir() && info.ModTime().Add(24*time.Hour*time.Duration(f.MaxDays)).Before(time.Now()) {
-			if strings.HasPrefix(filepath.Base(path), filepath.Base(f.fileNameOnly)) &&
-				strings.HasSuffix(filepath.Base(path), f.suffix) {
-				os.Remove(path)
-			}
-		}
-		return
-	})
+func (f *File) CurrentLevel() LogLevel {
+	return f.curLevel.load(f.LogLevel)
 }
 
-func (f *File) CurrentLevel() LogLevel {
-	return f.LogLevel
+// SetLevel 运行时修改日志级别
+func (f *File) SetLevel(level LogLevel) {
+	f.curLevel.store(level)
 }
 
+// Destroy 关闭日志文件, 并等待后台的压缩、清理完成
 func (f *File) Destroy() {
+	f.Lock()
+	cleaner := f.cleaner
+	f.cleaner = nil
 	f.fileWriter.Close()
+	f.Unlock()
+	cleaner.stop()
 }
 
 func (f *File) Name() string {
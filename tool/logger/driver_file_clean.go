@@ -0,0 +1,224 @@
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	compressSuffix = ".gz"
+	compressTmp    = ".gz.tmp"
+)
+
+// fileCleaner 在后台压缩切分出的旧日志文件, 并按 MaxDays, MaxBackups, MaxTotalSize 清理
+// 切分文件后调用 trigger 通知, 多次通知会合并为一次
+type fileCleaner struct {
+	dir          string
+	pattern      *regexp.Regexp // 匹配旧日志文件, 如 xx.2013-01-01.001.log, xx.2013-01-01.001.log.gz
+	compress     bool
+	maxDays      int64
+	maxBackups   int
+	maxTotalSize int64
+
+	ch chan struct{}
+	wg sync.WaitGroup
+}
+
+type backupFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+func (f *File) startCleaner() {
+	base := filepath.Base(f.fileNameOnly)
+	c := &fileCleaner{
+		dir: filepath.Dir(f.Filename),
+		pattern: regexp.MustCompile("^" + regexp.QuoteMeta(base) + `\.\d{4}-\d{2}-\d{2}\.\d{3}` +
+			regexp.QuoteMeta(f.suffix) + `(\.gz(\.tmp)?)?$`),
+		compress:     f.Compress,
+		maxDays:      f.MaxDays,
+		maxBackups:   f.MaxBackups,
+		maxTotalSize: int64(f.MaxTotalSize),
+		ch:           make(chan struct{}, 1),
+	}
+	c.wg.Add(1)
+	go c.run()
+	f.cleaner = c
+	c.trigger()
+}
+
+// backupExists 旧日志文件是否已存在, 压缩后的文件同样占用该序号
+func backupExists(name string) error {
+	if _, err := os.Lstat(name); err == nil {
+		return nil
+	}
+	_, err := os.Lstat(name + compressSuffix)
+	return err
+}
+
+func (c *fileCleaner) trigger() {
+	if c == nil {
+		return
+	}
+	select {
+	case c.ch <- struct{}{}:
+	default:
+	}
+}
+
+// stop 停止后台任务, 等待正在进行以及已通知的压缩、清理完成
+func (c *fileCleaner) stop() {
+	if c == nil {
+		return
+	}
+	close(c.ch)
+	c.wg.Wait()
+}
+
+func (c *fileCleaner) run() {
+	defer c.wg.Done()
+	for range c.ch {
+		c.clean()
+	}
+}
+
+func (c *fileCleaner) clean() {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Fprintf(os.Stderr, "Unable to clean old log in '%s', error: %v\n", c.dir, r)
+		}
+	}()
+
+	backups, err := c.backups()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to list old log in '%s', error: %v\n", c.dir, err)
+		return
+	}
+	if c.compress {
+		for i, b := range backups {
+			if strings.HasSuffix(b.path, compressSuffix) {
+				continue
+			}
+			if err = compressFile(b.path, b.path+compressSuffix); err != nil {
+				fmt.Fprintf(os.Stderr, "Unable to compress old log '%s', error: %v\n", b.path, err)
+				continue
+			}
+			if info, err := os.Stat(b.path + compressSuffix); err == nil {
+				backups[i] = backupFile{path: b.path + compressSuffix, size: info.Size(), modTime: info.ModTime()}
+			}
+		}
+	}
+	c.remove(backups)
+}
+
+// backups 返回旧日志文件, 新的在前
+// 同时处理进程崩溃遗留的文件: 压缩到一半的 .gz.tmp 直接删除, 源文件仍在, 之后重新压缩;
+// .gz 是写完后原子重命名得到的, 与源文件同时存在时说明源文件还未删除
+func (c *fileCleaner) backups() ([]backupFile, error) {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		if !e.IsDir() && c.pattern.MatchString(e.Name()) {
+			names[e.Name()] = true
+		}
+	}
+
+	var backups []backupFile
+	for name := range names {
+		path := filepath.Join(c.dir, name)
+		if strings.HasSuffix(name, compressTmp) {
+			os.Remove(path)
+			continue
+		}
+		if !strings.HasSuffix(name, compressSuffix) && names[name+compressSuffix] {
+			os.Remove(path)
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backupFile{path: path, size: info.Size(), modTime: info.ModTime()})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		if !backups[i].modTime.Equal(backups[j].modTime) {
+			return backups[i].modTime.After(backups[j].modTime)
+		}
+		// 文件名中含日期与序号, 同一时间切分的按文件名倒序
+		return backups[i].path > backups[j].path
+	})
+	return backups, nil
+}
+
+// remove 依次按 MaxDays, MaxBackups, MaxTotalSize 删除最旧的文件
+func (c *fileCleaner) remove(backups []backupFile) {
+	var total int64
+	for i, b := range backups {
+		expired := c.maxDays != -1 && b.modTime.Add(24*time.Hour*time.Duration(c.maxDays)).Before(time.Now())
+		total += b.size
+		if expired ||
+			(c.maxBackups > 0 && i >= c.maxBackups) ||
+			(c.maxTotalSize > 0 && total > c.maxTotalSize) {
+			os.Remove(b.path)
+		}
+	}
+}
+
+// compressFile 先写入 dst.tmp, 落盘后重命名为 dst, 再删除源文件, 任一步骤中断都可在下次清理时恢复
+func compressFile(src, dst string) (err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	tmp := strings.TrimSuffix(dst, compressSuffix) + compressTmp
+	out, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			out.Close()
+			os.Remove(tmp)
+		}
+	}()
+
+	gz := gzip.NewWriter(out)
+	gz.Name = filepath.Base(src)
+	gz.ModTime = info.ModTime()
+	if _, err = io.Copy(gz, in); err != nil {
+		return err
+	}
+	if err = gz.Close(); err != nil {
+		return err
+	}
+	if err = out.Sync(); err != nil {
+		return err
+	}
+	if err = out.Close(); err != nil {
+		return err
+	}
+	// 保留原文件的修改时间, MaxDays 按它计算
+	os.Chtimes(tmp, info.ModTime(), info.ModTime())
+	if err = os.Rename(tmp, dst); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
@@ -1,9 +1,17 @@
 package logger
 
 import (
+	"compress/gzip"
 	"fmt"
-	"github.com/senyu-up/toolbox/tool/config"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/senyu-up/toolbox/tool/config"
 )
 
 func TestFile_InitByConf(t *testing.T) {
@@ -41,3 +49,144 @@ func TestFile_InitByConf(t *testing.T) {
 	testStaticCalls()
 
 }
+
+func readGzip(t *testing.T, path string) string {
+	fd, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer fd.Close()
+	gz, err := gzip.NewReader(fd)
+	if err != nil {
+		t.Fatalf("gzip.NewReader() error = %v", err)
+	}
+	data, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	return string(data)
+}
+
+func TestFile_Compress(t *testing.T) {
+	dir := t.TempDir()
+	f := &File{}
+	err := f.InitByConf(config.FileConfig{
+		Filename:   filepath.Join(dir, "app.log"),
+		Level:      "DEBG",
+		Append:     true,
+		PermitMask: "0660",
+		MaxLines:   2,
+		MaxDays:    -1,
+		Compress:   true,
+		MaxBackups: 3,
+	})
+	if err != nil {
+		t.Fatalf("InitByConf() error = %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		if err = f.LogWrite(time.Now(), fmt.Sprintf("line %d", i), LevelInformational, nil); err != nil {
+			t.Fatalf("LogWrite() error = %v", err)
+		}
+	}
+	f.Destroy()
+
+	gz, _ := filepath.Glob(filepath.Join(dir, "app.*.log.gz"))
+	plain, _ := filepath.Glob(filepath.Join(dir, "app.*.log"))
+	if len(gz) != 3 || len(plain) != 0 {
+		t.Fatalf("backups gz = %v, plain = %v", gz, plain)
+	}
+	sort.Strings(gz)
+	// 共切分出 4 个备份, 最旧的 001 被删除
+	if got := readGzip(t, gz[0]); got != "line 2\nline 3\n" {
+		t.Errorf("backup %s = %q", gz[0], got)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "app.log")); string(data) != "line 8\nline 9\n" {
+		t.Errorf("active file = %q", data)
+	}
+}
+
+func TestFile_Recover(t *testing.T) {
+	dir := t.TempDir()
+	day := time.Now().Format("2006-01-02")
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0660); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+		return path
+	}
+	// 压缩到一半崩溃, 留下 .gz.tmp 与源文件
+	half := write("app."+day+".001.log", "half\n")
+	write("app."+day+".001.log.gz.tmp", "broken")
+	// 重命名 .gz 后、删除源文件前崩溃
+	done := write("app."+day+".002.log", "done\n")
+	if err := compressFile(done, done+compressSuffix); err != nil {
+		t.Fatalf("compressFile() error = %v", err)
+	}
+	write("app."+day+".002.log", "done\n")
+	other := write("other.log", "other\n")
+
+	f := &File{}
+	err := f.InitByConf(config.FileConfig{
+		Filename:   filepath.Join(dir, "app.log"),
+		Level:      "DEBG",
+		Append:     true,
+		PermitMask: "0660",
+		MaxDays:    -1,
+		Compress:   true,
+	})
+	if err != nil {
+		t.Fatalf("InitByConf() error = %v", err)
+	}
+	f.Destroy()
+
+	entries, _ := os.ReadDir(dir)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	want := []string{"app." + day + ".001.log.gz", "app." + day + ".002.log.gz", "app.log", "other.log"}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Fatalf("files = %v, want %v", names, want)
+	}
+	if got := readGzip(t, half+compressSuffix); got != "half\n" {
+		t.Errorf("recovered backup = %q", got)
+	}
+	if got := readGzip(t, done+compressSuffix); got != "done\n" {
+		t.Errorf("compressed backup = %q", got)
+	}
+	if _, err = os.Stat(other); err != nil {
+		t.Errorf("unrelated file removed: %v", err)
+	}
+}
+
+func TestFile_MaxTotalSize(t *testing.T) {
+	dir := t.TempDir()
+	f := &File{}
+	err := f.InitByConf(config.FileConfig{
+		Filename:   filepath.Join(dir, "app.log"),
+		Level:      "DEBG",
+		Append:     true,
+		PermitMask: "0660",
+		MaxLines:   1,
+		MaxDays:    -1,
+	})
+	if err != nil {
+		t.Fatalf("InitByConf() error = %v", err)
+	}
+	// 单位为 MB, 测试时直接设置转换后的字节数, 每行 10 字节, 最多保留 2 个备份
+	f.cleaner.maxTotalSize = 25
+	for i := 0; i < 6; i++ {
+		f.LogWrite(time.Now(), fmt.Sprintf("line %04d", i), LevelInformational, nil)
+	}
+	f.Destroy()
+
+	plain, _ := filepath.Glob(filepath.Join(dir, "app.*.log"))
+	sort.Strings(plain)
+	if len(plain) != 2 {
+		t.Fatalf("backups = %v", plain)
+	}
+	if data, _ := os.ReadFile(plain[1]); string(data) != "line 0004\n" {
+		t.Errorf("newest backup = %q", data)
+	}
+}
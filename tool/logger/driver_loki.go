@@ -0,0 +1,468 @@
+package logger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/senyu-up/toolbox/tool/config"
+	toolEnv "github.com/senyu-up/toolbox/tool/env"
+)
+
+const (
+	defaultLokiQueueSize    = 4096
+	defaultLokiBatchSize    = 1000
+	defaultLokiBatchWait    = time.Second
+	defaultLokiTimeout      = 5 * time.Second
+	defaultLokiMaxRetries   = 3
+	defaultLokiMinBackoff   = 500 * time.Millisecond
+	defaultLokiMaxBackoff   = 5 * time.Second
+	defaultLokiMaxSpillSize = 100 // MB
+
+	lokiSpillPrefix = "loki-"
+	lokiSpillSuffix = ".json"
+)
+
+var ErrLokiUrl = errors.New("loki url is required")
+
+type lokiEntry struct {
+	labels map[string]string
+	when   time.Time
+	line   string
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+// lokiBatch 一批待发送的日志, 按 label 分组
+type lokiBatch struct {
+	streams map[string]*lokiStream
+	size    int
+}
+
+func (b *lokiBatch) add(e lokiEntry) {
+	key := lokiStreamKey(e.labels)
+	s, ok := b.streams[key]
+	if !ok {
+		s = &lokiStream{Stream: e.labels}
+		b.streams[key] = s
+	}
+	s.Values = append(s.Values, [2]string{strconv.FormatInt(e.when.UnixNano(), 10), e.line})
+	b.size++
+}
+
+// encode 生成 /loki/api/v1/push 的请求体
+func (b *lokiBatch) encode() ([]byte, error) {
+	keys := make([]string, 0, len(b.streams))
+	for key := range b.streams {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	streams := make([]*lokiStream, 0, len(keys))
+	for _, key := range keys {
+		streams = append(streams, b.streams[key])
+	}
+	return json.Marshal(map[string][]*lokiStream{"streams": streams})
+}
+
+func lokiStreamKey(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name)
+		b.WriteByte('=')
+		b.WriteString(strconv.Quote(labels[name]))
+		b.WriteByte(',')
+	}
+	return b.String()
+}
+
+// Loki 批量推送日志到 Loki 的 /loki/api/v1/push 接口
+//   - label 由 env.AppInfo (app, stage, host), 日志级别 (level), 配置中的 Labels 与 LabelKeys 指定的 Extras 字段组成,
+//     其余 Extras 字段以 key=value 追加到日志末尾
+//   - 日志先写入队列, 由后台协程按 BatchSize, BatchWait 分批发送, 队列满时丢弃, 不阻塞业务
+//   - 发送失败时按指数退避重试, 重试仍失败时写入 SpillDir, 之后发送成功时补发
+type Loki struct {
+	dropped uint64 // 64 位原子操作的字段放在最前, 保证 32 位平台上对齐
+
+	url          string
+	tenantId     string
+	headers      map[string]string
+	labels       map[string]string
+	labelKeys    map[string]string // Extras 字段名 -> label 名
+	gzip         bool
+	batchSize    int
+	batchWait    time.Duration
+	maxRetries   int
+	minBackoff   time.Duration
+	maxBackoff   time.Duration
+	spillDir     string
+	maxSpillSize int64
+	spilled      bool // SpillDir 中是否有待补发的日志, 只在后台协程中读写
+	client       *http.Client
+
+	entries chan lokiEntry
+	lock    sync.RWMutex // 保护 closed, 避免 Destroy 后继续写入已关闭的队列
+	closed  bool
+	closing chan struct{} // Destroy 时关闭, 不再等待重试, 直接写入 SpillDir
+	done    chan struct{}
+
+	LogLevel LogLevel
+	curLevel atomicLevel
+}
+
+func (l *Loki) InitByConf(conf config.LokiConfig) (err error) {
+	if conf.Url == "" {
+		return ErrLokiUrl
+	}
+	if lv, ok := LevelMap[conf.Level]; ok {
+		l.LogLevel = lv
+		l.curLevel.store(lv)
+	} else {
+		return ErrInvalidLogLevel
+	}
+	l.url, l.tenantId, l.headers, l.gzip = conf.Url, conf.TenantId, conf.Headers, conf.Gzip
+	l.batchSize, l.batchWait, l.maxRetries = conf.BatchSize, conf.BatchWait, conf.MaxRetries
+	l.minBackoff, l.maxBackoff, l.maxSpillSize = conf.MinBackoff, conf.MaxBackoff, int64(conf.MaxSpillSize)<<20
+	if l.batchSize <= 0 {
+		l.batchSize = defaultLokiBatchSize
+	}
+	if l.batchWait <= 0 {
+		l.batchWait = defaultLokiBatchWait
+	}
+	if l.maxRetries <= 0 {
+		l.maxRetries = defaultLokiMaxRetries
+	}
+	if l.minBackoff <= 0 {
+		l.minBackoff = defaultLokiMinBackoff
+	}
+	if l.maxBackoff <= 0 {
+		l.maxBackoff = defaultLokiMaxBackoff
+	}
+	if l.maxSpillSize <= 0 {
+		l.maxSpillSize = defaultLokiMaxSpillSize << 20
+	}
+	if conf.Timeout <= 0 {
+		conf.Timeout = defaultLokiTimeout
+	}
+	if conf.QueueSize <= 0 {
+		conf.QueueSize = defaultLokiQueueSize
+	}
+	l.client = &http.Client{Timeout: conf.Timeout}
+
+	app := toolEnv.GetAppInfo()
+	l.labels = make(map[string]string)
+	for name, v := range map[string]string{"app": app.Name, "stage": app.Stage, "host": app.HostName} {
+		if v != "" {
+			l.labels[name] = v
+		}
+	}
+	for name, v := range conf.Labels {
+		if name = lokiLabelName(name); name != "" && v != "" {
+			l.labels[name] = v
+		}
+	}
+	l.labelKeys = make(map[string]string, len(conf.LabelKeys))
+	for _, key := range conf.LabelKeys {
+		if name := lokiLabelName(key); name != "" {
+			l.labelKeys[key] = name
+		}
+	}
+
+	if l.spillDir = conf.SpillDir; l.spillDir != "" {
+		if err = os.MkdirAll(l.spillDir, 0755); err != nil {
+			return err
+		}
+		files, _ := l.spillFiles()
+		l.spilled = len(files) > 0
+	}
+	l.entries = make(chan lokiEntry, conf.QueueSize)
+	l.closing = make(chan struct{})
+	l.done = make(chan struct{})
+	go l.run()
+	return nil
+}
+
+// label 名只能包含字母, 数字, 下划线, 且不能以数字开头
+func lokiLabelName(key string) string {
+	b := []byte(key)
+	for i, c := range b {
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 0 && c >= '0' && c <= '9') {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+func (l *Loki) LogWrite(when time.Time, msg string, level LogLevel, extra []Field) error {
+	if level > l.CurrentLevel() {
+		return nil
+	}
+	labels := make(map[string]string, len(l.labels)+len(l.labelKeys)+1)
+	for name, v := range l.labels {
+		labels[name] = v
+	}
+	labels["level"] = LevelName(level)
+	var line strings.Builder
+	line.WriteString(strings.TrimRight(msg, "\n"))
+	for _, field := range extra {
+		if field.Key == "" || field.Type == SkipType {
+			continue
+		}
+		v, _ := fieldText(field)
+		if name, ok := l.labelKeys[field.Key]; ok {
+			if v != "" {
+				labels[name] = v
+			}
+			continue
+		}
+		line.WriteByte(' ')
+		line.WriteString(field.Key)
+		line.WriteByte('=')
+		if v == "" || strings.ContainsAny(v, " =\"\n") {
+			v = strconv.Quote(v)
+		}
+		line.WriteString(v)
+	}
+	e := lokiEntry{labels: labels, when: when, line: line.String()}
+
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	if l.closed {
+		atomic.AddUint64(&l.dropped, 1)
+		return nil
+	}
+	select {
+	case l.entries <- e:
+	default:
+		atomic.AddUint64(&l.dropped, 1)
+	}
+	return nil
+}
+
+func (l *Loki) run() {
+	defer close(l.done)
+	var (
+		batch = &lokiBatch{streams: make(map[string]*lokiStream)}
+		timer *time.Timer
+		wait  <-chan time.Time
+	)
+	flush := func() {
+		if timer != nil {
+			timer.Stop()
+			wait = nil
+		}
+		if batch.size > 0 {
+			l.send(batch)
+			batch = &lokiBatch{streams: make(map[string]*lokiStream)}
+		}
+	}
+	for {
+		select {
+		case e, ok := <-l.entries:
+			if !ok {
+				flush()
+				return
+			}
+			if batch.size == 0 {
+				timer = time.NewTimer(l.batchWait)
+				wait = timer.C
+			}
+			batch.add(e)
+			if batch.size >= l.batchSize {
+				flush()
+			}
+		case <-wait:
+			flush()
+		}
+	}
+}
+
+func (l *Loki) send(batch *lokiBatch) {
+	payload, err := batch.encode()
+	if err == nil {
+		var retry bool
+		if retry, err = l.push(payload); err == nil {
+			l.replay()
+			return
+		} else if retry && l.spillDir != "" {
+			if err = l.spill(payload); err == nil {
+				return
+			}
+		}
+	}
+	atomic.AddUint64(&l.dropped, uint64(batch.size))
+	fmt.Fprintf(os.Stderr, "loki push failed, %d entries dropped, error:%v\n", batch.size, err)
+}
+
+// push 发送一批日志, 网络错误, 429 与 5xx 时按指数退避重试, retry 表示失败后是否值得稍后补发
+func (l *Loki) push(payload []byte) (retry bool, err error) {
+	backoff := l.minBackoff
+	for i := 0; ; i++ {
+		if retry, err = l.post(payload); err == nil || !retry || i >= l.maxRetries {
+			return retry, err
+		}
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-l.closing:
+			timer.Stop()
+			return retry, err
+		}
+		if backoff *= 2; backoff > l.maxBackoff {
+			backoff = l.maxBackoff
+		}
+	}
+}
+
+func (l *Loki) post(payload []byte) (retry bool, err error) {
+	body := payload
+	if l.gzip {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write(payload)
+		zw.Close()
+		body = buf.Bytes()
+	}
+	req, err := http.NewRequest(http.MethodPost, l.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if l.gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	if l.tenantId != "" {
+		req.Header.Set("X-Scope-OrgID", l.tenantId)
+	}
+	for k, v := range l.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 == 2 {
+		io.Copy(io.Discard, resp.Body)
+		return false, nil
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	err = fmt.Errorf("loki push status %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500, err
+}
+
+// spill 写入 SpillDir, 先写临时文件再改名, 避免补发时读到不完整的文件
+func (l *Loki) spill(payload []byte) error {
+	name := filepath.Join(l.spillDir, fmt.Sprintf("%s%d%s", lokiSpillPrefix, time.Now().UnixNano(), lokiSpillSuffix))
+	if err := os.WriteFile(name+".tmp", payload, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(name+".tmp", name); err != nil {
+		os.Remove(name + ".tmp")
+		return err
+	}
+	l.spilled = true
+	l.trimSpill()
+	return nil
+}
+
+// spillFiles SpillDir 中待补发的文件, 按写入时间排序
+func (l *Loki) spillFiles() ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(l.spillDir, lokiSpillPrefix+"*"+lokiSpillSuffix))
+	sort.Strings(files)
+	return files, err
+}
+
+// trimSpill 总大小超过 MaxSpillSize 时删除最旧的文件
+func (l *Loki) trimSpill() {
+	files, _ := l.spillFiles()
+	sizes := make([]int64, len(files))
+	var total int64
+	for i, file := range files {
+		if info, err := os.Stat(file); err == nil {
+			sizes[i] = info.Size()
+			total += sizes[i]
+		}
+	}
+	for i := 0; i < len(files)-1 && total > l.maxSpillSize; i++ {
+		if err := os.Remove(files[i]); err == nil {
+			total -= sizes[i]
+			fmt.Fprintf(os.Stderr, "loki spill dir exceeds %d bytes, %s removed\n", l.maxSpillSize, files[i])
+		}
+	}
+}
+
+// replay 补发 SpillDir 中的日志, 失败时保留文件, 下次发送成功后再试
+func (l *Loki) replay() {
+	if !l.spilled {
+		return
+	}
+	files, err := l.spillFiles()
+	if err != nil {
+		return
+	}
+	for _, file := range files {
+		payload, err := os.ReadFile(file)
+		if err != nil {
+			return
+		}
+		if retry, err := l.post(payload); err != nil && retry {
+			return
+		} else if err != nil {
+			fmt.Fprintf(os.Stderr, "loki replay %s failed, error:%v\n", file, err)
+		}
+		os.Remove(file)
+	}
+	l.spilled = false
+}
+
+// Dropped 因队列满, 发送失败而丢弃的日志条数
+func (l *Loki) Dropped() uint64 {
+	return atomic.LoadUint64(&l.dropped)
+}
+
+func (l *Loki) CurrentLevel() LogLevel {
+	return l.curLevel.load(l.LogLevel)
+}
+
+// SetLevel 运行时修改日志级别
+func (l *Loki) SetLevel(level LogLevel) {
+	l.curLevel.store(level)
+}
+
+// Destroy 停止接收日志, 发送队列中剩余的日志, 此时发送失败不再重试, 直接写入 SpillDir
+func (l *Loki) Destroy() {
+	l.lock.Lock()
+	if l.closed || l.entries == nil {
+		l.lock.Unlock()
+		return
+	}
+	l.closed = true
+	close(l.closing)
+	close(l.entries)
+	l.lock.Unlock()
+	<-l.done
+}
+
+func (l *Loki) Name() string {
+	return AdapterLoki
+}
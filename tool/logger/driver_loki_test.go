@@ -0,0 +1,261 @@
+package logger
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/senyu-up/toolbox/tool/config"
+)
+
+// lokiServer 记录收到的日志, status 为返回的状态码, 为 0 时返回 204
+type lokiServer struct {
+	*httptest.Server
+	lock     sync.Mutex
+	status   []int
+	requests int
+	headers  http.Header
+	streams  []lokiStream
+}
+
+func newLokiServer(t *testing.T, status ...int) *lokiServer {
+	s := &lokiServer{status: status}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body io.Reader = r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			zr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				t.Errorf("gzip.NewReader() error = %v", err)
+				return
+			}
+			body = zr
+		}
+		var push struct {
+			Streams []lokiStream `json:"streams"`
+		}
+		if err := json.NewDecoder(body).Decode(&push); err != nil {
+			t.Errorf("decode push body error = %v", err)
+		}
+
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		s.requests++
+		s.headers = r.Header
+		code := http.StatusNoContent
+		if len(s.status) > 0 {
+			code, s.status = s.status[0], s.status[1:]
+		}
+		if code/100 == 2 {
+			s.streams = append(s.streams, push.Streams...)
+		}
+		w.WriteHeader(code)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *lokiServer) lines() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var lines []string
+	for _, stream := range s.streams {
+		for _, v := range stream.Values {
+			lines = append(lines, v[1])
+		}
+	}
+	return lines
+}
+
+func (s *lokiServer) wait(t *testing.T, n int) []string {
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if lines := s.lines(); len(lines) >= n {
+			return lines
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("lines = %v, want %d lines", s.lines(), n)
+	return nil
+}
+
+func TestLoki_Batch(t *testing.T) {
+	s := newLokiServer(t)
+	l := &Loki{}
+	err := l.InitByConf(config.LokiConfig{Url: s.URL, Level: "INFO", TenantId: "t1", Gzip: true, BatchSize: 3, BatchWait: time.Hour,
+		Labels: map[string]string{"team-name": "infra"}, LabelKeys: []string{"trace_id"}})
+	if err != nil {
+		t.Fatalf("InitByConf() error = %v", err)
+	}
+	defer l.Destroy()
+
+	now := time.Now()
+	l.LogWrite(now, "debug", LevelDebug, nil)
+	l.LogWrite(now, "first\n", LevelInformational, E().String("trace_id", "a").Int("uid", 7).String("q", "x y").Fields())
+	l.LogWrite(now, "second", LevelInformational, E().String("trace_id", "b").Fields())
+	l.LogWrite(now, "third", LevelError, E().String("trace_id", "a").Fields())
+	lines := s.wait(t, 3)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.requests != 1 || s.headers.Get("X-Scope-OrgID") != "t1" || s.headers.Get("Content-Type") != "application/json" {
+		t.Errorf("requests = %d, headers = %v", s.requests, s.headers)
+	}
+	// 按 label 分为 3 个 stream
+	if len(s.streams) != 3 || len(lines) != 3 {
+		t.Fatalf("streams = %+v", s.streams)
+	}
+	want := map[string]string{`first uid=7 q="x y"`: "a/INFO", "second": "b/INFO", "third": "a/EROR"}
+	for _, stream := range s.streams {
+		labels := stream.Stream
+		if labels["app"] == "" || labels["stage"] == "" || labels["team_name"] != "infra" {
+			t.Errorf("labels = %v", labels)
+		}
+		line := stream.Values[0][1]
+		if got := labels["trace_id"] + "/" + labels["level"]; got != want[line] {
+			t.Errorf("line %q labels = %s, want %s", line, got, want[line])
+		}
+		if ts := stream.Values[0][0]; ts == "" || ts[0] == '0' {
+			t.Errorf("timestamp = %s", ts)
+		}
+	}
+}
+
+func TestLoki_BatchWait(t *testing.T) {
+	s := newLokiServer(t)
+	l := &Loki{}
+	if err := l.InitByConf(config.LokiConfig{Url: s.URL, Level: "INFO", BatchWait: 20 * time.Millisecond}); err != nil {
+		t.Fatalf("InitByConf() error = %v", err)
+	}
+	defer l.Destroy()
+	l.LogWrite(time.Now(), "hello", LevelInformational, nil)
+	s.wait(t, 1)
+}
+
+func TestLoki_Logger(t *testing.T) {
+	s := newLokiServer(t)
+	l := &Loki{}
+	if err := l.InitByConf(config.LokiConfig{Url: s.URL, Level: "INFO", BatchWait: 20 * time.Millisecond}); err != nil {
+		t.Fatalf("InitByConf() error = %v", err)
+	}
+	defer l.Destroy()
+
+	// 时间与级别由时间戳、level 标签表示, 经 Logger 写入时 msg 不拼接前缀, 调用位置作为 caller 字段
+	NewLocalLogger("loki", l, LogOptWithCallDepth(DefaultCallDepth)).Info("hello %d", 1)
+	line := s.wait(t, 1)[0]
+	if !strings.HasPrefix(line, "hello 1 ") || strings.Contains(line, "[INFO]") {
+		t.Errorf("line = %q, should not have a time or level prefix", line)
+	}
+	if !strings.Contains(line, " caller=tool/logger/driver_loki_test.go:") {
+		t.Errorf("line = %q, should have the caller", line)
+	}
+}
+
+func TestLoki_Retry(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   []int
+		requests int
+		lines    int
+		dropped  uint64
+	}{
+		{name: "5xx", status: []int{500, 429, 503}, requests: 4, lines: 1},
+		{name: "exceeds max retries", status: []int{500, 500, 500, 500}, requests: 4, dropped: 1},
+		{name: "4xx", status: []int{400}, requests: 1, dropped: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newLokiServer(t, tt.status...)
+			l := &Loki{}
+			if err := l.InitByConf(config.LokiConfig{Url: s.URL, Level: "INFO", BatchWait: time.Millisecond,
+				MinBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond}); err != nil {
+				t.Fatalf("InitByConf() error = %v", err)
+			}
+			l.LogWrite(time.Now(), "hello", LevelInformational, nil)
+			time.Sleep(50 * time.Millisecond)
+			l.Destroy()
+			if s.requests != tt.requests || len(s.lines()) != tt.lines || l.Dropped() != tt.dropped {
+				t.Errorf("requests = %d, lines = %d, dropped = %d", s.requests, len(s.lines()), l.Dropped())
+			}
+		})
+	}
+}
+
+func TestLoki_Spill(t *testing.T) {
+	dir := t.TempDir()
+	// 第一批重试仍失败, 写入 SpillDir
+	s := newLokiServer(t, 503, 503)
+	l := &Loki{}
+	conf := config.LokiConfig{Url: s.URL, Level: "INFO", BatchWait: time.Millisecond, MaxRetries: 1,
+		MinBackoff: time.Millisecond, SpillDir: dir}
+	if err := l.InitByConf(conf); err != nil {
+		t.Fatalf("InitByConf() error = %v", err)
+	}
+	l.LogWrite(time.Now(), "spilled", LevelInformational, nil)
+	deadline := time.Now().Add(3 * time.Second)
+	for files, _ := l.spillFiles(); len(files) == 0; files, _ = l.spillFiles() {
+		if time.Now().After(deadline) {
+			t.Fatalf("spill file not found")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// 恢复后, 下一批发送成功时补发
+	l.LogWrite(time.Now(), "recovered", LevelInformational, nil)
+	lines := s.wait(t, 2)
+	if lines[0] != "recovered" || lines[1] != "spilled" {
+		t.Errorf("lines = %v", lines)
+	}
+	if files, _ := l.spillFiles(); len(files) != 0 {
+		t.Errorf("spill files = %v, should be removed after replay", files)
+	}
+	l.Destroy()
+
+	// Destroy 时发送失败不再重试, 直接写入 SpillDir, 重启后补发
+	down := newLokiServer(t, 500)
+	l = &Loki{}
+	conf.Url, conf.BatchWait, conf.MinBackoff = down.URL, time.Hour, time.Hour
+	if err := l.InitByConf(conf); err != nil {
+		t.Fatalf("InitByConf() error = %v", err)
+	}
+	l.LogWrite(time.Now(), "on destroy", LevelInformational, nil)
+	l.Destroy()
+	if files, _ := filepath.Glob(filepath.Join(dir, "*")); len(files) != 1 || down.requests != 1 {
+		t.Fatalf("spill files = %v, requests = %d", files, down.requests)
+	}
+	l = &Loki{}
+	conf.Url, conf.BatchWait = s.URL, time.Millisecond
+	if err := l.InitByConf(conf); err != nil {
+		t.Fatalf("InitByConf() error = %v", err)
+	}
+	defer l.Destroy()
+	l.LogWrite(time.Now(), "restarted", LevelInformational, nil)
+	if lines = s.wait(t, 4); lines[3] != "on destroy" {
+		t.Errorf("lines = %v", lines)
+	}
+}
+
+func TestLoki_TrimSpill(t *testing.T) {
+	dir := t.TempDir()
+	l := &Loki{spillDir: dir, maxSpillSize: 10}
+	for _, payload := range []string{"123456", "abcdef", "xy"} {
+		if err := l.spill([]byte(payload)); err != nil {
+			t.Fatalf("spill() error = %v", err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	files, _ := l.spillFiles()
+	if len(files) != 2 {
+		t.Fatalf("spill files = %v", files)
+	}
+	if b, _ := os.ReadFile(files[0]); string(b) != "abcdef" {
+		t.Errorf("oldest file should be removed, got %s", b)
+	}
+}
@@ -0,0 +1,255 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jinzhu/copier"
+	"github.com/senyu-up/toolbox/tool/config"
+	toolEnv "github.com/senyu-up/toolbox/tool/env"
+)
+
+const (
+	defaultSyslogFacility = 16 // local0
+	defaultSyslogSdId     = "extras@32473"
+	defaultSyslogTimeout  = 5 * time.Second
+	defaultSyslogRetry    = time.Second
+)
+
+// 日志级别与 syslog severity 的映射, Notice(5) 不使用, Trace 与 Debug 同为 7
+var syslogSeverity = [LevelTrace + 1]int{0, 1, 2, 3, 4, 6, 7, 7}
+
+// SyslogFacilityMap 配置中的 facility 与 syslog facility 映射关系
+var SyslogFacilityMap = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5, "lpr": 6, "news": 7,
+	"uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19, "local4": 20, "local5": 21, "local6": 22, "local7": 23,
+	"": defaultSyslogFacility,
+}
+
+var ErrSyslogNet = errors.New("syslog net must be one of udp, tcp, unix, unixgram")
+
+// Syslog 按 RFC 5424 格式发送日志, Extras 作为 structured data
+// tcp, unix 使用 octet counting 分帧, udp, unixgram 每条日志一个数据报
+// 连接断开时在下一条日志重连, 重连失败后 Retry 时间内的日志直接丢弃, 避免阻塞业务
+type Syslog struct {
+	sync.Mutex
+	conn     net.Conn
+	retryAt  time.Time // 上次连接失败后, 下次允许重连的时间
+	stream   bool      // 是否为流式连接, 需要分帧
+	fac      int
+	host     string
+	app      string
+	procId   string
+	Net      string        `json:"net"`
+	Addr     string        `json:"addr"`
+	Level    string        `json:"level"`
+	Facility string        `json:"facility"`
+	AppName  string        `json:"appname"`
+	Hostname string        `json:"hostname"`
+	SdId     string        `json:"sdid"`
+	Timeout  time.Duration `json:"timeout"`
+	Retry    time.Duration `json:"retry"`
+	LogLevel LogLevel
+	curLevel atomicLevel
+}
+
+func (s *Syslog) InitByConf(conf config.SyslogConfig) (err error) {
+	copier.CopyWithOption(s, conf, copier.Option{IgnoreEmpty: true})
+	switch s.Net {
+	case "udp", "udp4", "udp6", "unixgram":
+		s.stream = false
+	case "tcp", "tcp4", "tcp6", "unix":
+		s.stream = true
+	default:
+		return ErrSyslogNet
+	}
+	if l, ok := LevelMap[s.Level]; ok {
+		s.LogLevel = l
+		s.curLevel.store(l)
+	} else {
+		return ErrInvalidLogLevel
+	}
+	if s.fac, err = syslogFacility(s.Facility); err != nil {
+		return err
+	}
+	app := toolEnv.GetAppInfo()
+	s.host = syslogHeader(s.Hostname, app.HostName, 255)
+	s.app = syslogHeader(s.AppName, app.Name, 48)
+	s.procId = strconv.Itoa(os.Getpid())
+	if s.SdId == "" {
+		s.SdId = defaultSyslogSdId
+	}
+	if s.Timeout <= 0 {
+		s.Timeout = defaultSyslogTimeout
+	}
+	if s.Retry <= 0 {
+		s.Retry = defaultSyslogRetry
+	}
+	s.close()
+	s.retryAt = time.Time{}
+	return nil
+}
+
+func syslogFacility(name string) (int, error) {
+	if f, ok := SyslogFacilityMap[strings.ToLower(name)]; ok {
+		return f, nil
+	}
+	if f, err := strconv.Atoi(name); err == nil && f >= 0 && f <= 23 {
+		return f, nil
+	}
+	return 0, fmt.Errorf("invalid syslog facility: %s", name)
+}
+
+// header 字段只能是可打印的 ASCII, 为空时用 "-"
+func syslogHeader(v, def string, max int) string {
+	if v == "" {
+		v = def
+	}
+	b := make([]byte, 0, len(v))
+	for i := 0; i < len(v) && len(b) < max; i++ {
+		if v[i] > 32 && v[i] < 127 {
+			b = append(b, v[i])
+		}
+	}
+	if len(b) == 0 {
+		return "-"
+	}
+	return string(b)
+}
+
+func (s *Syslog) LogWrite(when time.Time, msg string, level LogLevel, extra []Field) error {
+	if level > s.CurrentLevel() {
+		return nil
+	}
+	frame := s.format(when, msg, level, extra)
+
+	s.Lock()
+	defer s.Unlock()
+	err := s.write(frame)
+	if err != nil && s.conn != nil {
+		// 连接已断开, 重连后重试一次
+		s.close()
+		err = s.write(frame)
+	}
+	return err
+}
+
+func (s *Syslog) write(frame []byte) error {
+	if s.conn == nil {
+		if time.Now().Before(s.retryAt) {
+			return fmt.Errorf("syslog %s %s unavailable, drop log until %s", s.Net, s.Addr, s.retryAt.Format(time.RFC3339))
+		}
+		conn, err := net.DialTimeout(s.Net, s.Addr, s.Timeout)
+		if err != nil {
+			s.retryAt = time.Now().Add(s.Retry)
+			return err
+		}
+		s.conn = conn
+	}
+	if s.stream {
+		frame = append([]byte(strconv.Itoa(len(frame))+" "), frame...)
+	}
+	s.conn.SetWriteDeadline(time.Now().Add(s.Timeout))
+	_, err := s.conn.Write(frame)
+	return err
+}
+
+// format 生成 RFC 5424 格式的日志
+// <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID [SD-ID key="value"...] MSG
+func (s *Syslog) format(when time.Time, msg string, level LogLevel, extra []Field) []byte {
+	var b strings.Builder
+	b.WriteByte('<')
+	b.WriteString(strconv.Itoa(s.fac*8 + syslogSeverity[level]))
+	b.WriteString(">1 ")
+	b.WriteString(when.Format("2006-01-02T15:04:05.000000Z07:00"))
+	b.WriteByte(' ')
+	b.WriteString(s.host)
+	b.WriteByte(' ')
+	b.WriteString(s.app)
+	b.WriteByte(' ')
+	b.WriteString(s.procId)
+	b.WriteString(" - ")
+	s.structuredData(&b, extra)
+	if msg != "" {
+		b.WriteByte(' ')
+		b.WriteString(strings.TrimRight(msg, "\n"))
+	}
+	return []byte(b.String())
+}
+
+func (s *Syslog) structuredData(b *strings.Builder, extra []Field) {
+	n := 0
+	for _, field := range extra {
+		name := syslogParamName(field.Key)
+		if name == "" || field.Type == SkipType {
+			continue
+		}
+		if n == 0 {
+			b.WriteByte('[')
+			b.WriteString(s.SdId)
+		}
+		n++
+		v, _ := fieldText(field)
+		b.WriteByte(' ')
+		b.WriteString(name)
+		b.WriteString(`="`)
+		// 值中的 " \ ] 需要转义
+		for _, r := range v {
+			if r == '"' || r == '\\' || r == ']' {
+				b.WriteByte('\\')
+			}
+			b.WriteRune(r)
+		}
+		b.WriteByte('"')
+	}
+	if n == 0 {
+		b.WriteByte('-')
+		return
+	}
+	b.WriteByte(']')
+}
+
+// PARAM-NAME 最长 32 个可打印 ASCII, 不能包含 = 空格 ] "
+func syslogParamName(key string) string {
+	b := make([]byte, 0, len(key))
+	for i := 0; i < len(key) && len(b) < 32; i++ {
+		c := key[i]
+		if c > 32 && c < 127 && c != '=' && c != ']' && c != '"' {
+			b = append(b, c)
+		}
+	}
+	return string(b)
+}
+
+func (s *Syslog) close() {
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+}
+
+func (s *Syslog) CurrentLevel() LogLevel {
+	return s.curLevel.load(s.LogLevel)
+}
+
+// SetLevel 运行时修改日志级别
+func (s *Syslog) SetLevel(level LogLevel) {
+	s.curLevel.store(level)
+}
+
+func (s *Syslog) Destroy() {
+	s.Lock()
+	defer s.Unlock()
+	s.close()
+}
+
+func (s *Syslog) Name() string {
+	return AdapterSyslog
+}
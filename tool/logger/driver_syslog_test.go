@@ -0,0 +1,207 @@
+package logger
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/senyu-up/toolbox/tool/config"
+)
+
+// 按 octet counting 读取一帧
+func readFrame(r *bufio.Reader) (string, error) {
+	size, err := r.ReadString(' ')
+	if err != nil {
+		return "", err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(size))
+	if err != nil {
+		return "", err
+	}
+	buf := make([]byte, n)
+	_, err = io.ReadFull(r, buf)
+	return string(buf), err
+}
+
+func TestSyslog_Format(t *testing.T) {
+	s := &Syslog{}
+	err := s.InitByConf(config.SyslogConfig{Net: "udp", Addr: "127.0.0.1:514", Level: "TRAC",
+		Facility: "local3", AppName: "demo app", Hostname: "host-1"})
+	if err != nil {
+		t.Fatalf("InitByConf() error = %v", err)
+	}
+	when := time.Date(2024, 1, 2, 3, 4, 5, 6000, time.UTC)
+	pid := strconv.Itoa(os.Getpid())
+	tests := []struct {
+		level LogLevel
+		msg   string
+		extra []Field
+		want  string
+	}{
+		{level: LevelError, msg: "boom\n", want: "<155>1 2024-01-02T03:04:05.000006Z host-1 demoapp " + pid + " - - boom"},
+		{level: LevelTrace, msg: "", want: "<159>1 2024-01-02T03:04:05.000006Z host-1 demoapp " + pid + " - -"},
+		{level: LevelInformational, msg: "hi", extra: E().String("trace", "t1").Int("uid", 7).String("q", `a"b]c\`).String("k=v x", "1").Fields(),
+			want: "<158>1 2024-01-02T03:04:05.000006Z host-1 demoapp " + pid + ` - [extras@32473 trace="t1" uid="7" q="a\"b\]c\\" kvx="1"] hi`},
+	}
+	for _, tt := range tests {
+		if got := string(s.format(when, tt.msg, tt.level, tt.extra)); got != tt.want {
+			t.Errorf("format() = %s, want %s", got, tt.want)
+		}
+	}
+
+	for _, conf := range []config.SyslogConfig{{Net: "http"}, {Net: "udp", Level: "DEBUG"}, {Net: "udp", Facility: "local9"}} {
+		if err = (&Syslog{}).InitByConf(conf); err == nil {
+			t.Errorf("InitByConf(%+v) should fail", conf)
+		}
+	}
+}
+
+func TestSyslog_UDP(t *testing.T) {
+	for _, network := range []string{"udp", "unixgram"} {
+		t.Run(network, func(t *testing.T) {
+			addr := "127.0.0.1:0"
+			if network == "unixgram" {
+				addr = filepath.Join(t.TempDir(), "log.sock")
+			}
+			pc, err := net.ListenPacket(network, addr)
+			if err != nil {
+				t.Fatalf("ListenPacket() error = %v", err)
+			}
+			defer pc.Close()
+
+			s := &Syslog{}
+			if err = s.InitByConf(config.SyslogConfig{Net: network, Addr: pc.LocalAddr().String(), Level: "INFO"}); err != nil {
+				t.Fatalf("InitByConf() error = %v", err)
+			}
+			defer s.Destroy()
+			s.LogWrite(time.Now(), "debug", LevelDebug, nil)
+			for i := 0; i < 2; i++ {
+				if err = s.LogWrite(time.Now(), fmt.Sprint("msg ", i), LevelInformational, nil); err != nil {
+					t.Fatalf("LogWrite() error = %v", err)
+				}
+			}
+			buf := make([]byte, 1024)
+			for i := 0; i < 2; i++ {
+				pc.SetReadDeadline(time.Now().Add(time.Second))
+				n, _, err := pc.ReadFrom(buf)
+				if err != nil {
+					t.Fatalf("ReadFrom() error = %v", err)
+				}
+				if got := string(buf[:n]); !strings.HasPrefix(got, "<134>1 ") || !strings.HasSuffix(got, fmt.Sprint(" - - msg ", i)) {
+					t.Errorf("datagram = %q", got)
+				}
+			}
+
+			// 报文头已带有时间和级别, 经 Logger 写入时 msg 不拼接前缀
+			NewLocalLogger("syslog", s, LogOptWithCallDepth(DefaultCallDepth)).Info("hello %d", 1)
+			pc.SetReadDeadline(time.Now().Add(time.Second))
+			n, _, err := pc.ReadFrom(buf)
+			if err != nil {
+				t.Fatalf("ReadFrom() error = %v", err)
+			}
+			if got := string(buf[:n]); !strings.HasSuffix(got, "] hello 1") || strings.Contains(got, "[INFO]") {
+				t.Errorf("datagram = %q, should not have a text prefix", got)
+			} else if !strings.Contains(got, ` caller="tool/logger/driver_syslog_test.go:`) {
+				t.Errorf("datagram = %q, should have the caller as a SD-PARAM", got)
+			}
+		})
+	}
+}
+
+func TestSyslog_TCPReconnect(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	defer ln.Close()
+	frames := make(chan string, 100)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					frame, err := readFrame(r)
+					if err != nil {
+						return
+					}
+					frames <- frame
+					// 收到 close 后断开连接, 模拟 sidecar 重启
+					if strings.HasSuffix(frame, " close") {
+						return
+					}
+				}
+			}(conn)
+		}
+	}()
+
+	s := &Syslog{}
+	if err = s.InitByConf(config.SyslogConfig{Net: "tcp", Addr: ln.Addr().String(), Level: "INFO", Retry: 10 * time.Millisecond}); err != nil {
+		t.Fatalf("InitByConf() error = %v", err)
+	}
+	defer s.Destroy()
+	s.LogWrite(time.Now(), "multi\nline", LevelInformational, E().String("k", "v").Fields())
+	s.LogWrite(time.Now(), "close", LevelInformational, nil)
+	for _, want := range []string{`[extras@32473 k="v"] multi` + "\nline", " - - close"} {
+		select {
+		case got := <-frames:
+			if !strings.HasSuffix(got, want) {
+				t.Errorf("frame = %q, want suffix %q", got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("frame %q not received", want)
+		}
+	}
+
+	// 对端关闭后, 写入失败时重连, 之后的日志通过新连接送达
+	deadline := time.After(3 * time.Second)
+	for i := 0; ; i++ {
+		s.LogWrite(time.Now(), fmt.Sprint("after ", i), LevelInformational, nil)
+		select {
+		case got := <-frames:
+			if !strings.Contains(got, " - - after ") {
+				t.Fatalf("frame = %q", got)
+			}
+			return
+		case <-deadline:
+			t.Fatalf("not reconnected")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestSyslog_Unavailable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	s := &Syslog{}
+	if err = s.InitByConf(config.SyslogConfig{Net: "tcp", Addr: addr, Level: "INFO", Retry: time.Hour}); err != nil {
+		t.Fatalf("InitByConf() error = %v", err)
+	}
+	if err = s.LogWrite(time.Now(), "first", LevelInformational, nil); err == nil {
+		t.Fatalf("LogWrite() should fail when syslog is down")
+	}
+	// Retry 时间内不再重连, 直接丢弃
+	start := time.Now()
+	if err = s.LogWrite(time.Now(), "second", LevelInformational, nil); err == nil || !strings.Contains(err.Error(), "unavailable") {
+		t.Errorf("LogWrite() error = %v", err)
+	}
+	if time.Since(start) > 100*time.Millisecond {
+		t.Errorf("LogWrite() should not block while syslog is unavailable")
+	}
+}
@@ -73,14 +73,17 @@ func (z *Zap) getWriteSyncer() (zapcore.WriteSyncer, error) {
 
 type Zap struct {
 	zapInst  *zap.Logger
-	Level    string   `json:"level"`
-	Colorful bool     `json:"color"`
-	LogLevel LogLevel `json:"log_level"`
+	Level    string       `json:"level"`
+	Colorful bool         `json:"color"`
+	LogLevel LogLevel     `json:"log_level"`
+	curLevel *atomicLevel // 与 WithCallerSkip 的副本共用
 	//Writer io.Writer
 	// std(默认) or 具体的文件路径
 	Output string `json:"output"`
 	// 调用栈往上走的层数
 	CallerSkip int `json:"caller_skip"`
+
+	atomicLevel zap.AtomicLevel // zap core 的级别, 运行时修改日志级别时同步修改
 }
 
 func (z *Zap) InitByConf(conf config.ZapConfig) (err error) {
@@ -102,6 +105,10 @@ func (z *Zap) InitByConf(conf config.ZapConfig) (err error) {
 			z.LogLevel = LevelDebug
 		}
 	}
+	if z.curLevel == nil {
+		z.curLevel = &atomicLevel{}
+	}
+	z.curLevel.store(z.LogLevel)
 
 	encoder := z.getEncoder()
 	writer, err := z.getWriteSyncer()
@@ -109,25 +116,20 @@ func (z *Zap) InitByConf(conf config.ZapConfig) (err error) {
 		return err
 	}
 
-	var lv zapcore.Level
-	if z.LogLevel == LevelInformational {
-		lv = zapcore.InfoLevel
-	} else {
-		lv = zapcore.DebugLevel
-	}
+	z.atomicLevel = zap.NewAtomicLevelAt(zapLevel(z.LogLevel))
 
 	var skip = 2
 	if z.CallerSkip > 0 {
 		skip = z.CallerSkip
 	}
-	core := zapcore.NewCore(encoder, writer, lv)
+	core := zapcore.NewCore(encoder, writer, z.atomicLevel)
 	z.zapInst = zap.New(core, zap.AddCaller(), zap.AddCallerSkip(skip))
 
 	return err
 }
 
 func (z *Zap) LogWrite(when time.Time, msg string, level LogLevel, extras []Field) error {
-	if level > z.LogLevel {
+	if level > z.CurrentLevel() {
 		return nil
 	}
 	var msgData = fieldsToZapFields(extras)
@@ -151,6 +153,15 @@ func (z *Zap) LogWrite(when time.Time, msg string, level LogLevel, extras []Fiel
 	return nil
 }
 
+// WithCallerSkip 返回调用位置多跳过 skip 层的副本, 与原 driver 共用 core 与日志级别
+func (z *Zap) WithCallerSkip(skip int) Driver {
+	c := *z
+	if z.zapInst != nil {
+		c.zapInst = z.zapInst.WithOptions(zap.AddCallerSkip(skip))
+	}
+	return &c
+}
+
 func (z *Zap) Destroy() {
 	z.zapInst = nil
 }
@@ -160,7 +171,25 @@ func (z *Zap) Name() string {
 }
 
 func (z *Zap) CurrentLevel() LogLevel {
-	return z.LogLevel
+	if z.curLevel == nil {
+		return z.LogLevel
+	}
+	return z.curLevel.load(z.LogLevel)
+}
+
+// SetLevel 运行时修改日志级别
+func (z *Zap) SetLevel(level LogLevel) {
+	if z.zapInst != nil {
+		z.curLevel.store(level)
+		z.atomicLevel.SetLevel(zapLevel(level))
+	}
+}
+
+func zapLevel(level LogLevel) zapcore.Level {
+	if level == LevelInformational {
+		return zapcore.InfoLevel
+	}
+	return zapcore.DebugLevel
 }
 
 func toShortCaller(line string) string {
@@ -0,0 +1,160 @@
+package logger
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var ErrLevelUnsupported = errors.New("the logger driver does not support changing level")
+
+// LevelSetter 支持运行时修改日志级别的 Driver
+type LevelSetter interface {
+	SetLevel(level LogLevel)
+}
+
+// atomicLevel driver 当前的日志级别, SetLevel 与写日志并发读写;
+// 没有设置过时使用 driver 的 LogLevel 字段, 兼容直接构造 &Console{LogLevel: ...} 的写法
+type atomicLevel struct {
+	v atomic.Int32 // 级别+1, 0 表示没有设置过
+}
+
+func (a *atomicLevel) load(base LogLevel) LogLevel {
+	if v := a.v.Load(); v > 0 {
+		return LogLevel(v - 1)
+	}
+	return base
+}
+
+func (a *atomicLevel) store(level LogLevel) {
+	a.v.Store(int32(level) + 1)
+}
+
+// LevelInfo driver 当前的日志级别
+type LevelInfo struct {
+	Level    string     `json:"level"`
+	Base     string     `json:"base,omitempty"`      // 临时调整前的级别, 到期后恢复
+	ExpireAt *time.Time `json:"expire_at,omitempty"` // 临时调整的到期时间
+}
+
+// 临时调整的日志级别
+type levelElevation struct {
+	driver   Driver
+	base     LogLevel
+	expireAt time.Time
+	timer    *time.Timer
+}
+
+var (
+	elevations    = map[string]*levelElevation{}
+	elevationLock = sync.Mutex{}
+)
+
+// LevelName 日志级别的名称, 如 INFO, DEBG
+func LevelName(level LogLevel) string {
+	if level < LevelEmergency || level > LevelTrace {
+		return ""
+	}
+	return levelPrefix[level]
+}
+
+// Levels 返回已注册的 driver 当前的日志级别, key 为 driver 名称
+func Levels() map[string]LevelInfo {
+	adapterRwLock.RLock()
+	drivers := make(map[string]Driver, len(adapters))
+	for name, d := range adapters {
+		drivers[name] = d
+	}
+	adapterRwLock.RUnlock()
+
+	elevationLock.Lock()
+	defer elevationLock.Unlock()
+	res := make(map[string]LevelInfo, len(drivers))
+	for name, d := range drivers {
+		info := LevelInfo{Level: LevelName(d.CurrentLevel())}
+		if e, ok := elevations[name]; ok && e.driver == d {
+			expireAt := e.expireAt
+			info.Base, info.ExpireAt = LevelName(e.base), &expireAt
+		}
+		res[name] = info
+	}
+	return res
+}
+
+// SetLevel 运行时修改 driver 的日志级别, adapter 为空时修改全部支持的 driver
+// ttl 大于 0 时为临时调整, 到期后恢复为调整前的级别; 临时调整期间再次修改, 恢复的仍是最初的级别
+//
+//	// 生产环境临时打开 5 分钟 debug 日志
+//	logger.SetLevel(logger.AdapterZap, logger.LevelDebug, 5*time.Minute)
+func SetLevel(adapter string, level LogLevel, ttl time.Duration) error {
+	if level < LevelEmergency || level > LevelTrace {
+		return ErrInvalidLogLevel
+	}
+	if adapter != "" {
+		d, err := GetAdapter(adapter)
+		if err != nil {
+			return err
+		}
+		return setLevel(adapter, d, level, ttl)
+	}
+
+	adapterRwLock.RLock()
+	drivers := make(map[string]Driver, len(adapters))
+	for name, d := range adapters {
+		if levelSetter(d) != nil {
+			drivers[name] = d
+		}
+	}
+	adapterRwLock.RUnlock()
+	for name, d := range drivers {
+		if err := setLevel(name, d, level, ttl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setLevel(name string, d Driver, level LogLevel, ttl time.Duration) error {
+	setter := levelSetter(d)
+	if setter == nil {
+		return ErrLevelUnsupported
+	}
+	elevationLock.Lock()
+	defer elevationLock.Unlock()
+
+	base := d.CurrentLevel()
+	if e, ok := elevations[name]; ok {
+		e.timer.Stop()
+		delete(elevations, name)
+		if e.driver == d {
+			base = e.base
+		}
+	}
+	setter.SetLevel(level)
+	if ttl <= 0 {
+		return nil
+	}
+
+	e := &levelElevation{driver: d, base: base, expireAt: time.Now().Add(ttl)}
+	e.timer = time.AfterFunc(ttl, func() {
+		elevationLock.Lock()
+		defer elevationLock.Unlock()
+		if elevations[name] != e {
+			return
+		}
+		delete(elevations, name)
+		setter.SetLevel(e.base)
+	})
+	elevations[name] = e
+	return nil
+}
+
+// 包装类的 driver, 如 Async, 按被包装的 driver 判断是否支持
+func levelSetter(d Driver) LevelSetter {
+	if w, ok := d.(interface{ Unwrap() Driver }); ok && levelSetter(w.Unwrap()) == nil {
+		return nil
+	}
+	setter, _ := d.(LevelSetter)
+	return setter
+}
@@ -0,0 +1,96 @@
+package logger
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/senyu-up/toolbox/tool/config"
+)
+
+func TestSetLevel(t *testing.T) {
+	adapterRwLock.Lock()
+	saved := adapters
+	adapters = map[string]Driver{}
+	adapterRwLock.Unlock()
+	defer func() {
+		adapterRwLock.Lock()
+		adapters = saved
+		adapterRwLock.Unlock()
+	}()
+
+	zapLogger := &Zap{}
+	if err := zapLogger.InitByConf(config.ZapConfig{Level: "INFO"}); err != nil {
+		t.Fatalf("InitByConf() error = %v", err)
+	}
+	register(AdapterConsole, &Console{LogLevel: LevelInformational})
+	register(AdapterZap, zapLogger)
+	register("mem", &memDriver{})
+
+	if err := SetLevel("unknown", LevelDebug, 0); !errors.Is(err, ErrAdapterNotFound) {
+		t.Errorf("SetLevel(unknown) error = %v", err)
+	}
+	if err := SetLevel("mem", LevelDebug, 0); !errors.Is(err, ErrLevelUnsupported) {
+		t.Errorf("SetLevel(mem) error = %v", err)
+	}
+	if err := SetLevel(AdapterConsole, LogLevel(10), 0); !errors.Is(err, ErrInvalidLogLevel) {
+		t.Errorf("SetLevel(10) error = %v", err)
+	}
+
+	// 永久修改
+	if err := SetLevel(AdapterConsole, LevelWarning, 0); err != nil {
+		t.Fatalf("SetLevel() error = %v", err)
+	}
+	if info := Levels()[AdapterConsole]; info.Level != "WARN" || info.ExpireAt != nil {
+		t.Errorf("Levels() console = %+v", info)
+	}
+
+	// 临时调整全部 driver, 期间再次调整, 到期后恢复为最初的级别
+	if err := SetLevel("", LevelDebug, time.Hour); err != nil {
+		t.Fatalf("SetLevel() error = %v", err)
+	}
+	if err := SetLevel("", LevelTrace, 50*time.Millisecond); err != nil {
+		t.Fatalf("SetLevel() error = %v", err)
+	}
+	info := Levels()[AdapterZap]
+	if info.Level != "TRAC" || info.Base != "INFO" || info.ExpireAt == nil {
+		t.Errorf("Levels() zap = %+v", info)
+	}
+	if !zapLogger.atomicLevel.Enabled(-1) {
+		t.Errorf("zap core should enable debug level")
+	}
+	time.Sleep(100 * time.Millisecond)
+	if got := Levels(); got[AdapterConsole].Level != "WARN" || got[AdapterZap].Level != "INFO" || got[AdapterZap].ExpireAt != nil {
+		t.Errorf("Levels() after ttl = %+v", got)
+	}
+	if zapLogger.atomicLevel.Enabled(-1) {
+		t.Errorf("zap core should disable debug level after ttl")
+	}
+}
+
+func TestSetLevel_Concurrent(t *testing.T) {
+	c := &Console{LogLevel: LevelInformational}
+	s := NewSlog(slog.NewTextHandler(io.Discard, nil), LevelInformational)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				c.LogWrite(time.Now(), "msg", LevelDebug, nil)
+				s.LogWrite(time.Now(), "msg", LevelDebug, nil)
+			}
+		}()
+	}
+	for _, level := range []LogLevel{LevelWarning, LevelError, LevelCritical} {
+		c.SetLevel(level)
+		s.SetLevel(level)
+	}
+	wg.Wait()
+	if c.CurrentLevel() != LevelCritical || s.CurrentLevel() != LevelCritical {
+		t.Errorf("CurrentLevel() = %d, %d, want %d", c.CurrentLevel(), s.CurrentLevel(), LevelCritical)
+	}
+}
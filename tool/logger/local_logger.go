@@ -52,54 +52,108 @@ func (l *LocalLogger) SetErr(e error) Log {
 
 func (l *LocalLogger) Panic(format string, args ...interface{}) {
 	//l.bl.doLog(LevelEmergency, format, args...)
-	doLog(l.bl.ctx, l.bl.adapter, l.bl.callBack, l.bl.usePath, l.bl.spanId, l.bl.traceId, l.bl.timeFormat, format,
+	doLog(l.bl.ctx, l.bl.adapter, l.bl.callBack, l.bl.sampler, l.bl.redactor, l.bl.usePath, l.bl.spanId, l.bl.traceId, l.bl.timeFormat, format,
 		l.bl.ShowCallerLevel, LevelEmergency, l.bl.callDepth, l.bl.notify, l.bl.err, l.bl.extra.fields, args...)
 }
 
 func (l *LocalLogger) Emer(format string, args ...interface{}) {
 	//l.bl.doLog(LevelEmergency, format, args...)
-	doLog(l.bl.ctx, l.bl.adapter, l.bl.callBack, l.bl.usePath, l.bl.spanId, l.bl.traceId, l.bl.timeFormat, format,
+	doLog(l.bl.ctx, l.bl.adapter, l.bl.callBack, l.bl.sampler, l.bl.redactor, l.bl.usePath, l.bl.spanId, l.bl.traceId, l.bl.timeFormat, format,
 		l.bl.ShowCallerLevel, LevelEmergency, l.bl.callDepth, l.bl.notify, l.bl.err, l.bl.extra.fields, args...)
 }
 
 func (l *LocalLogger) Alert(format string, args ...interface{}) {
 	//l.bl.doLog(LevelAlert, format, args...)
-	doLog(l.bl.ctx, l.bl.adapter, l.bl.callBack, l.bl.usePath, l.bl.spanId, l.bl.traceId, l.bl.timeFormat, format,
+	doLog(l.bl.ctx, l.bl.adapter, l.bl.callBack, l.bl.sampler, l.bl.redactor, l.bl.usePath, l.bl.spanId, l.bl.traceId, l.bl.timeFormat, format,
 		l.bl.ShowCallerLevel, LevelAlert, l.bl.callDepth, l.bl.notify, l.bl.err, l.bl.extra.fields, args...)
 }
 
 func (l *LocalLogger) Crit(format string, args ...interface{}) {
 	//l.bl.doLog(LevelCritical, format, args...)
-	doLog(l.bl.ctx, l.bl.adapter, l.bl.callBack, l.bl.usePath, l.bl.spanId, l.bl.traceId, l.bl.timeFormat, format,
+	doLog(l.bl.ctx, l.bl.adapter, l.bl.callBack, l.bl.sampler, l.bl.redactor, l.bl.usePath, l.bl.spanId, l.bl.traceId, l.bl.timeFormat, format,
 		l.bl.ShowCallerLevel, LevelCritical, l.bl.callDepth, l.bl.notify, l.bl.err, l.bl.extra.fields, args...)
 }
 
 func (l *LocalLogger) Error(format string, args ...interface{}) {
 	//l.bl.doLog(LevelError, format, args...)
-	doLog(l.bl.ctx, l.bl.adapter, l.bl.callBack, l.bl.usePath, l.bl.spanId, l.bl.traceId, l.bl.timeFormat, format,
+	doLog(l.bl.ctx, l.bl.adapter, l.bl.callBack, l.bl.sampler, l.bl.redactor, l.bl.usePath, l.bl.spanId, l.bl.traceId, l.bl.timeFormat, format,
 		l.bl.ShowCallerLevel, LevelError, l.bl.callDepth, l.bl.notify, l.bl.err, l.bl.extra.fields, args...)
 }
 
 func (l *LocalLogger) Warn(format string, args ...interface{}) {
 	//l.bl.doLog(LevelWarning, format, args...)
-	doLog(l.bl.ctx, l.bl.adapter, l.bl.callBack, l.bl.usePath, l.bl.spanId, l.bl.traceId, l.bl.timeFormat, format,
+	doLog(l.bl.ctx, l.bl.adapter, l.bl.callBack, l.bl.sampler, l.bl.redactor, l.bl.usePath, l.bl.spanId, l.bl.traceId, l.bl.timeFormat, format,
 		l.bl.ShowCallerLevel, LevelWarning, l.bl.callDepth, l.bl.notify, l.bl.err, l.bl.extra.fields, args...)
 }
 
 func (l *LocalLogger) Info(format string, args ...interface{}) {
 	//l.bl.doLog(LevelInformational, format, args...)
-	doLog(l.bl.ctx, l.bl.adapter, l.bl.callBack, l.bl.usePath, l.bl.spanId, l.bl.traceId, l.bl.timeFormat, format,
+	doLog(l.bl.ctx, l.bl.adapter, l.bl.callBack, l.bl.sampler, l.bl.redactor, l.bl.usePath, l.bl.spanId, l.bl.traceId, l.bl.timeFormat, format,
 		l.bl.ShowCallerLevel, LevelInformational, l.bl.callDepth, l.bl.notify, l.bl.err, l.bl.extra.fields, args...)
 }
 
 func (l *LocalLogger) Debug(format string, args ...interface{}) {
 	//l.bl.doLog(LevelDebug, format, args...)
-	doLog(l.bl.ctx, l.bl.adapter, l.bl.callBack, l.bl.usePath, l.bl.spanId, l.bl.traceId, l.bl.timeFormat, format,
+	doLog(l.bl.ctx, l.bl.adapter, l.bl.callBack, l.bl.sampler, l.bl.redactor, l.bl.usePath, l.bl.spanId, l.bl.traceId, l.bl.timeFormat, format,
 		l.bl.ShowCallerLevel, LevelDebug, l.bl.callDepth, l.bl.notify, l.bl.err, l.bl.extra.fields, args...)
 }
 
 func (l *LocalLogger) Trace(format string, args ...interface{}) {
 	//l.bl.doLog(LevelTrace, format, args...)
-	doLog(l.bl.ctx, l.bl.adapter, l.bl.callBack, l.bl.usePath, l.bl.spanId, l.bl.traceId, l.bl.timeFormat, format,
+	doLog(l.bl.ctx, l.bl.adapter, l.bl.callBack, l.bl.sampler, l.bl.redactor, l.bl.usePath, l.bl.spanId, l.bl.traceId, l.bl.timeFormat, format,
 		l.bl.ShowCallerLevel, LevelTrace, l.bl.callDepth, l.bl.notify, l.bl.err, l.bl.extra.fields, args...)
 }
+
+// With 追加结构化字段, kv 同 Extras.KV
+func (l *LocalLogger) With(kv ...interface{}) Log {
+	return l.bl.Clone().With(kv...)
+}
+
+// Logw 结构化日志, 从 ctx 解析 traceId, spanId, msg 不做格式化, kv 同 Extras.KV
+func (l *LocalLogger) Logw(ctx context.Context, level LogLevel, msg string, kv ...interface{}) {
+	if level < LevelEmergency || level > LevelTrace {
+		level = LevelError
+	}
+	traceId, spanId := ctxTrace(ctx, l.bl.traceId, l.bl.spanId)
+	if ctx == nil {
+		ctx = l.bl.ctx
+	}
+	doLog(ctx, l.bl.adapter, l.bl.callBack, l.bl.sampler, l.bl.redactor, l.bl.usePath, spanId, traceId, l.bl.timeFormat, msg,
+		l.bl.ShowCallerLevel, level, l.bl.callDepth, l.bl.notify, l.bl.err, kvFields(l.bl.extra.fields, kv))
+}
+
+func (l *LocalLogger) Errorw(ctx context.Context, msg string, kv ...interface{}) {
+	traceId, spanId := ctxTrace(ctx, l.bl.traceId, l.bl.spanId)
+	if ctx == nil {
+		ctx = l.bl.ctx
+	}
+	doLog(ctx, l.bl.adapter, l.bl.callBack, l.bl.sampler, l.bl.redactor, l.bl.usePath, spanId, traceId, l.bl.timeFormat, msg,
+		l.bl.ShowCallerLevel, LevelError, l.bl.callDepth, l.bl.notify, l.bl.err, kvFields(l.bl.extra.fields, kv))
+}
+
+func (l *LocalLogger) Warnw(ctx context.Context, msg string, kv ...interface{}) {
+	traceId, spanId := ctxTrace(ctx, l.bl.traceId, l.bl.spanId)
+	if ctx == nil {
+		ctx = l.bl.ctx
+	}
+	doLog(ctx, l.bl.adapter, l.bl.callBack, l.bl.sampler, l.bl.redactor, l.bl.usePath, spanId, traceId, l.bl.timeFormat, msg,
+		l.bl.ShowCallerLevel, LevelWarning, l.bl.callDepth, l.bl.notify, l.bl.err, kvFields(l.bl.extra.fields, kv))
+}
+
+func (l *LocalLogger) Infow(ctx context.Context, msg string, kv ...interface{}) {
+	traceId, spanId := ctxTrace(ctx, l.bl.traceId, l.bl.spanId)
+	if ctx == nil {
+		ctx = l.bl.ctx
+	}
+	doLog(ctx, l.bl.adapter, l.bl.callBack, l.bl.sampler, l.bl.redactor, l.bl.usePath, spanId, traceId, l.bl.timeFormat, msg,
+		l.bl.ShowCallerLevel, LevelInformational, l.bl.callDepth, l.bl.notify, l.bl.err, kvFields(l.bl.extra.fields, kv))
+}
+
+func (l *LocalLogger) Debugw(ctx context.Context, msg string, kv ...interface{}) {
+	traceId, spanId := ctxTrace(ctx, l.bl.traceId, l.bl.spanId)
+	if ctx == nil {
+		ctx = l.bl.ctx
+	}
+	doLog(ctx, l.bl.adapter, l.bl.callBack, l.bl.sampler, l.bl.redactor, l.bl.usePath, spanId, traceId, l.bl.timeFormat, msg,
+		l.bl.ShowCallerLevel, LevelDebug, l.bl.callDepth, l.bl.notify, l.bl.err, kvFields(l.bl.extra.fields, kv))
+}
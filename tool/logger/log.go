@@ -63,6 +63,9 @@ const (
 	AdapterFile          = "file"                // 文件输出配置项a
 	AdapterConn          = "conn"
 	AdapterZap           = "zap"
+	AdapterSyslog        = "syslog"
+	AdapterLoki          = "loki"
+	AdapterSlog          = "slog"
 )
 
 var appSn = os.Getenv("APPSN")
@@ -113,6 +116,22 @@ type Log interface {
 	Debug(format string, args ...interface{})
 
 	Trace(format string, args ...interface{})
+
+	// With 追加结构化字段, kv 为交替的 key, value, 也可以是 Field, []Field, *Extras
+	With(kv ...interface{}) Log
+
+	// 结构化日志, 从 ctx 解析 traceId, spanId, msg 不做格式化, kv 同 With
+	//
+	//	logger.Notify().Errorw(ctx, "pay failed", "order_id", id, "error", err)
+	Logw(ctx context.Context, level LogLevel, msg string, kv ...interface{})
+
+	Errorw(ctx context.Context, msg string, kv ...interface{})
+
+	Warnw(ctx context.Context, msg string, kv ...interface{})
+
+	Infow(ctx context.Context, msg string, kv ...interface{})
+
+	Debugw(ctx context.Context, msg string, kv ...interface{})
 }
 
 type loginfo struct {
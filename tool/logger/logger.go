@@ -17,6 +17,8 @@ type baseLogger struct {
 	callBackName string
 	adapter      Driver
 	callBack     Driver
+	sampler      *sampler  // 日志采样、去重, 为 nil 时不采样
+	redactor     *Redactor // 日志脱敏, 为 nil 时不脱敏
 
 	ctx     context.Context
 	extra   *Extras
@@ -62,6 +64,8 @@ func (b *baseLogger) Clone() Log {
 		usePath:         b.usePath,
 		ShowCallerLevel: b.ShowCallerLevel,
 		callBack:        b.callBack,
+		sampler:         b.sampler,
+		redactor:        b.redactor,
 		extra:           NewExtras(),
 	}
 }
@@ -183,24 +187,9 @@ func writeMsg(ctx context.Context, adapter Driver, usePath, timeFormat string, w
 	if reqId := trace.GetRequestId(ctx); 0 < len(reqId) {
 		msg = reqId + ": " + msg
 	}
-	var (
-		src        = ""
-		file       = ""
-		lineno int = 0
-		ok         = false
-		strim      = "src/"
-	)
+	var src = ""
 	if logLevel <= showCallerLevel {
-		_, file, lineno, ok = runtime.Caller(callDepth)
-		if ok {
-			file = toShortCaller(file)
-		}
-	}
-	if usePath != "" {
-		strim = usePath
-	}
-	if ok {
-		src = strings.Replace(fmt.Sprintf("%s:%d", stringTrim(file, strim), lineno), "%2e", ".", -1)
+		src = callerSource(callDepth+1, usePath)
 	}
 
 	msgStr := when.Format(timeFormat) + " [" + levelPrefix[logLevel] + "] " + "[" + src + "] " + msg
@@ -212,6 +201,20 @@ func writeMsg(ctx context.Context, adapter Driver, usePath, timeFormat string, w
 	return nil
 }
 
+// callerSource 调用位置, skip 从 callerSource 自身算起, 由 doLog 调用时与 writeMsg 的 callDepth 一致,
+// 路径去掉 usePath(默认 src/) 之前的部分
+func callerSource(skip int, usePath string) string {
+	_, file, lineno, ok := runtime.Caller(skip)
+	if !ok {
+		return ""
+	}
+	strim := "src/"
+	if usePath != "" {
+		strim = usePath
+	}
+	return strings.Replace(fmt.Sprintf("%s:%d", stringTrim(toShortCaller(file), strim), lineno), "%2e", ".", -1)
+}
+
 func (b *baseLogger) doLog(logLevel LogLevel, format string, args ...interface{}) {
 	//ad, err := GetAdapter(b.adapterName)
 	//if err != nil {
@@ -225,7 +228,10 @@ func (b *baseLogger) doLog(logLevel LogLevel, format string, args ...interface{}
 	var msg = formatLog(format, args...)
 	var t = time.Now()
 	extra.Int64("ms", t.UnixNano()/1e6)
-	if b.adapterName == AdapterZap {
+	if rawMsg(b.adapter) {
+		if logLevel <= b.ShowCallerLevel && callerField(b.adapter) {
+			extra.String(CallerKey, callerSource(b.callDepth, b.usePath))
+		}
 		_ = b.adapter.LogWrite(t, msg, logLevel, extra.fields)
 	} else {
 		//b.writeMsg(t, msg, logLevel, extra.fields)
@@ -249,16 +255,46 @@ func (b *baseLogger) doLog(logLevel LogLevel, format string, args ...interface{}
 	b.exLock.Unlock()
 }
 
-func doLog(ctx context.Context, adapter, callBack Driver, usePath, spanId, traceId, timeFormat, format string,
+// rawMsg zap, slog 自行输出时间、级别、调用位置, syslog 的报文头、loki 的时间戳与 level 标签已带有时间和级别,
+// msg 不拼接前缀
+func rawMsg(adapter Driver) bool {
+	switch adapter.Name() {
+	case AdapterZap, AdapterSlog, AdapterSyslog, AdapterLoki:
+		return true
+	}
+	return false
+}
+
+// CallerKey 调用位置的字段名
+const CallerKey = "caller"
+
+// callerField syslog, loki 不自行记录调用位置, 以 CallerKey 字段传入
+func callerField(adapter Driver) bool {
+	name := adapter.Name()
+	return name == AdapterSyslog || name == AdapterLoki
+}
+
+func doLog(ctx context.Context, adapter, callBack Driver, sampler *sampler, redactor *Redactor, usePath, spanId, traceId, timeFormat, format string,
 	showCallerLevel, logLevel LogLevel, callDepth int, notify bool, err error, f []Field, args ...interface{}) {
 	if logLevel > adapter.CurrentLevel() {
 		return
 	}
+	// Emer, Alert 不采样, zap driver 在这两个级别会退出进程或 panic
+	if sampler != nil && logLevel > LevelAlert && !sampler.allow(adapter, redactor, timeFormat, usePath, logLevel, format, callDepth) {
+		return
+	}
 	var extra = setExtraField(f, err, spanId, traceId)
 	var msg = formatLog(format, args...)
+	if redactor != nil {
+		msg = redactor.Message(msg)
+		extra.fields = redactor.Fields(extra.fields)
+	}
 	var t = time.Now()
 	extra.Int64("ms", t.UnixNano()/1e6)
-	if adapter.Name() == AdapterZap {
+	if rawMsg(adapter) {
+		if logLevel <= showCallerLevel && callerField(adapter) {
+			extra.String(CallerKey, callerSource(callDepth, usePath))
+		}
 		_ = adapter.LogWrite(t, msg, logLevel, extra.fields)
 	} else {
 		writeMsg(ctx, adapter, usePath, timeFormat, t, msg, showCallerLevel, logLevel, callDepth, extra.fields)
@@ -278,58 +314,118 @@ func doLog(ctx context.Context, adapter, callBack Driver, usePath, spanId, trace
 //	@param args  body any true "-"
 func (b *baseLogger) Panic(format string, args ...interface{}) {
 	//b.doLog(LevelEmergency, format, args...)
-	doLog(b.ctx, b.adapter, b.callBack, b.usePath, b.spanId, b.traceId, b.timeFormat, format,
+	doLog(b.ctx, b.adapter, b.callBack, b.sampler, b.redactor, b.usePath, b.spanId, b.traceId, b.timeFormat, format,
 		b.ShowCallerLevel, LevelEmergency, b.callDepth, b.notify, b.err, b.extra.fields, args...)
 }
 
 func (b *baseLogger) Emer(format string, args ...interface{}) {
 	//b.doLog(LevelEmergency, format, args...)
-	doLog(b.ctx, b.adapter, b.callBack, b.usePath, b.spanId, b.traceId, b.timeFormat, format,
+	doLog(b.ctx, b.adapter, b.callBack, b.sampler, b.redactor, b.usePath, b.spanId, b.traceId, b.timeFormat, format,
 		b.ShowCallerLevel, LevelEmergency, b.callDepth, b.notify, b.err, b.extra.fields, args...)
 }
 
 func (b *baseLogger) Alert(format string, args ...interface{}) {
 	//b.doLog(LevelAlert, format, args...)
-	doLog(b.ctx, b.adapter, b.callBack, b.usePath, b.spanId, b.traceId, b.timeFormat, format,
+	doLog(b.ctx, b.adapter, b.callBack, b.sampler, b.redactor, b.usePath, b.spanId, b.traceId, b.timeFormat, format,
 		b.ShowCallerLevel, LevelAlert, b.callDepth, b.notify, b.err, b.extra.fields, args...)
 }
 
 func (b *baseLogger) Crit(format string, args ...interface{}) {
 	//b.doLog(LevelCritical, format, args...)
-	doLog(b.ctx, b.adapter, b.callBack, b.usePath, b.spanId, b.traceId, b.timeFormat, format,
+	doLog(b.ctx, b.adapter, b.callBack, b.sampler, b.redactor, b.usePath, b.spanId, b.traceId, b.timeFormat, format,
 		b.ShowCallerLevel, LevelCritical, b.callDepth, b.notify, b.err, b.extra.fields, args...)
 }
 
 func (b *baseLogger) Error(format string, args ...interface{}) {
 	//b.doLog(LevelError, format, args...)
-	doLog(b.ctx, b.adapter, b.callBack, b.usePath, b.spanId, b.traceId, b.timeFormat, format,
+	doLog(b.ctx, b.adapter, b.callBack, b.sampler, b.redactor, b.usePath, b.spanId, b.traceId, b.timeFormat, format,
 		b.ShowCallerLevel, LevelError, b.callDepth, b.notify, b.err, b.extra.fields, args...)
 }
 
 func (b *baseLogger) Warn(format string, args ...interface{}) {
 	//b.doLog(LevelWarning, format, args...)
-	doLog(b.ctx, b.adapter, b.callBack, b.usePath, b.spanId, b.traceId, b.timeFormat, format,
+	doLog(b.ctx, b.adapter, b.callBack, b.sampler, b.redactor, b.usePath, b.spanId, b.traceId, b.timeFormat, format,
 		b.ShowCallerLevel, LevelWarning, b.callDepth, b.notify, b.err, b.extra.fields, args...)
 }
 
 func (b *baseLogger) Info(format string, args ...interface{}) {
 	//b.doLog(LevelInformational, format, args...)
-	doLog(b.ctx, b.adapter, b.callBack, b.usePath, b.spanId, b.traceId, b.timeFormat, format,
+	doLog(b.ctx, b.adapter, b.callBack, b.sampler, b.redactor, b.usePath, b.spanId, b.traceId, b.timeFormat, format,
 		b.ShowCallerLevel, LevelInformational, b.callDepth, b.notify, b.err, b.extra.fields, args...)
 }
 
 func (b *baseLogger) Debug(format string, args ...interface{}) {
 	//b.doLog(LevelDebug, format, args...)
-	doLog(b.ctx, b.adapter, b.callBack, b.usePath, b.spanId, b.traceId, b.timeFormat, format,
+	doLog(b.ctx, b.adapter, b.callBack, b.sampler, b.redactor, b.usePath, b.spanId, b.traceId, b.timeFormat, format,
 		b.ShowCallerLevel, LevelDebug, b.callDepth, b.notify, b.err, b.extra.fields, args...)
 }
 
 func (b *baseLogger) Trace(format string, args ...interface{}) {
 	//b.doLog(LevelTrace, format, args...)
-	doLog(b.ctx, b.adapter, b.callBack, b.usePath, b.spanId, b.traceId, b.timeFormat, format,
+	doLog(b.ctx, b.adapter, b.callBack, b.sampler, b.redactor, b.usePath, b.spanId, b.traceId, b.timeFormat, format,
 		b.ShowCallerLevel, LevelTrace, b.callDepth, b.notify, b.err, b.extra.fields, args...)
 }
 
+// With 追加结构化字段, kv 同 Extras.KV
+func (b *baseLogger) With(kv ...interface{}) Log {
+	b.exLock.Lock()
+	defer b.exLock.Unlock()
+	if b.extra == nil {
+		b.extra = NewExtras()
+	}
+	b.extra.KV(kv...)
+	return b
+}
+
+// Logw 结构化日志, 从 ctx 解析 traceId, spanId, msg 不做格式化, kv 同 Extras.KV
+func (b *baseLogger) Logw(ctx context.Context, level LogLevel, msg string, kv ...interface{}) {
+	if level < LevelEmergency || level > LevelTrace {
+		level = LevelError
+	}
+	traceId, spanId := ctxTrace(ctx, b.traceId, b.spanId)
+	if ctx == nil {
+		ctx = b.ctx
+	}
+	doLog(ctx, b.adapter, b.callBack, b.sampler, b.redactor, b.usePath, spanId, traceId, b.timeFormat, msg,
+		b.ShowCallerLevel, level, b.callDepth, b.notify, b.err, kvFields(b.extra.fields, kv))
+}
+
+func (b *baseLogger) Errorw(ctx context.Context, msg string, kv ...interface{}) {
+	traceId, spanId := ctxTrace(ctx, b.traceId, b.spanId)
+	if ctx == nil {
+		ctx = b.ctx
+	}
+	doLog(ctx, b.adapter, b.callBack, b.sampler, b.redactor, b.usePath, spanId, traceId, b.timeFormat, msg,
+		b.ShowCallerLevel, LevelError, b.callDepth, b.notify, b.err, kvFields(b.extra.fields, kv))
+}
+
+func (b *baseLogger) Warnw(ctx context.Context, msg string, kv ...interface{}) {
+	traceId, spanId := ctxTrace(ctx, b.traceId, b.spanId)
+	if ctx == nil {
+		ctx = b.ctx
+	}
+	doLog(ctx, b.adapter, b.callBack, b.sampler, b.redactor, b.usePath, spanId, traceId, b.timeFormat, msg,
+		b.ShowCallerLevel, LevelWarning, b.callDepth, b.notify, b.err, kvFields(b.extra.fields, kv))
+}
+
+func (b *baseLogger) Infow(ctx context.Context, msg string, kv ...interface{}) {
+	traceId, spanId := ctxTrace(ctx, b.traceId, b.spanId)
+	if ctx == nil {
+		ctx = b.ctx
+	}
+	doLog(ctx, b.adapter, b.callBack, b.sampler, b.redactor, b.usePath, spanId, traceId, b.timeFormat, msg,
+		b.ShowCallerLevel, LevelInformational, b.callDepth, b.notify, b.err, kvFields(b.extra.fields, kv))
+}
+
+func (b *baseLogger) Debugw(ctx context.Context, msg string, kv ...interface{}) {
+	traceId, spanId := ctxTrace(ctx, b.traceId, b.spanId)
+	if ctx == nil {
+		ctx = b.ctx
+	}
+	doLog(ctx, b.adapter, b.callBack, b.sampler, b.redactor, b.usePath, spanId, traceId, b.timeFormat, msg,
+		b.ShowCallerLevel, LevelDebug, b.callDepth, b.notify, b.err, kvFields(b.extra.fields, kv))
+}
+
 func (b *baseLogger) assembleField(extra *Extras) (e *Extras) {
 	b.exLock.Lock()
 	e = E()
@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"context"
 	"errors"
 	"github.com/senyu-up/toolbox/tool/config"
 	"github.com/senyu-up/toolbox/tool/trace"
@@ -130,3 +131,35 @@ func BenchmarkBaseLogger_Error_Best(b *testing.B) {
 	}
 	b.StopTimer()
 }
+
+type discardDriver struct{}
+
+func (discardDriver) LogWrite(when time.Time, msg string, level LogLevel, extra []Field) error {
+	return nil
+}
+
+func (discardDriver) Destroy() {}
+
+func (discardDriver) CurrentLevel() LogLevel { return LevelDebug }
+
+func (discardDriver) Name() string { return "discard" }
+
+// 结构化日志与 printf 风格 + SetExtra 的对比
+func BenchmarkStructured(b *testing.B) {
+	var l = NewLocalLogger("discard", discardDriver{}, LogOptWithCallDepth(DefaultCallDepth))
+	var err = errors.New("This is err ")
+	var ctx = trace.NewContextWithRequestIdAndSpanId(context.TODO(), "req1", "span1")
+
+	b.Run("Errorw", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			l.SetErr(err).Errorw(ctx, "Got Err", "name", "xh", "age", 18)
+		}
+	})
+	b.Run("Error", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			l.Ctx(ctx).SetErr(err).SetExtra(E().String("name", "xh").Int("age", 18)).Error("Got Err")
+		}
+	})
+}
@@ -1,5 +1,7 @@
 package logger
 
+import "time"
+
 type LogOption func(*baseLogger)
 
 func LogOptWithAppName(ap string) LogOption {
@@ -40,6 +42,26 @@ func LogOptWithCallBack(cb Driver) LogOption {
 	}
 }
 
+// LogOptWithSampling 日志采样、去重, 相同级别、format、调用位置的日志每 interval 内前 first 条正常输出,
+// 之后每 thereafter 条输出一条, thereafter 为 0 时全部抑制, 被抑制的条数定期输出一条汇总
+// first 为 0 时不采样; Emer, Alert 级别的日志总是输出
+func LogOptWithSampling(first, thereafter int, interval time.Duration) LogOption {
+	return func(option *baseLogger) {
+		if 0 < first {
+			option.sampler = newSampler(first, thereafter, interval)
+		} else {
+			option.sampler = nil
+		}
+	}
+}
+
+// LogOptWithRedactor 日志脱敏, 在写入 driver 之前处理消息与 Extras 字段
+func LogOptWithRedactor(r *Redactor) LogOption {
+	return func(option *baseLogger) {
+		option.redactor = r
+	}
+}
+
 type QWRobotOption func(*QWRobot)
 
 func QWRobotOptWithCallerSkip(skip int) QWRobotOption {
@@ -47,3 +69,39 @@ func QWRobotOptWithCallerSkip(skip int) QWRobotOption {
 		option.callerSkip = skip
 	}
 }
+
+type AsyncOption func(*Async)
+
+// AsyncOptWithQueueSize 队列长度, 默认 4096
+func AsyncOptWithQueueSize(size int) AsyncOption {
+	return func(option *Async) {
+		if 0 < size {
+			option.queueSize = size
+		}
+	}
+}
+
+// AsyncOptWithOverflow 队列满时的处理方式, 默认阻塞
+func AsyncOptWithOverflow(p OverflowPolicy) AsyncOption {
+	return func(option *Async) {
+		option.overflow = p
+	}
+}
+
+// AsyncOptWithSampleRate OverflowSample 时每多少条写入一条, 默认 100
+func AsyncOptWithSampleRate(rate int) AsyncOption {
+	return func(option *Async) {
+		if 0 < rate {
+			option.sampleRate = uint64(rate)
+		}
+	}
+}
+
+// AsyncOptWithFlushTimeout Destroy 时等待队列写完的最长时间, 默认 5s
+func AsyncOptWithFlushTimeout(timeout time.Duration) AsyncOption {
+	return func(option *Async) {
+		if 0 < timeout {
+			option.flushTimeout = timeout
+		}
+	}
+}
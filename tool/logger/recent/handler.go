@@ -0,0 +1,59 @@
+package recent
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// Path 查询最近日志的路由, Install 时注册到 http_health.HealthChecker
+const Path = "/debug/logs"
+
+// Handler 以 JSON 返回 Default 中最近的日志
+// 查询参数 level 为日志级别, trace_id 对应 Extras 中的 trace 字段, limit 为最多返回最近的多少条,
+// 其余参数按 Extras 字段过滤, 如 uid=7
+//
+//	curl 'localhost/debug/logs?level=EROR&trace_id=xxx'
+func Handler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		writeJson(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	rc := Default()
+	if rc == nil {
+		writeJson(w, http.StatusNotFound, map[string]string{"error": "recent log is not installed"})
+		return
+	}
+	q := Query{Fields: make(map[string]string)}
+	for key, values := range r.URL.Query() {
+		v := values[0]
+		switch key {
+		case "level":
+			q.Level = v
+		case "limit":
+			limit, err := strconv.Atoi(v)
+			if err != nil || limit < 0 {
+				writeJson(w, http.StatusBadRequest, map[string]string{"error": "invalid limit: " + v})
+				return
+			}
+			q.Limit = limit
+		case "trace_id":
+			q.Fields[TraceKey] = v
+		default:
+			q.Fields[key] = v
+		}
+	}
+	entries, err := rc.Query(q)
+	if err != nil {
+		writeJson(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJson(w, http.StatusOK, entries)
+}
+
+func writeJson(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
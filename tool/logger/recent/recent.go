@@ -0,0 +1,252 @@
+package recent
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/senyu-up/toolbox/tool/http/http_health"
+	"github.com/senyu-up/toolbox/tool/logger"
+)
+
+// DefaultSize 每个级别默认保留的日志条数
+const DefaultSize = 100
+
+// TraceKey 查询参数 trace_id 对应的 Extras 字段, 与 logger.Extras.Trace 一致
+const TraceKey = "trace"
+
+var (
+	std     *Recent
+	stdLock sync.RWMutex
+)
+
+// Entry 一条日志
+type Entry struct {
+	Time    time.Time         `json:"time"`
+	Level   string            `json:"level"`
+	Message string            `json:"message"`
+	Fields  map[string]string `json:"fields,omitempty"`
+}
+
+// Query 查询条件
+type Query struct {
+	Level  string            // 日志级别, 如 EROR, 为空时查询全部级别
+	Fields map[string]string // Extras 字段需要等于的值, 如 trace: xxx
+	Limit  int               // 最多返回最近的多少条, 为 0 时不限制
+}
+
+// Recent 包装任意 Driver, 在内存中按级别保留最近的 size 条日志, 供线上排查时通过 /debug/logs 查看
+// 只记录写入被包装 Driver 的日志, 即不低于其当前级别的日志
+type Recent struct {
+	driver  logger.Driver
+	write   logger.Driver // 写日志使用的 Driver, 自行计算调用位置的 Driver 跳过 Recent 这一层
+	size    int
+	lock    sync.Mutex
+	buffers [logger.LevelTrace + 1]*ring
+}
+
+// ring 固定容量的环形缓冲, 满时覆盖最旧的一条
+// 不使用 queue.RingBuffer: tool/queue 依赖 tool/str, 会让 logger 跟着依赖 str; RingBuffer 按名称全局注册且不回收,
+// 不加锁读取 length, 也没有不出队的快照方法
+type ring struct {
+	items []*Entry
+	next  int // 下一条写入的位置
+	full  bool
+}
+
+func newRing(size int) *ring {
+	return &ring{items: make([]*Entry, size)}
+}
+
+func (b *ring) put(e *Entry) {
+	b.items[b.next] = e
+	if b.next++; b.next == len(b.items) {
+		b.next, b.full = 0, true
+	}
+}
+
+// entries 按写入先后返回缓冲中的日志
+func (b *ring) entries() []*Entry {
+	if !b.full {
+		return append([]*Entry(nil), b.items[:b.next]...)
+	}
+	return append(append([]*Entry(nil), b.items[b.next:]...), b.items[:b.next]...)
+}
+
+// New 包装 Driver, size 为每个级别保留的条数, 为 0 时使用 DefaultSize
+func New(d logger.Driver, size int) *Recent {
+	if size <= 0 {
+		size = DefaultSize
+	}
+	r := &Recent{driver: d, write: d, size: size}
+	if s, ok := d.(logger.CallerSkipper); ok {
+		r.write = s.WithCallerSkip(1)
+	}
+	for level := range r.buffers {
+		r.buffers[level] = newRing(size)
+	}
+	return r
+}
+
+// Install 包装全局 logger 当前使用的 driver, 并在 http_health.HealthChecker 上注册 /debug/logs
+// 重复调用时返回已安装的 Recent
+func Install(size int) *Recent {
+	stdLock.Lock()
+	defer stdLock.Unlock()
+	http_health.DebugHandle(Path, Handler)
+	logger.WrapDriver(func(d logger.Driver) logger.Driver {
+		if r, ok := d.(*Recent); ok {
+			std = r
+			return r
+		}
+		std = New(d, size)
+		return std
+	})
+	return std
+}
+
+// Default 返回 Install 安装的 Recent, 未安装时为 nil
+func Default() *Recent {
+	stdLock.RLock()
+	defer stdLock.RUnlock()
+	return std
+}
+
+func (r *Recent) LogWrite(when time.Time, msg string, level logger.LogLevel, extra []logger.Field) error {
+	if level >= logger.LevelEmergency && level <= logger.LevelTrace {
+		r.record(when, msg, level, extra)
+	}
+	return r.write.LogWrite(when, msg, level, extra)
+}
+
+func (r *Recent) record(when time.Time, msg string, level logger.LogLevel, extra []logger.Field) {
+	e := &Entry{Time: when, Level: logger.LevelName(level), Message: msg}
+	for _, field := range extra {
+		if field.Key == "" || field.Type == logger.SkipType {
+			continue
+		}
+		if e.Fields == nil {
+			e.Fields = make(map[string]string, len(extra))
+		}
+		e.Fields[field.Key] = fieldText(field)
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.buffers[level].put(e)
+}
+
+func fieldText(field logger.Field) string {
+	switch field.Type {
+	case logger.StringType:
+		return field.String
+	case logger.ByteStringType, logger.BinaryType:
+		return string(field.Bytes)
+	case logger.Int64Type, logger.Int32Type, logger.Int16Type, logger.Int8Type:
+		return fmt.Sprint(field.Integer)
+	case logger.Uint64Type, logger.Uint32Type, logger.Uint16Type, logger.Uint8Type, logger.UintptrType:
+		return fmt.Sprint(uint64(field.Integer))
+	case logger.Float64Type, logger.Float32Type:
+		return fmt.Sprint(field.Float)
+	case logger.BoolType:
+		return fmt.Sprint(field.Boolean)
+	}
+	if field.Interface != nil {
+		return fmt.Sprint(field.Interface)
+	}
+	return field.String
+}
+
+// Query 按条件查询最近的日志, 按时间先后排序
+func (r *Recent) Query(q Query) ([]Entry, error) {
+	buffers := r.buffers[:]
+	if q.Level != "" {
+		level, ok := logger.LevelMap[q.Level]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", logger.ErrInvalidLogLevel, q.Level)
+		}
+		buffers = buffers[level : level+1]
+	}
+
+	res := make([]Entry, 0)
+	r.lock.Lock()
+	for _, buf := range buffers {
+		for _, e := range buf.entries() {
+			if e.match(q.Fields) {
+				res = append(res, *e)
+			}
+		}
+	}
+	r.lock.Unlock()
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Time.Before(res[j].Time)
+	})
+	if q.Limit > 0 && len(res) > q.Limit {
+		res = res[len(res)-q.Limit:]
+	}
+	return res, nil
+}
+
+func (e *Entry) match(fields map[string]string) bool {
+	for k, v := range fields {
+		if got, ok := e.Fields[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Recent) Destroy() {
+	r.driver.Destroy()
+}
+
+func (r *Recent) CurrentLevel() logger.LogLevel {
+	return r.driver.CurrentLevel()
+}
+
+// SetLevel 修改被包装的 Driver 的日志级别, 被包装的 Driver 不支持时忽略
+func (r *Recent) SetLevel(level logger.LogLevel) {
+	if setter, ok := r.driver.(logger.LevelSetter); ok {
+		setter.SetLevel(level)
+	}
+}
+
+// Unwrap 返回被包装的 Driver
+func (r *Recent) Unwrap() logger.Driver {
+	return r.driver
+}
+
+// WithCallerSkip 返回与 r 共用缓冲的副本, 写日志时调用位置多跳过 skip 层
+func (r *Recent) WithCallerSkip(skip int) logger.Driver {
+	s, ok := r.write.(logger.CallerSkipper)
+	if !ok {
+		return r
+	}
+	return &callerSkip{Recent: r, write: s.WithCallerSkip(skip)}
+}
+
+// callerSkip Recent 的 WithCallerSkip 副本, 记录的日志与原 Recent 一起查询
+type callerSkip struct {
+	*Recent
+	write logger.Driver
+}
+
+func (s *callerSkip) LogWrite(when time.Time, msg string, level logger.LogLevel, extra []logger.Field) error {
+	if level >= logger.LevelEmergency && level <= logger.LevelTrace {
+		s.record(when, msg, level, extra)
+	}
+	return s.write.LogWrite(when, msg, level, extra)
+}
+
+func (s *callerSkip) WithCallerSkip(skip int) logger.Driver {
+	if d, ok := s.write.(logger.CallerSkipper); ok {
+		return &callerSkip{Recent: s.Recent, write: d.WithCallerSkip(skip)}
+	}
+	return s
+}
+
+// Name 返回被包装的 Driver 的名称, 日志格式与直接使用该 Driver 时一致
+func (r *Recent) Name() string {
+	return r.driver.Name()
+}
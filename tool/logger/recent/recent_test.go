@@ -0,0 +1,192 @@
+package recent
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/senyu-up/toolbox/tool/config"
+	"github.com/senyu-up/toolbox/tool/logger"
+)
+
+type countDriver struct {
+	sync.Mutex
+	count int
+	level logger.LogLevel
+}
+
+func (d *countDriver) LogWrite(when time.Time, msg string, level logger.LogLevel, extra []logger.Field) error {
+	d.Lock()
+	defer d.Unlock()
+	d.count++
+	return nil
+}
+
+func (d *countDriver) Destroy()                      {}
+func (d *countDriver) CurrentLevel() logger.LogLevel { return d.level }
+func (d *countDriver) Name() string                  { return "count" }
+
+func TestRecent_Query(t *testing.T) {
+	d := &countDriver{level: logger.LevelTrace}
+	r := New(d, 2)
+	now := time.Now()
+	r.LogWrite(now, "e1", logger.LevelError, logger.E().Trace("a").Int("uid", 1).Fields())
+	r.LogWrite(now.Add(1), "e2", logger.LevelError, logger.E().Trace("b").Fields())
+	r.LogWrite(now.Add(2), "i1", logger.LevelInformational, logger.E().Trace("a").Fields())
+	r.LogWrite(now.Add(3), "e3", logger.LevelError, logger.E().Trace("a").Int("uid", 3).Fields())
+	if d.count != 4 {
+		t.Errorf("driver count = %d, want 4", d.count)
+	}
+
+	tests := []struct {
+		name string
+		q    Query
+		want string
+	}{
+		// 每个级别只保留最近的 2 条
+		{name: "all", q: Query{}, want: "e2,i1,e3"},
+		{name: "level", q: Query{Level: "EROR"}, want: "e2,e3"},
+		{name: "trace", q: Query{Fields: map[string]string{TraceKey: "a"}}, want: "i1,e3"},
+		{name: "field", q: Query{Fields: map[string]string{"uid": "3"}}, want: "e3"},
+		{name: "limit", q: Query{Limit: 1}, want: "e3"},
+		{name: "empty", q: Query{Level: "WARN"}, want: ""},
+	}
+	for _, tt := range tests {
+		entries, err := r.Query(tt.q)
+		if err != nil {
+			t.Fatalf("%s: Query() error = %v", tt.name, err)
+		}
+		var msgs []string
+		for _, e := range entries {
+			msgs = append(msgs, e.Message)
+		}
+		if got := strings.Join(msgs, ","); got != tt.want {
+			t.Errorf("%s: Query() = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+	if _, err := r.Query(Query{Level: "error"}); err == nil {
+		t.Errorf("Query() with invalid level should fail")
+	}
+}
+
+// 输出到 stdout 的 zap driver, 返回读取每行调用位置的函数
+func newStdoutZap(t *testing.T) (*logger.Zap, func() string) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Pipe() error = %v", err)
+	}
+	t.Cleanup(func() { r.Close(); w.Close() })
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	z := &logger.Zap{}
+	if err = z.InitByConf(config.ZapConfig{Level: "DEBUG"}); err != nil {
+		t.Fatalf("InitByConf() error = %v", err)
+	}
+	lines := bufio.NewReader(r)
+	return z, func() string {
+		line, err := lines.ReadString('\n')
+		if err != nil {
+			t.Fatalf("ReadString() error = %v", err)
+		}
+		// 时间 级别 调用位置 消息, 以 tab 分隔
+		return strings.Split(line, "\t")[2]
+	}
+}
+
+func TestRecent_CallerSkip(t *testing.T) {
+	z, caller := newStdoutZap(t)
+	tests := []struct {
+		name    string
+		want    logger.Driver
+		wrapped logger.Driver
+	}{
+		// Recent 多出的一层不影响 zap 的调用位置
+		{name: "recent", want: z, wrapped: New(z, 10)},
+		{name: "skip", want: z.WithCallerSkip(1), wrapped: New(z, 10).WithCallerSkip(1)},
+		{name: "skip twice", want: z.WithCallerSkip(2), wrapped: New(z, 10).WithCallerSkip(1).(logger.CallerSkipper).WithCallerSkip(1)},
+	}
+	for _, tt := range tests {
+		var callers []string
+		// 在同一行写日志, 跳过的层数相同时调用位置一致
+		for _, d := range []logger.Driver{tt.want, tt.wrapped} {
+			logger.NewLocalLogger("zap", d, logger.LogOptWithCallDepth(logger.DefaultCallDepth)).Info(tt.name)
+			callers = append(callers, caller())
+		}
+		if callers[0] != callers[1] {
+			t.Errorf("%s: caller = %s, want %s", tt.name, callers[1], callers[0])
+		}
+	}
+
+	// 副本记录的日志与原 Recent 一起查询
+	r := New(z, 10)
+	r.WithCallerSkip(1).LogWrite(time.Now(), "copy", logger.LevelInformational, nil)
+	caller()
+	if entries, _ := r.Query(Query{}); len(entries) != 1 || entries[0].Message != "copy" {
+		t.Errorf("Query() = %+v", entries)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	if _, err := logger.InitDefaultLoggerByConf(&config.LogConfig{Console: &config.ConsoleConfig{Level: "INFO"}}); err != nil {
+		t.Fatalf("InitDefaultLoggerByConf() error = %v", err)
+	}
+	r := Install(10)
+	if Install(10) != r || Default() != r {
+		t.Fatalf("Install() should return the installed Recent")
+	}
+	logger.TraceId("t1").Error("boom %d", 1)
+	logger.TraceId("t2").Error("boom %d", 2)
+	logger.Debug("not written")
+	// 修改级别时作用于被包装的 driver
+	if err := logger.SetLevel(logger.AdapterConsole, logger.LevelWarning, 0); err != nil || r.CurrentLevel() != logger.LevelWarning {
+		t.Errorf("SetLevel() error = %v, level = %d", err, r.CurrentLevel())
+	}
+	defer logger.SetLevel(logger.AdapterConsole, logger.LevelInformational, 0)
+
+	tests := []struct {
+		query      string
+		wantStatus int
+		wantMsgs   []string
+	}{
+		{query: "level=EROR&trace_id=t1", wantStatus: http.StatusOK, wantMsgs: []string{"boom 1"}},
+		{query: "limit=1", wantStatus: http.StatusOK, wantMsgs: []string{"boom 2"}},
+		{query: "level=DEBG", wantStatus: http.StatusOK},
+		{query: "level=debug", wantStatus: http.StatusBadRequest},
+		{query: "limit=x", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		Handler(w, httptest.NewRequest(http.MethodGet, Path+"?"+tt.query, nil))
+		if w.Code != tt.wantStatus {
+			t.Fatalf("%s: status = %d, body %s", tt.query, w.Code, w.Body)
+		}
+		if w.Code != http.StatusOK {
+			continue
+		}
+		var entries []Entry
+		if err := json.Unmarshal(w.Body.Bytes(), &entries); err != nil {
+			t.Fatalf("Unmarshal() error = %v", err)
+		}
+		if len(entries) != len(tt.wantMsgs) {
+			t.Fatalf("%s: entries = %+v", tt.query, entries)
+		}
+		for i, e := range entries {
+			if e.Level != "EROR" || !strings.HasSuffix(e.Message, tt.wantMsgs[i]) {
+				t.Errorf("%s: entry = %+v", tt.query, e)
+			}
+		}
+	}
+
+	w := httptest.NewRecorder()
+	Handler(w, httptest.NewRequest(http.MethodPost, Path, nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %d", w.Code)
+	}
+}
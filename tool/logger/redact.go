@@ -0,0 +1,178 @@
+package logger
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/senyu-up/toolbox/tool/config"
+	"github.com/senyu-up/toolbox/tool/sensitive"
+)
+
+// RedactMode 脱敏方式
+type RedactMode int
+
+const (
+	RedactMask RedactMode = iota // 部分遮盖, 保留首尾各 1/4, 如 13*******78
+	RedactHash                   // 替换为 sha256 摘要的前 16 位, 相同的值摘要相同, 便于排查
+	RedactDrop                   // 删除字段, 消息中的敏感词直接删除
+)
+
+// RedactModeMap 配置中的 mode 与 RedactMode 映射关系
+var RedactModeMap = map[string]RedactMode{
+	"mask": RedactMask,
+	"hash": RedactHash,
+	"drop": RedactDrop,
+	"":     RedactMask,
+}
+
+// DefaultRedactKeys 默认脱敏的字段, 字段名包含其中之一即脱敏, 不区分大小写
+var DefaultRedactKeys = []string{"password", "token", "phone", "email"}
+
+// Redactor 日志脱敏, 在写入 driver 之前处理消息与 Extras 字段
+//   - 字段名包含 keys 之一的字段按 mode 脱敏
+//   - 消息及字符串类型的字段中, 命中 filter 敏感词的部分按 mode 脱敏
+type Redactor struct {
+	mode   RedactMode
+	keys   []string
+	filter *sensitive.Filter
+}
+
+// NewRedactor keys 为空时使用 DefaultRedactKeys, filter 为 nil 时不处理消息
+func NewRedactor(mode RedactMode, keys []string, filter *sensitive.Filter) *Redactor {
+	if len(keys) == 0 {
+		keys = DefaultRedactKeys
+	}
+	r := &Redactor{mode: mode, filter: filter}
+	for _, key := range keys {
+		if key != "" {
+			r.keys = append(r.keys, strings.ToLower(key))
+		}
+	}
+	return r
+}
+
+// NewRedactorByConf 按配置创建, words 为消息中需要脱敏的敏感词
+func NewRedactorByConf(conf config.RedactConfig) (*Redactor, error) {
+	mode, ok := RedactModeMap[strings.ToLower(conf.Mode)]
+	if !ok {
+		return nil, fmt.Errorf("invalid log redact mode: %s", conf.Mode)
+	}
+	var filter *sensitive.Filter
+	if len(conf.Words) > 0 {
+		filter = sensitive.New()
+		for _, word := range conf.Words {
+			filter.AddWord(sensitive.Word{Text: word, IsSensitive: true})
+		}
+	}
+	return NewRedactor(mode, conf.Keys, filter), nil
+}
+
+// Value 按 mode 脱敏一个值, drop 时返回空
+func (r *Redactor) Value(v string) string {
+	switch r.mode {
+	case RedactDrop:
+		return ""
+	case RedactHash:
+		sum := sha256.Sum256([]byte(v))
+		return "sha256:" + hex.EncodeToString(sum[:8])
+	default:
+		runes := []rune(v)
+		keep := len(runes) / 4
+		for i := keep; i < len(runes)-keep; i++ {
+			runes[i] = '*'
+		}
+		return string(runes)
+	}
+}
+
+// Message 消息中命中敏感词的部分脱敏
+func (r *Redactor) Message(msg string) string {
+	if r.filter == nil || msg == "" {
+		return msg
+	}
+	for _, word := range r.filter.FindAll(msg) {
+		msg = strings.ReplaceAll(msg, word.Text, r.Value(word.Text))
+	}
+	return msg
+}
+
+// Fields 字段脱敏, 有改动时返回新的切片, 不修改传入的 fields
+func (r *Redactor) Fields(fields []Field) []Field {
+	var res []Field
+	for i, field := range fields {
+		redacted, changed, drop := r.field(field)
+		if !changed && res == nil {
+			continue
+		}
+		if res == nil {
+			res = make([]Field, i, len(fields))
+			copy(res, fields[:i])
+		}
+		if !drop {
+			res = append(res, redacted)
+		}
+	}
+	if res == nil {
+		return fields
+	}
+	return res
+}
+
+func (r *Redactor) field(field Field) (res Field, changed, drop bool) {
+	if r.matchKey(field.Key) {
+		if r.mode == RedactDrop {
+			return field, true, true
+		}
+		v, _ := fieldText(field)
+		return Field{Key: field.Key, Type: StringType, String: r.Value(v)}, true, false
+	}
+	if v, isText := fieldText(field); isText {
+		if msg := r.Message(v); msg != v {
+			return Field{Key: field.Key, Type: StringType, String: msg}, true, false
+		}
+	}
+	return field, false, false
+}
+
+func (r *Redactor) matchKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	key = strings.ToLower(key)
+	for _, k := range r.keys {
+		if strings.Contains(key, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldText 字段值的文本, isText 表示字段本身为文本, 如字符串, error
+func fieldText(field Field) (v string, isText bool) {
+	switch field.Type {
+	case StringType:
+		return field.String, true
+	case ByteStringType, BinaryType:
+		return string(field.Bytes), true
+	case ErrorType, StringerType:
+		if field.Interface == nil {
+			return "", false
+		}
+		return fmt.Sprint(field.Interface), true
+	case Int64Type, Int32Type, Int16Type, Int8Type:
+		return strconv.FormatInt(field.Integer, 10), false
+	case Uint64Type, Uint32Type, Uint16Type, Uint8Type, UintptrType:
+		return strconv.FormatUint(uint64(field.Integer), 10), false
+	case Float64Type, Float32Type:
+		return strconv.FormatFloat(field.Float, 'f', -1, 64), false
+	case BoolType:
+		return strconv.FormatBool(field.Boolean), false
+	}
+	if field.Interface != nil {
+		return fmt.Sprint(field.Interface), false
+	}
+	return field.String, false
+}
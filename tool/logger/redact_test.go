@@ -0,0 +1,107 @@
+package logger
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/senyu-up/toolbox/tool/config"
+	"github.com/senyu-up/toolbox/tool/sensitive"
+)
+
+func TestRedactor_Value(t *testing.T) {
+	tests := []struct {
+		mode RedactMode
+		v    string
+		want string
+	}{
+		{mode: RedactMask, v: "13812345678", want: "13*******78"},
+		{mode: RedactMask, v: "abc", want: "***"},
+		{mode: RedactMask, v: "密码是123456", want: "密码*****56"},
+		{mode: RedactHash, v: "13812345678", want: "sha256:0123456789abcdef"}, // 只比较长度
+		{mode: RedactDrop, v: "13812345678", want: ""},
+	}
+	for _, tt := range tests {
+		got := NewRedactor(tt.mode, nil, nil).Value(tt.v)
+		if tt.mode == RedactHash {
+			if !strings.HasPrefix(got, "sha256:") || len(got) != len(tt.want) || got != NewRedactor(tt.mode, nil, nil).Value(tt.v) {
+				t.Errorf("Value(%q) = %q", tt.v, got)
+			}
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Value(%q) = %q, want %q", tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestRedactor_Fields(t *testing.T) {
+	filter := sensitive.New()
+	filter.AddWord(sensitive.Word{Text: "secret-key"})
+	fields := E().String("user_phone", "13812345678").Int64("Phone", 13812345678).String("name", "xh").
+		String("Password", "123456").Error(errors.New("auth by secret-key failed")).Fields()
+
+	tests := []struct {
+		name string
+		mode RedactMode
+		want map[string]string
+	}{
+		{name: "mask", mode: RedactMask, want: map[string]string{
+			"user_phone": "13*******78", "Phone": "13*******78", "name": "xh", "Password": "1****6", "error": "auth by se******ey failed"}},
+		{name: "drop", mode: RedactDrop, want: map[string]string{"name": "xh", "error": "auth by  failed"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewRedactor(tt.mode, nil, filter).Fields(fields)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Fields() = %+v", got)
+			}
+			for _, f := range got {
+				if v, _ := fieldText(f); v != tt.want[f.Key] {
+					t.Errorf("field %s = %q, want %q", f.Key, v, tt.want[f.Key])
+				}
+			}
+			if fields[0].String != "13812345678" {
+				t.Errorf("Fields() should not modify the input")
+			}
+		})
+	}
+
+	// 没有需要脱敏的字段时返回原切片
+	plain := E().String("name", "xh").Fields()
+	if got := NewRedactor(RedactMask, nil, filter).Fields(plain); &got[0] != &plain[0] {
+		t.Errorf("Fields() should return the input when nothing changed")
+	}
+}
+
+func TestRedactor_Logger(t *testing.T) {
+	redactor, err := NewRedactorByConf(config.RedactConfig{Mode: "mask", Keys: []string{"id_card"}, Words: []string{"hunter2"}})
+	if err != nil {
+		t.Fatalf("NewRedactorByConf() error = %v", err)
+	}
+	if _, err = NewRedactorByConf(config.RedactConfig{Mode: "unknown"}); err == nil {
+		t.Errorf("NewRedactorByConf() should fail with invalid mode")
+	}
+
+	d := &memDriver{}
+	cb := &memDriver{}
+	l := NewLocalLogger("mem", d, LogOptWithRedactor(redactor), LogOptWithCallBack(cb))
+	l.SetExtra(E().String("id_card", "110101199001011234").String("phone", "13812345678")).
+		Notify().Info("login with password %s", "hunter2")
+
+	for _, m := range []*memDriver{d, cb} {
+		msgs := m.messages()
+		if len(msgs) != 1 || strings.Contains(msgs[0], "hunter2") || !strings.HasSuffix(msgs[0], "password h*****2") {
+			t.Fatalf("messages = %v", msgs)
+		}
+		for _, f := range m.extras[0] {
+			if f.Key == "id_card" && f.String != "1101**********1234" {
+				t.Errorf("id_card = %q", f.String)
+			}
+			// 指定 keys 后不再使用默认的 keys
+			if f.Key == "phone" && f.String != "13812345678" {
+				t.Errorf("phone = %q", f.String)
+			}
+		}
+	}
+}
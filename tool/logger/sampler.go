@@ -0,0 +1,163 @@
+package logger
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultSampleInterval = time.Second
+
+// sampler 日志采样、去重
+// 按 (日志级别, format, 调用位置) 计数, 每个 interval 内前 first 条正常输出, 之后每 thereafter 条输出一条,
+// 被抑制的条数在 interval 结束后输出一条 "suppressed X similar messages" 汇总
+type sampler struct {
+	first      uint64
+	thereafter uint64
+	interval   time.Duration
+
+	lock      sync.Mutex
+	counters  map[sampleKey]*sampleCounter
+	reporting bool // 后台汇总协程是否在运行, 没有需要汇总的日志时退出
+}
+
+type sampleKey struct {
+	level  LogLevel
+	format string
+	pc     uintptr
+}
+
+type sampleCounter struct {
+	start      time.Time
+	count      uint64
+	suppressed uint64
+
+	// 输出汇总时使用最近一次写入的 driver, 格式与脱敏
+	adapter    Driver
+	redactor   *Redactor
+	timeFormat string
+	usePath    string
+}
+
+func newSampler(first, thereafter int, interval time.Duration) *sampler {
+	if interval <= 0 {
+		interval = defaultSampleInterval
+	}
+	return &sampler{
+		first:      uint64(first),
+		thereafter: uint64(thereafter),
+		interval:   interval,
+		counters:   make(map[sampleKey]*sampleCounter),
+	}
+}
+
+// allow 是否输出这条日志, 由 doLog 调用, callDepth 与 writeMsg 中的一致
+func (s *sampler) allow(adapter Driver, redactor *Redactor, timeFormat, usePath string, level LogLevel, format string, callDepth int) bool {
+	var pcs [1]uintptr
+	runtime.Callers(callDepth+1, pcs[:])
+	key := sampleKey{level: level, format: format, pc: pcs[0]}
+	now := time.Now()
+
+	s.lock.Lock()
+	c, ok := s.counters[key]
+	if !ok {
+		c = &sampleCounter{start: now}
+		s.counters[key] = c
+	}
+	c.adapter, c.redactor, c.timeFormat, c.usePath = adapter, redactor, timeFormat, usePath
+
+	var (
+		summary uint64
+		last    = *c
+	)
+	if now.Sub(c.start) >= s.interval {
+		summary, c.suppressed = c.suppressed, 0
+		c.start, c.count = now, 0
+	}
+	c.count++
+	allowed := c.count <= s.first || (s.thereafter > 0 && (c.count-s.first)%s.thereafter == 0)
+	if !allowed {
+		c.suppressed++
+		if !s.reporting {
+			s.reporting = true
+			go s.report()
+		}
+	}
+	s.lock.Unlock()
+
+	if summary > 0 {
+		s.writeSummary(key, &last, summary, now)
+	}
+	return allowed
+}
+
+// report 定期输出汇总, 并清理过期的计数
+func (s *sampler) report() {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		type summary struct {
+			key sampleKey
+			c   sampleCounter
+		}
+		var summaries []summary
+
+		s.lock.Lock()
+		for key, c := range s.counters {
+			if now.Sub(c.start) < s.interval {
+				continue
+			}
+			if c.suppressed > 0 {
+				summaries = append(summaries, summary{key: key, c: *c})
+			}
+			delete(s.counters, key)
+		}
+		pending := false
+		for _, c := range s.counters {
+			if c.suppressed > 0 {
+				pending = true
+				break
+			}
+		}
+		if !pending {
+			s.reporting = false
+		}
+		s.lock.Unlock()
+
+		for _, sum := range summaries {
+			s.writeSummary(sum.key, &sum.c, sum.c.suppressed, now)
+		}
+		if !pending {
+			return
+		}
+	}
+}
+
+func (s *sampler) writeSummary(key sampleKey, c *sampleCounter, suppressed uint64, when time.Time) {
+	adapter, timeFormat, usePath := c.adapter, c.timeFormat, c.usePath
+	if adapter == nil {
+		return
+	}
+	src := ""
+	if frame, _ := runtime.CallersFrames([]uintptr{key.pc}).Next(); frame.File != "" {
+		strim := "src/"
+		if usePath != "" {
+			strim = usePath
+		}
+		src = fmt.Sprintf("%s:%d", stringTrim(toShortCaller(frame.File), strim), frame.Line)
+	}
+	// 不带参数的日志 format 即为消息本身, 与 doLog 一样脱敏
+	format := key.format
+	if c.redactor != nil {
+		format = c.redactor.Message(format)
+	}
+	msg := fmt.Sprintf("suppressed %d similar messages in last %s: %q", suppressed, s.interval, format)
+	if !rawMsg(adapter) {
+		msg = when.Format(timeFormat) + " [" + levelPrefix[key.level] + "] [" + strings.Replace(src, "%2e", ".", -1) + "] " + msg
+	} else {
+		msg += " caller: " + src
+	}
+	adapter.LogWrite(when, msg, key.level, nil)
+}
@@ -0,0 +1,110 @@
+package logger
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/senyu-up/toolbox/tool/config"
+)
+
+func TestSampler(t *testing.T) {
+	d := &memDriver{}
+	l := NewLocalLogger("mem", d, LogOptWithSampling(2, 3, 50*time.Millisecond), LogOptWithCallDepth(DefaultCallDepth))
+
+	for i := 0; i < 10; i++ {
+		l.Error("redis down: %d", i)
+	}
+	// 不同调用位置, 不同 format, 不同级别分别计数
+	l.Error("redis down: %d", 10)
+	l.Error("mysql down: %d", 0)
+	l.Warn("redis down: %d", 0)
+	// Emer, Alert 不采样
+	for i := 0; i < 5; i++ {
+		l.Alert("disk full: %d", i)
+	}
+
+	msgs := d.messages()
+	want := []string{"[EROR] redis down: 0", "[EROR] redis down: 1", "[EROR] redis down: 4", "[EROR] redis down: 7",
+		"[EROR] redis down: 10", "[EROR] mysql down: 0", "[WARN] redis down: 0", "[ALRT] disk full: 0", "[ALRT] disk full: 1",
+		"[ALRT] disk full: 2", "[ALRT] disk full: 3", "[ALRT] disk full: 4"}
+	if len(msgs) != len(want) {
+		t.Fatalf("messages = %v, want %v", msgs, want)
+	}
+	for i, w := range want {
+		level, msg := w[:6], w[7:]
+		if !strings.Contains(msgs[i], level) || !strings.HasSuffix(msgs[i], msg) {
+			t.Errorf("message %d = %q, want %q", i, msgs[i], w)
+		}
+	}
+
+	// 周期结束后输出汇总
+	deadline := time.Now().Add(time.Second)
+	for len(d.messages()) == len(msgs) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	msgs = d.messages()
+	if len(msgs) != len(want)+1 {
+		t.Fatalf("messages = %v, want a summary", msgs)
+	}
+	summary := msgs[len(msgs)-1]
+	if !strings.Contains(summary, "[EROR]") || !strings.Contains(summary, `suppressed 6 similar messages`) ||
+		!strings.Contains(summary, `"redis down: %d"`) || !strings.Contains(summary, "sampler_test.go:") {
+		t.Errorf("summary = %q", summary)
+	}
+
+	// 新的周期重新计数
+	for i := 0; i < 3; i++ {
+		l.Error("redis down: %d", i)
+	}
+	if got := len(d.messages()) - len(msgs); got != 2 {
+		t.Errorf("new interval messages = %d, want 2", got)
+	}
+}
+
+func TestSampler_Redact(t *testing.T) {
+	redactor, err := NewRedactorByConf(config.RedactConfig{Mode: "mask", Words: []string{"hunter2"}})
+	if err != nil {
+		t.Fatalf("NewRedactorByConf() error = %v", err)
+	}
+	d := &memDriver{}
+	l := NewLocalLogger("mem", d, LogOptWithSampling(1, 0, 50*time.Millisecond), LogOptWithRedactor(redactor),
+		LogOptWithCallDepth(DefaultCallDepth))
+	for i := 0; i < 3; i++ {
+		l.Info("login with password hunter2")
+	}
+
+	// 汇总中的 format 同样脱敏
+	deadline := time.Now().Add(time.Second)
+	for len(d.messages()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	msgs := d.messages()
+	if len(msgs) != 2 || !strings.Contains(msgs[1], `suppressed 2 similar messages`) {
+		t.Fatalf("messages = %v, want a summary", msgs)
+	}
+	for _, msg := range msgs {
+		if strings.Contains(msg, "hunter2") || !strings.Contains(msg, "password h*****2") {
+			t.Errorf("message = %q, should be redacted", msg)
+		}
+	}
+}
+
+func TestSampler_InitByConf(t *testing.T) {
+	log, err := InitLoggerByConf(&config.LogConfig{
+		Console:  &config.ConsoleConfig{Level: "DEBG"},
+		Sampling: &config.SamplingConfig{First: 1, Interval: time.Minute},
+	})
+	if err != nil {
+		t.Fatalf("InitLoggerByConf() error = %v", err)
+	}
+	s := log.(*LocalLogger).bl.sampler
+	if s == nil || s.first != 1 || s.thereafter != 0 || s.interval != time.Minute {
+		t.Fatalf("sampler = %+v", s)
+	}
+	// Clone 出的 logger 共用计数
+	if log.Ctx(context.Background()).(*baseLogger).sampler != s {
+		t.Errorf("cloned logger should share sampler")
+	}
+}
@@ -0,0 +1,235 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// SlogHandler 实现 slog.Handler, 将通过 slog 输出的日志写入 logger 的 Driver,
+// 第三方库使用 slog 时日志也能进入统一的输出、脱敏、采集流程
+//
+//	slog.SetDefault(slog.New(logger.NewSlogHandler(nil)))
+type SlogHandler struct {
+	driver Driver  // 为 nil 时使用全局 logger 当前的 driver
+	fields []Field // WithAttrs 追加的字段
+	group  string  // WithGroup 的前缀, 如 a.b.
+}
+
+// NewSlogHandler d 为 nil 时写入全局 logger 当前的 driver, 并使用其时间格式、脱敏规则,
+// SwitchLogger, WrapDriver 之后仍然生效
+func NewSlogHandler(d Driver) *SlogHandler {
+	return &SlogHandler{driver: d}
+}
+
+func (h *SlogHandler) target() (d Driver, timeFormat, usePath string, redactor *Redactor) {
+	if h.driver != nil {
+		return h.driver, LogTimeDefaultFormat, "", nil
+	}
+	if logInst == nil {
+		initDefaultLogger()
+	}
+	l := logInst
+	return l.adapter, l.timeFormat, l.usePath, l.redactor
+}
+
+func (h *SlogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	d, _, _, _ := h.target()
+	return FromSlogLevel(level) <= d.CurrentLevel()
+}
+
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	d, timeFormat, usePath, redactor := h.target()
+	level := FromSlogLevel(r.Level)
+	if level > d.CurrentLevel() {
+		return nil
+	}
+
+	e := &Extras{fields: make([]Field, len(h.fields), len(h.fields)+r.NumAttrs()+3)}
+	copy(e.fields, h.fields)
+	r.Attrs(func(a slog.Attr) bool {
+		e.fields = appendAttr(e.fields, h.group, a)
+		return true
+	})
+	traceId, spanId := ParseTrace(ctx)
+	e.Trace(traceId).Span(spanId)
+
+	msg := r.Message
+	if redactor != nil {
+		msg = redactor.Message(msg)
+		e.fields = redactor.Fields(e.fields)
+	}
+	when := r.Time
+	if when.IsZero() {
+		when = time.Now()
+	}
+	e.Int64("ms", when.UnixNano()/1e6)
+	if !rawMsg(d) {
+		msg = when.Format(timeFormat) + " [" + levelPrefix[level] + "] [" + slogSource(r.PC, usePath) + "] " + msg
+	} else if callerField(d) {
+		e.String(CallerKey, slogSource(r.PC, usePath))
+	}
+	return d.LogWrite(when, msg, level, e.fields)
+}
+
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	c := *h
+	c.fields = make([]Field, len(h.fields), len(h.fields)+len(attrs))
+	copy(c.fields, h.fields)
+	for _, a := range attrs {
+		c.fields = appendAttr(c.fields, h.group, a)
+	}
+	return &c
+}
+
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	c := *h
+	c.group = h.group + name + "."
+	return &c
+}
+
+// appendAttr group 展开为 group.key, 空的 Attr 与空 group 忽略
+func appendAttr(fields []Field, group string, a slog.Attr) []Field {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			group += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			fields = appendAttr(fields, group, ga)
+		}
+		return fields
+	}
+	return append(fields, anyField(group+a.Key, a.Value.Any()))
+}
+
+func slogSource(pc uintptr, usePath string) string {
+	if pc == 0 {
+		return ""
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	if frame.File == "" {
+		return ""
+	}
+	strim := "src/"
+	if usePath != "" {
+		strim = usePath
+	}
+	return strings.Replace(fmt.Sprintf("%s:%d", stringTrim(toShortCaller(frame.File), strim), frame.Line), "%2e", ".", -1)
+}
+
+// FromSlogLevel slog 级别转换为 LogLevel, 高于 Error 的级别为 Crit, 低于 Debug 的级别为 Trac
+func FromSlogLevel(l slog.Level) LogLevel {
+	switch {
+	case l > slog.LevelError:
+		return LevelCritical
+	case l >= slog.LevelError:
+		return LevelError
+	case l >= slog.LevelWarn:
+		return LevelWarning
+	case l >= slog.LevelInfo:
+		return LevelInformational
+	case l >= slog.LevelDebug:
+		return LevelDebug
+	}
+	return LevelTrace
+}
+
+// ToSlogLevel LogLevel 转换为 slog 级别, Emer, Alert, Crit 均为 slog.LevelError+4
+func ToSlogLevel(level LogLevel) slog.Level {
+	switch {
+	case level <= LevelCritical:
+		return slog.LevelError + 4
+	case level == LevelError:
+		return slog.LevelError
+	case level == LevelWarning:
+		return slog.LevelWarn
+	case level == LevelInformational:
+		return slog.LevelInfo
+	case level == LevelDebug:
+		return slog.LevelDebug
+	}
+	return slog.LevelDebug - 4
+}
+
+// Slog 将日志转发到任意 slog.Handler, 如 slog.NewJSONHandler 或第三方的 handler
+// 时间、级别由 slog.Handler 输出, msg 不再拼接时间、级别、调用位置
+// 不要转发到写入同一 driver 的 SlogHandler, 否则会循环写入
+type Slog struct {
+	LogLevel LogLevel
+	curLevel atomicLevel
+	handler  slog.Handler
+}
+
+func NewSlog(h slog.Handler, level LogLevel) *Slog {
+	return &Slog{LogLevel: level, handler: h}
+}
+
+func (s *Slog) LogWrite(when time.Time, msg string, level LogLevel, extra []Field) error {
+	if level > s.CurrentLevel() {
+		return nil
+	}
+	ctx := context.Background()
+	sl := ToSlogLevel(level)
+	if !s.handler.Enabled(ctx, sl) {
+		return nil
+	}
+	r := slog.NewRecord(when, sl, msg, 0)
+	for _, field := range extra {
+		if a, ok := fieldAttr(field); ok {
+			r.AddAttrs(a)
+		}
+	}
+	return s.handler.Handle(ctx, r)
+}
+
+func fieldAttr(field Field) (slog.Attr, bool) {
+	if field.Key == "" || field.Type == SkipType {
+		return slog.Attr{}, false
+	}
+	switch field.Type {
+	case StringType:
+		return slog.String(field.Key, field.String), true
+	case ByteStringType, BinaryType:
+		return slog.String(field.Key, string(field.Bytes)), true
+	case Int64Type, Int32Type, Int16Type, Int8Type:
+		return slog.Int64(field.Key, field.Integer), true
+	case Uint64Type, Uint32Type, Uint16Type, Uint8Type, UintptrType:
+		return slog.Uint64(field.Key, uint64(field.Integer)), true
+	case Float64Type, Float32Type:
+		return slog.Float64(field.Key, field.Float), true
+	case BoolType:
+		return slog.Bool(field.Key, field.Boolean), true
+	}
+	if field.Interface != nil {
+		return slog.Any(field.Key, field.Interface), true
+	}
+	return slog.String(field.Key, field.String), true
+}
+
+func (s *Slog) Destroy() {}
+
+func (s *Slog) CurrentLevel() LogLevel {
+	return s.curLevel.load(s.LogLevel)
+}
+
+// SetLevel 运行时修改日志级别
+func (s *Slog) SetLevel(level LogLevel) {
+	s.curLevel.store(level)
+}
+
+func (s *Slog) Name() string {
+	return AdapterSlog
+}
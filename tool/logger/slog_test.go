@@ -0,0 +1,96 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/senyu-up/toolbox/tool/trace"
+)
+
+func TestSlogHandler(t *testing.T) {
+	d := &memDriver{}
+	ctx := trace.NewContextWithRequestIdAndSpanId(context.TODO(), "req1", "span1")
+	l := slog.New(NewSlogHandler(d)).With("app", "toolbox").WithGroup("req")
+
+	l.InfoContext(ctx, "hello", "uid", 7, slog.Group("user", "name", "xh"), slog.Group("empty"))
+	l.Log(ctx, slog.LevelDebug-4, "trace level")
+	if l.Enabled(ctx, slog.LevelDebug-4) || !l.Enabled(ctx, slog.LevelDebug) {
+		t.Errorf("Enabled() should follow driver level %s", LevelName(d.CurrentLevel()))
+	}
+
+	msgs := d.messages()
+	if len(msgs) != 1 {
+		t.Fatalf("messages = %v", msgs)
+	}
+	if !strings.Contains(msgs[0], "[INFO] [tool/logger/slog_test.go:") || !strings.HasSuffix(msgs[0], "hello") {
+		t.Errorf("message = %s", msgs[0])
+	}
+	fields := fieldMap(d.extras[0])
+	if fields["app"].String != "toolbox" || fields["req.uid"].Integer != 7 || fields["req.user.name"].String != "xh" ||
+		fields["trace"].String != "req1" || fields["span"].String != "span1" {
+		t.Errorf("fields = %+v", d.extras[0])
+	}
+	if _, ok := fields["req.empty"]; ok {
+		t.Errorf("empty group should be ignored, fields = %+v", d.extras[0])
+	}
+}
+
+func TestSlogLevel(t *testing.T) {
+	tests := []struct {
+		slog  slog.Level
+		level LogLevel
+	}{
+		{slog: slog.LevelError + 4, level: LevelCritical},
+		{slog: slog.LevelError, level: LevelError},
+		{slog: slog.LevelWarn, level: LevelWarning},
+		{slog: slog.LevelInfo, level: LevelInformational},
+		{slog: slog.LevelDebug, level: LevelDebug},
+		{slog: slog.LevelDebug - 4, level: LevelTrace},
+	}
+	for _, tt := range tests {
+		if got := FromSlogLevel(tt.slog); got != tt.level {
+			t.Errorf("FromSlogLevel(%v) = %v, want %v", tt.slog, got, tt.level)
+		}
+		if got := ToSlogLevel(tt.level); got != tt.slog {
+			t.Errorf("ToSlogLevel(%v) = %v, want %v", tt.level, got, tt.slog)
+		}
+	}
+	if got := FromSlogLevel(slog.LevelInfo + 2); got != LevelInformational {
+		t.Errorf("FromSlogLevel(INFO+2) = %v", got)
+	}
+}
+
+func TestSlog(t *testing.T) {
+	var buf bytes.Buffer
+	d := NewSlog(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}), LevelInformational)
+	err := errors.New("boom")
+
+	d.LogWrite(time.Now(), "debug", LevelDebug, nil)
+	d.LogWrite(time.Now(), "hello", LevelError, E().String("name", "xh").Int("uid", 7).Error(err).NamedError("skip", nil).Fields())
+	// 通过 logger 写入时 msg 不拼接时间、级别
+	NewLocalLogger(AdapterSlog, d).Warnw(trace.NewContextWithRequestIdAndSpanId(context.TODO(), "req1", "span1"), "raw")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("lines = %v", lines)
+	}
+	var first, second map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if first["level"] != "ERROR" || first["msg"] != "hello" || first["name"] != "xh" || first["uid"] != float64(7) || first["error"] != "boom" {
+		t.Errorf("first = %v", first)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &second); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if second["level"] != "WARN" || second["msg"] != "raw" || second["trace"] != "req1" {
+		t.Errorf("second = %v", second)
+	}
+}
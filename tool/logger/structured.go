@@ -0,0 +1,161 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/senyu-up/toolbox/tool/trace"
+)
+
+// BadKey kv 中缺少 key 或 key 不是字符串时使用的字段名
+const BadKey = "!BADKEY"
+
+// tracer 自带链路信息的 ctx, 如 su_logger.Ctx 返回的 ctx
+type tracer interface {
+	Trace() (traceId, spanId string)
+}
+
+// ParseTrace 从 ctx 解析 traceId, spanId
+func ParseTrace(ctx context.Context) (traceId, spanId string) {
+	if t, ok := ctx.(tracer); ok {
+		return t.Trace()
+	}
+	return trace.ParseFromContext(ctx)
+}
+
+// KV 按 key, value 交替追加字段, 也可以直接传入 Field, []Field, *Extras
+// 缺少 value 或 key 不是字符串时, 字段名为 BadKey
+//
+//	logger.E().KV("uid", 7, "name", "xh", logger.E().Trace("t1"))
+func (e *Extras) KV(kv ...interface{}) *Extras {
+	for i := 0; i < len(kv); i++ {
+		switch v := kv[i].(type) {
+		case Field:
+			e.fields = append(e.fields, v)
+		case []Field:
+			e.fields = append(e.fields, v...)
+		case *Extras:
+			if v != nil {
+				e.fields = append(e.fields, v.fields...)
+			}
+		case string:
+			if i+1 >= len(kv) {
+				e.fields = append(e.fields, Field{Key: BadKey, Type: StringType, String: v})
+			} else {
+				i++
+				e.fields = append(e.fields, anyField(v, kv[i]))
+			}
+		default:
+			e.fields = append(e.fields, anyField(BadKey, v))
+		}
+	}
+	return e
+}
+
+func anyField(key string, value interface{}) Field {
+	switch v := value.(type) {
+	case nil:
+		return Field{Key: key, Type: StringType}
+	case string:
+		return Field{Key: key, Type: StringType, String: v}
+	case []byte:
+		return Field{Key: key, Type: ByteStringType, Bytes: v}
+	case bool:
+		return Field{Key: key, Type: BoolType, Boolean: v}
+	case int:
+		return Field{Key: key, Type: Int64Type, Integer: int64(v)}
+	case int8:
+		return Field{Key: key, Type: Int8Type, Integer: int64(v)}
+	case int16:
+		return Field{Key: key, Type: Int16Type, Integer: int64(v)}
+	case int32:
+		return Field{Key: key, Type: Int32Type, Integer: int64(v)}
+	case int64:
+		return Field{Key: key, Type: Int64Type, Integer: v}
+	case uint:
+		return Field{Key: key, Type: Uint64Type, Integer: int64(v)}
+	case uint8:
+		return Field{Key: key, Type: Uint8Type, Integer: int64(v)}
+	case uint16:
+		return Field{Key: key, Type: Uint16Type, Integer: int64(v)}
+	case uint32:
+		return Field{Key: key, Type: Uint32Type, Integer: int64(v)}
+	case uint64:
+		return Field{Key: key, Type: Uint64Type, Integer: int64(v)}
+	case float32:
+		return Field{Key: key, Type: Float32Type, Float: float64(v)}
+	case float64:
+		return Field{Key: key, Type: Float64Type, Float: v}
+	case time.Duration:
+		return Field{Key: key, Type: StringType, String: v.String()}
+	case time.Time:
+		return Field{Key: key, Type: StringType, String: v.Format(time.RFC3339Nano)}
+	case error:
+		return Field{Key: key, Type: ErrorType, Interface: v}
+	case fmt.Stringer:
+		return Field{Key: key, Type: StringerType, Interface: v}
+	}
+	return (&Extras{}).Interface(key, value).fields[0]
+}
+
+// kvFields 在 base 之后追加 kv 中的字段, 不修改 base
+func kvFields(base []Field, kv []interface{}) []Field {
+	if len(kv) == 0 {
+		return base
+	}
+	e := &Extras{fields: make([]Field, len(base), len(base)+len(kv))}
+	copy(e.fields, base)
+	return e.KV(kv...).fields
+}
+
+// ctxTrace ctx 中有链路信息时使用 ctx 中的, 否则使用 TraceId, SpanId 设置的
+func ctxTrace(ctx context.Context, traceId, spanId string) (string, string) {
+	if ctx == nil {
+		return traceId, spanId
+	}
+	if t, s := ParseTrace(ctx); t != "" {
+		return t, s
+	}
+	return traceId, spanId
+}
+
+// CallerSkipper 自行计算调用位置的 Driver, 如 zap; 包装类的 Driver 转交给被包装的 Driver
+type CallerSkipper interface {
+	// WithCallerSkip 返回调用位置多跳过 skip 层的副本
+	WithCallerSkip(skip int) Driver
+}
+
+// AddCallerSkip 返回全局 logger 的副本, 打印的调用位置多跳过 skip 层, 用于 su_logger 之类再封装一层的场景
+func AddCallerSkip(skip int) Log {
+	l := cloneLogger().(*baseLogger)
+	l.callDepth += skip
+	if s, ok := l.adapter.(CallerSkipper); ok {
+		l.adapter = s.WithCallerSkip(skip)
+	}
+	return &LocalLogger{bl: l}
+}
+
+func With(kv ...interface{}) Log {
+	return cloneLogger().With(kv...)
+}
+
+func Logw(ctx context.Context, level LogLevel, msg string, kv ...interface{}) {
+	GetLogger().Logw(ctx, level, msg, kv...)
+}
+
+func Errorw(ctx context.Context, msg string, kv ...interface{}) {
+	GetLogger().Errorw(ctx, msg, kv...)
+}
+
+func Warnw(ctx context.Context, msg string, kv ...interface{}) {
+	GetLogger().Warnw(ctx, msg, kv...)
+}
+
+func Infow(ctx context.Context, msg string, kv ...interface{}) {
+	GetLogger().Infow(ctx, msg, kv...)
+}
+
+func Debugw(ctx context.Context, msg string, kv ...interface{}) {
+	GetLogger().Debugw(ctx, msg, kv...)
+}
@@ -0,0 +1,171 @@
+package logger
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/senyu-up/toolbox/tool/config"
+	"github.com/senyu-up/toolbox/tool/trace"
+)
+
+func TestExtras_KV(t *testing.T) {
+	err := errors.New("boom")
+	tests := []struct {
+		name string
+		kv   []interface{}
+		want []Field
+	}{
+		{name: "pairs", kv: []interface{}{"uid", 7, "name", "xh", "ok", true, "cost", 1.5},
+			want: []Field{{Key: "uid", Type: Int64Type, Integer: 7}, {Key: "name", Type: StringType, String: "xh"},
+				{Key: "ok", Type: BoolType, Boolean: true}, {Key: "cost", Type: Float64Type, Float: 1.5}}},
+		{name: "error and duration", kv: []interface{}{"err", err, "d", time.Second},
+			want: []Field{{Key: "err", Type: ErrorType, Interface: err}, {Key: "d", Type: StringType, String: "1s"}}},
+		{name: "field and extras", kv: []interface{}{Field{Key: "a", Type: StringType, String: "1"}, E().String("b", "2")},
+			want: []Field{{Key: "a", Type: StringType, String: "1"}, {Key: "b", Type: StringType, String: "2"}}},
+		{name: "missing value", kv: []interface{}{"uid", 7, "dangling"},
+			want: []Field{{Key: "uid", Type: Int64Type, Integer: 7}, {Key: BadKey, Type: StringType, String: "dangling"}}},
+		{name: "non string key", kv: []interface{}{42, "uid", 7},
+			want: []Field{{Key: BadKey, Type: Int64Type, Integer: 42}, {Key: "uid", Type: Int64Type, Integer: 7}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := E().KV(tt.kv...).Fields()
+			if len(got) != len(tt.want) {
+				t.Fatalf("KV() = %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if !reflect.DeepEqual(got[i], tt.want[i]) {
+					t.Errorf("KV()[%d] = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func fieldMap(fields []Field) map[string]Field {
+	m := make(map[string]Field, len(fields))
+	for _, f := range fields {
+		m[f.Key] = f
+	}
+	return m
+}
+
+func TestLocalLogger_Infow(t *testing.T) {
+	d := &memDriver{}
+	l := NewLocalLogger("mem", d, LogOptWithCallDepth(DefaultCallDepth))
+	ctx := trace.NewContextWithRequestIdAndSpanId(context.TODO(), "req1", "span1")
+
+	l.Infow(ctx, "hello %v", "uid", 7)
+	l.With("app", "toolbox").TraceId("ignored").Warnw(ctx, "with")
+	l.Debugw(nil, "no ctx")
+
+	msgs := d.messages()
+	if len(msgs) != 3 {
+		t.Fatalf("messages = %v", msgs)
+	}
+	// msg 不做格式化, 调用位置为本文件
+	if !strings.HasSuffix(msgs[0], "hello %v") || !strings.Contains(msgs[0], "structured_test.go:") {
+		t.Errorf("message = %s", msgs[0])
+	}
+	first := fieldMap(d.extras[0])
+	if first["uid"].Integer != 7 || first["trace"].String != "req1" || first["span"].String != "span1" {
+		t.Errorf("fields = %+v", d.extras[0])
+	}
+	// ctx 中的链路优先
+	second := fieldMap(d.extras[1])
+	if second["app"].String != "toolbox" || second["trace"].String != "req1" || !strings.Contains(msgs[1], "structured_test.go:") {
+		t.Errorf("message = %s, fields = %+v", msgs[1], d.extras[1])
+	}
+	if _, ok := fieldMap(d.extras[2])["trace"]; ok {
+		t.Errorf("fields = %+v, should not have trace", d.extras[2])
+	}
+}
+
+func TestLocalLogger_Notify(t *testing.T) {
+	d, cb := &memDriver{}, &memDriver{}
+	l := NewLocalLogger("mem", d, LogOptWithCallBack(cb))
+	err := errors.New("boom")
+
+	l.Errorw(context.TODO(), "quiet")
+	l.SetErr(err).Notify().Errorw(context.TODO(), "loud", "uid", 7)
+	l.Logw(context.TODO(), LogLevel(100), "invalid level")
+
+	if msgs := cb.messages(); len(msgs) != 1 || msgs[0] != "loud" {
+		t.Fatalf("callback messages = %v", msgs)
+	}
+	fields := fieldMap(cb.extras[0])
+	if fields["uid"].Integer != 7 || fields["error"].Interface != err {
+		t.Errorf("callback fields = %+v", cb.extras[0])
+	}
+	if msgs := d.messages(); len(msgs) != 3 || !strings.Contains(msgs[2], "["+levelPrefix[LevelError]+"]") {
+		t.Errorf("messages = %v", msgs)
+	}
+}
+
+func logWithSkip(ctx context.Context) {
+	AddCallerSkip(1).Infow(ctx, "skip")
+}
+
+func TestAddCallerSkip(t *testing.T) {
+	d := &memDriver{}
+	old := logInst
+	logInst = newBaseLogger("mem", d, LogOptWithCallDepth(DefaultCallDepth))
+	defer func() { logInst = old }()
+
+	logWithSkip(context.TODO()) // 调用位置为这一行, 而不是 logWithSkip
+	msgs := d.messages()
+	if len(msgs) != 1 || !strings.Contains(msgs[0], "structured_test.go:121") {
+		t.Errorf("messages = %v", msgs)
+	}
+	if logInst.callDepth != DefaultCallDepth {
+		t.Errorf("global callDepth = %d, should not be changed", logInst.callDepth)
+	}
+}
+
+func TestAddCallerSkip_Async(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Pipe() error = %v", err)
+	}
+	defer r.Close()
+	defer w.Close()
+	stdout := os.Stdout
+	os.Stdout = w
+	z := &Zap{}
+	err = z.InitByConf(config.ZapConfig{Level: "DEBUG"})
+	os.Stdout = stdout
+	if err != nil {
+		t.Fatalf("InitByConf() error = %v", err)
+	}
+
+	a := NewAsync(z)
+	old := logInst
+	logInst = newBaseLogger(AdapterZap, a, LogOptWithCallDepth(DefaultCallDepth))
+	defer func() { logInst = old }()
+	l := AddCallerSkip(1).(*LocalLogger)
+	skipped, ok := l.bl.adapter.(*asyncCallerSkip)
+	if !ok || skipped.Async != a || skipped.driver == Driver(z) {
+		t.Fatalf("adapter = %#v, should be a copy of Async writing to a copy of Zap", l.bl.adapter)
+	}
+
+	// 调用位置与直接包装跳过 1 层的 zap 一致, 与原 Async 不同
+	lines := bufio.NewReader(r)
+	var callers []string
+	for _, d := range []Driver{NewAsync(z.WithCallerSkip(1)), skipped, NewAsync(z)} {
+		d.LogWrite(time.Now(), "async", LevelInformational, nil)
+		line, err := lines.ReadString('\n')
+		if err != nil {
+			t.Fatalf("ReadString() error = %v", err)
+		}
+		callers = append(callers, strings.Split(line, "\t")[2])
+	}
+	if callers[0] != callers[1] || callers[1] == callers[2] {
+		t.Errorf("callers = %v", callers)
+	}
+}
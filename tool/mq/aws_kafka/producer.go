@@ -198,11 +198,10 @@ func (p *Producer) PushObj(ctx context.Context, topic string, event interface{})
 	if err != nil {
 		return err
 	}
-	p.PushMsgs(ctx, []kafka.Message{kafka.Message{
+	return p.PushMsgs(ctx, []kafka.Message{kafka.Message{
 		Topic: topic,
 		Value: msg,
 	}})
-	return nil
 }
 
 // msgFilter
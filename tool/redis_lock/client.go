@@ -0,0 +1,346 @@
+package redis_lock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	mrand "math/rand"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/senyu-up/toolbox/tool/config"
+)
+
+// 未配置时的默认值
+const (
+	DefaultPrefix      = "lock:"
+	DefaultExpire      = 30 * time.Second
+	DefaultMinBackoff  = 10 * time.Millisecond
+	DefaultMaxBackoff  = 500 * time.Millisecond
+	DefaultDriftFactor = 0.01 // 多节点模式下的时钟漂移系数, 漂移时间为 过期时间*系数+2ms
+)
+
+var (
+	// ErrNotObtained TryLock 没有获取到锁
+	ErrNotObtained = errors.New("redis lock: not obtained")
+	// ErrNotHeld 解锁时锁已过期或被其他持有者获取
+	ErrNotHeld = errors.New("redis lock: not held")
+)
+
+// Client 基于注入的 redis 客户端创建锁, 同一个 Client 可以创建任意多个锁
+// 单节点与多节点(Redlock)模式的 API 相同, 通过 NewClientByConf 可以由配置切换
+//
+//	locks := redis_lock.NewClient(redisCli, redis_lock.OptWithExpire(10*time.Second))
+//	m := locks.Mutex("order:1")
+//	if err := m.Lock(ctx); err != nil {
+//		return err
+//	}
+//	defer m.Unlock()
+type Client struct {
+	nodes       []redis.UniversalClient
+	quorum      int     // 多数节点数, N/2+1
+	driftFactor float64 // 时钟漂移系数
+	prefix      string
+	expire      time.Duration // 锁的过期时间, 持有期间由 watchdog 每 1/3 过期时间续期
+	watchdog    bool
+	minBackoff  time.Duration // Lock 等待时的重试间隔, 从 minBackoff 开始翻倍到 maxBackoff
+	maxBackoff  time.Duration
+	now         func() time.Time
+}
+
+// NewClient 单节点模式
+func NewClient(rdb redis.UniversalClient, opts ...Option) *Client {
+	return NewRedlock([]redis.UniversalClient{rdb}, opts...)
+}
+
+// NewRedlock 多节点(Redlock)模式, nodes 为 N 个相互独立的 redis(不是同一集群的节点),
+// 在多数节点上获取成功, 且扣除耗时与时钟漂移后仍在有效期内时才算获取到锁, 少数节点故障时锁仍可用
+func NewRedlock(nodes []redis.UniversalClient, opts ...Option) *Client {
+	c := &Client{
+		nodes:       nodes,
+		quorum:      len(nodes)/2 + 1,
+		driftFactor: DefaultDriftFactor,
+		prefix:      DefaultPrefix,
+		expire:      DefaultExpire,
+		watchdog:    true,
+		minBackoff:  DefaultMinBackoff,
+		maxBackoff:  DefaultMaxBackoff,
+		now:         time.Now,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Mutex 互斥锁, owner 为持有者的 token, 相同 token 可重入, 不传时随机生成
+func (c *Client) Mutex(name string, owner ...string) *Mutex {
+	return newMutex(c, name, false, owner)
+}
+
+// FairMutex 公平锁, 等待者按请求的先后顺序获取锁
+func (c *Client) FairMutex(name string, owner ...string) *Mutex {
+	return newMutex(c, name, true, owner)
+}
+
+// RWMutex 读写锁, 读锁共享, 写锁互斥, 有写者等待时新的读者需要等待写者
+func (c *Client) RWMutex(name string, owner ...string) *RWMutex {
+	return newRWMutex(c, name, owner)
+}
+
+// key 锁相关的 key 使用相同的 hash tag, 保证集群模式下在同一个 slot
+func (c *Client) key(name, suffix string) string {
+	return c.prefix + "{" + name + "}" + suffix
+}
+
+func (c *Client) ttl() int64 {
+	return int64(c.expire / time.Millisecond)
+}
+
+// waitTTL 公平锁等待者、写锁等待标记的过期时间, 等待者每次重试时续期, 放弃等待后不会阻塞其他等待者
+func (c *Client) waitTTL() int64 {
+	return int64(4*c.maxBackoff/time.Millisecond) + 1000
+}
+
+// NewClientByConf 按配置创建, 一个配置为单节点模式, 多个配置为多节点(Redlock)模式
+func NewClientByConf(confs []*config.RedisConfig, opts ...Option) (*Client, error) {
+	if len(confs) == 0 {
+		return nil, errors.New("redis lock: redis config is required")
+	}
+	nodes := make([]redis.UniversalClient, len(confs))
+	for i, conf := range confs {
+		if len(conf.Addrs) == 0 {
+			return nil, fmt.Errorf("redis lock: redis config %d addrs is required", i)
+		}
+		nodes[i] = newRedisByConf(conf)
+	}
+	return NewRedlock(nodes, opts...), nil
+}
+
+// reply 一个节点上脚本的执行结果
+type reply struct {
+	res []int64
+	err error
+}
+
+// evalAll 在所有节点上并发执行脚本
+func (c *Client) evalAll(script *redis.Script, keys []string, args ...interface{}) []reply {
+	replies := make([]reply, len(c.nodes))
+	if len(c.nodes) == 1 {
+		replies[0].res, replies[0].err = eval(c.nodes[0], script, keys, args...)
+		return replies
+	}
+	var wg sync.WaitGroup
+	for i, node := range c.nodes {
+		wg.Add(1)
+		go func(i int, node redis.UniversalClient) {
+			defer wg.Done()
+			replies[i].res, replies[i].err = eval(node, script, keys, args...)
+		}(i, node)
+	}
+	wg.Wait()
+	return replies
+}
+
+func eval(rdb redis.UniversalClient, script *redis.Script, keys []string, args ...interface{}) ([]int64, error) {
+	v, err := script.Run(rdb, keys, args...).Result()
+	if err != nil {
+		return nil, err
+	}
+	return toInts(v)
+}
+
+// quorumErr 出错的节点过多, 不可能达到多数时返回第一个错误
+func (c *Client) quorumErr(replies []reply) error {
+	var failed int
+	var first error
+	for _, r := range replies {
+		if r.err != nil {
+			failed++
+			if first == nil {
+				first = r.err
+			}
+		}
+	}
+	if failed > len(c.nodes)-c.quorum {
+		return first
+	}
+	return nil
+}
+
+// acquire 在所有节点上执行返回 {1, fence} 或 {0, 剩余毫秒数} 的获取脚本
+// 多数节点获取成功, 且扣除耗时与时钟漂移后仍在有效期内时成功, 返回各节点中最大的 fence 与有效期截止时间;
+// 失败时执行 undo 释放已获取的节点, 返回锁的剩余时间用于等待
+// fenced 为 true 时 keys[0], keys[1] 为锁 hash 与 fencing 计数器, args[0] 为 owner, 多节点模式下同步 fence, 见 syncFence
+func (c *Client) acquire(script *redis.Script, keys []string, args []interface{}, fenced bool, undo func()) (ok bool, fence int64, until time.Time, ttl time.Duration, err error) {
+	start := time.Now()
+	replies := c.evalAll(script, keys, args...)
+	var acquired int
+	for _, r := range replies {
+		if r.err != nil {
+			continue
+		}
+		if r.res[0] == 1 {
+			acquired++
+			if r.res[1] > fence {
+				fence = r.res[1]
+			}
+		} else if wait := time.Duration(r.res[1]) * time.Millisecond; wait > 0 && (ttl == 0 || wait < ttl) {
+			ttl = wait
+		}
+	}
+	until = start.Add(c.validity())
+	if acquired >= c.quorum && time.Now().Before(until) {
+		if !fenced || len(c.nodes) == 1 {
+			return true, fence, until, 0, nil
+		}
+		synced, err := c.syncFence(keys[:2], args[0], fence)
+		if synced && time.Now().Before(until) {
+			return true, fence, until, 0, nil
+		}
+		if undo != nil {
+			undo()
+		}
+		return false, 0, time.Time{}, ttl, err
+	}
+	if acquired > 0 && undo != nil {
+		undo()
+	}
+	return false, 0, time.Time{}, ttl, c.quorumErr(replies)
+}
+
+// syncFence 多节点模式下各节点的 fencing 计数器相互独立, 获取失败时少数节点上的计数器也会递增,
+// 直接取最大值时后续只在其他多数节点上获取的持有者可能拿到更小的 fence;
+// 获取成功后将多数节点的计数器与锁的 fence 提升到本次的 fence, 之后的多数节点与其至少有一个交集,
+// 从而保证 fence 单调递增(要求节点重启后不丢失数据)
+func (c *Client) syncFence(keys []string, owner interface{}, fence int64) (bool, error) {
+	replies := c.evalAll(fenceScript, keys, owner, fence)
+	var synced int
+	for _, r := range replies {
+		if r.err == nil && r.res[0] == 1 {
+			synced++
+		}
+	}
+	if synced >= c.quorum {
+		return true, nil
+	}
+	return false, c.quorumErr(replies)
+}
+
+// validity 从开始获取时算起的有效期, 多节点模式下扣除时钟漂移, 超过有效期才获取到多数节点时视为失败
+func (c *Client) validity() time.Duration {
+	if len(c.nodes) == 1 {
+		return c.expire
+	}
+	drift := time.Duration(float64(c.expire)*c.driftFactor) + 2*time.Millisecond
+	return c.expire - drift
+}
+
+// release 在所有节点上执行返回剩余计数的释放脚本, 多数节点释放成功时返回最大的剩余计数, 否则返回 -1
+func (c *Client) release(script *redis.Script, keys []string, args ...interface{}) (int64, error) {
+	replies := c.evalAll(script, keys, args...)
+	var released int
+	remain := int64(-1)
+	for _, r := range replies {
+		if r.err == nil && r.res[0] >= 0 {
+			released++
+			if r.res[0] > remain {
+				remain = r.res[0]
+			}
+		}
+	}
+	if released >= c.quorum {
+		return remain, nil
+	}
+	if err := c.quorumErr(replies); err != nil {
+		return 0, err
+	}
+	return -1, nil
+}
+
+// refresh 在所有节点上执行返回 1 表示仍持有的续期脚本, 多数节点续期成功时返回新的有效期截止时间
+func (c *Client) refresh(script *redis.Script, keys []string, args ...interface{}) (bool, time.Time, error) {
+	start := time.Now()
+	replies := c.evalAll(script, keys, args...)
+	var refreshed int
+	for _, r := range replies {
+		if r.err == nil && r.res[0] == 1 {
+			refreshed++
+		}
+	}
+	until := start.Add(c.validity())
+	if refreshed >= c.quorum && time.Now().Before(until) {
+		return true, until, nil
+	}
+	return false, time.Time{}, c.quorumErr(replies)
+}
+
+func toInts(v interface{}) ([]int64, error) {
+	switch v := v.(type) {
+	case int64:
+		return []int64{v}, nil
+	case []interface{}:
+		nums := make([]int64, len(v))
+		for i, value := range v {
+			n, ok := value.(int64)
+			if !ok {
+				return nil, fmt.Errorf("redis lock: unexpected script result %v", v)
+			}
+			nums[i] = n
+		}
+		return nums, nil
+	}
+	return nil, fmt.Errorf("redis lock: unexpected script result %v", v)
+}
+
+// wait 重试 try 直到获取锁或 ctx 结束, 重试间隔指数退避并加入随机抖动, 不超过锁的剩余时间
+// ctx 结束时调用 cancel, 如从公平锁的队列中移除
+func (c *Client) wait(ctx context.Context, try func() (ok bool, ttl time.Duration, err error), cancel func()) error {
+	backoff := c.minBackoff
+	for {
+		ok, ttl, err := try()
+		if err != nil || ok {
+			return err
+		}
+		wait := backoff/2 + time.Duration(mrand.Int63n(int64(backoff/2)+1))
+		if ttl > 0 && ttl < wait {
+			wait = ttl
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			if cancel != nil {
+				cancel()
+			}
+			return ctx.Err()
+		case <-timer.C:
+		}
+		if backoff *= 2; backoff > c.maxBackoff {
+			backoff = c.maxBackoff
+		}
+	}
+}
+
+// newToken 随机的持有者 token
+func newToken() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d-%d", time.Now().UnixNano(), mrand.Int63())
+	}
+	return hex.EncodeToString(b)
+}
+
+func ownerToken(owner []string) string {
+	if len(owner) > 0 && owner[0] != "" {
+		return owner[0]
+	}
+	return newToken()
+}
+
+func ms(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
@@ -0,0 +1,390 @@
+package redis_lock
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
+	"github.com/senyu-up/toolbox/tool/config"
+)
+
+func newTestClient(t *testing.T, opts ...Option) (*Client, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	opts = append([]Option{OptWithBackoff(time.Millisecond, 10*time.Millisecond)}, opts...)
+	return NewClient(rdb, opts...), mr
+}
+
+func TestMutex(t *testing.T) {
+	c, mr := newTestClient(t, OptWithWatchdog(false))
+	m := c.Mutex("order")
+	other := c.Mutex("order")
+
+	if ok, err := m.TryLock(); !ok || err != nil || m.Fence() != 1 {
+		t.Fatalf("TryLock() = %v, %v, fence = %d", ok, err, m.Fence())
+	}
+	// 同一持有者可重入, fence 不变
+	if ok, _ := m.TryLock(); !ok || m.Fence() != 1 {
+		t.Fatalf("reentrant TryLock() = %v, fence = %d", ok, m.Fence())
+	}
+	if ok, _ := other.TryLock(); ok {
+		t.Fatal("other owner should not obtain the lock")
+	}
+	// 相同 token 的其他对象也可重入
+	if ok, _ := c.Mutex("order", m.Owner()).TryLock(); !ok {
+		t.Fatal("same owner token should be reentrant")
+	}
+	if mr.HGet("lock:{order}", "count") != "3" {
+		t.Errorf("count = %s", mr.HGet("lock:{order}", "count"))
+	}
+	for i := 0; i < 3; i++ {
+		if err := m.Unlock(); err != nil {
+			t.Fatalf("Unlock() error = %v", err)
+		}
+	}
+	if mr.Exists("lock:{order}") {
+		t.Fatal("lock should be deleted")
+	}
+	if err := m.Unlock(); err != ErrNotHeld {
+		t.Errorf("Unlock() error = %v, want ErrNotHeld", err)
+	}
+
+	// fencing token 单调递增
+	if ok, _ := other.TryLock(); !ok || other.Fence() != 2 {
+		t.Fatalf("TryLock() = %v, fence = %d", ok, other.Fence())
+	}
+	// 过期后其他持有者可以获取, 旧持有者解锁失败
+	mr.FastForward(DefaultExpire)
+	if ok, _ := m.TryLock(); !ok || m.Fence() != 3 {
+		t.Fatalf("TryLock() after expire = %v, fence = %d", ok, m.Fence())
+	}
+	if err := other.Unlock(); err != ErrNotHeld {
+		t.Errorf("Unlock() error = %v, want ErrNotHeld", err)
+	}
+}
+
+func TestMutex_Lock(t *testing.T) {
+	c, _ := newTestClient(t)
+	m, other := c.Mutex("job"), c.Mutex("job")
+	if err := m.Lock(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.TODO(), 30*time.Millisecond)
+	defer cancel()
+	if err := other.Lock(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Lock() error = %v, want DeadlineExceeded", err)
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		m.Unlock()
+	}()
+	if err := other.Lock(context.TODO()); err != nil || other.Fence() != 2 {
+		t.Fatalf("Lock() error = %v, fence = %d", err, other.Fence())
+	}
+	if err := other.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMutex_Watchdog(t *testing.T) {
+	c, mr := newTestClient(t, OptWithExpire(300*time.Millisecond))
+	m := c.Mutex("watchdog")
+	if err := m.Lock(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	mr.FastForward(200 * time.Millisecond)
+	time.Sleep(150 * time.Millisecond)
+	if ttl := mr.TTL("lock:{watchdog}"); ttl <= 200*time.Millisecond {
+		t.Errorf("ttl = %s, should be refreshed", ttl)
+	}
+	if err := m.Unlock(); err != nil || m.stop != nil {
+		t.Errorf("Unlock() error = %v, watchdog should stop", err)
+	}
+}
+
+func TestFairMutex(t *testing.T) {
+	c, mr := newTestClient(t)
+	holder := c.FairMutex("fair")
+	if err := holder.Lock(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		lock  sync.Mutex
+		order []string
+		wg    sync.WaitGroup
+	)
+	waitQueue := func(n int) {
+		for i := 0; i < 100; i++ {
+			if list, _ := mr.List("lock:{fair}:queue"); len(list) == n {
+				return
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		t.Fatalf("queue length should be %d", n)
+	}
+	for i, name := range []string{"a", "b", "c"} {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			m := c.FairMutex("fair")
+			if err := m.Lock(context.TODO()); err != nil {
+				t.Error(err)
+				return
+			}
+			lock.Lock()
+			order = append(order, name)
+			lock.Unlock()
+			time.Sleep(5 * time.Millisecond)
+			m.Unlock()
+		}(name)
+		waitQueue(i + 1)
+	}
+
+	// 放弃等待的等待者从队列中移除
+	ctx, cancel := context.WithTimeout(context.TODO(), 20*time.Millisecond)
+	defer cancel()
+	if err := c.FairMutex("fair").Lock(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Lock() error = %v", err)
+	}
+	waitQueue(3)
+
+	if err := holder.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+	// 有等待者时 TryLock 不插队
+	if ok, _ := c.FairMutex("fair").TryLock(); ok {
+		t.Error("TryLock() should not jump the queue")
+	}
+	wg.Wait()
+	if len(order) != 3 || order[0] != "a" || order[1] != "b" || order[2] != "c" {
+		t.Errorf("order = %v, want [a b c]", order)
+	}
+}
+
+func TestRWMutex(t *testing.T) {
+	c, mr := newTestClient(t, OptWithWatchdog(false))
+	r1, r2, w := c.RWMutex("doc"), c.RWMutex("doc"), c.RWMutex("doc")
+
+	if ok, _ := r1.TryRLock(); !ok {
+		t.Fatal("r1 should obtain read lock")
+	}
+	if ok, _ := r2.TryRLock(); !ok {
+		t.Fatal("r2 should obtain read lock")
+	}
+	if ok, _ := w.TryLock(); ok {
+		t.Fatal("writer should wait for readers")
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- w.Lock(context.TODO()) }()
+	for i := 0; i < 100 && !mr.Exists("lock:{doc}:wwait"); i++ {
+		time.Sleep(5 * time.Millisecond)
+	}
+	// 有写者等待时新的读者需要等待, 已持有读锁的可重入
+	if ok, _ := c.RWMutex("doc").TryRLock(); ok {
+		t.Error("new reader should wait for the waiting writer")
+	}
+	if ok, _ := r1.TryRLock(); !ok {
+		t.Error("reader should be reentrant")
+	}
+	r1.RUnlock()
+	r1.RUnlock()
+	r2.RUnlock()
+	if err := <-done; err != nil || w.Fence() != 1 {
+		t.Fatalf("Lock() error = %v, fence = %d", err, w.Fence())
+	}
+	if ok, _ := r1.TryRLock(); ok {
+		t.Fatal("reader should not obtain read lock while writing")
+	}
+	// 写锁降级
+	if ok, _ := w.TryRLock(); !ok || w.Fence() != 1 {
+		t.Fatal("writer should obtain read lock")
+	}
+	if err := w.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := r1.TryRLock(); !ok || r1.Fence() != 1 {
+		t.Fatalf("reader should obtain read lock, fence = %d", r1.Fence())
+	}
+	w.RUnlock()
+	r1.RUnlock()
+	if err := r1.RUnlock(); err != ErrNotHeld {
+		t.Errorf("RUnlock() error = %v, want ErrNotHeld", err)
+	}
+
+	// 过期的读者不再阻塞写者
+	if ok, _ := r2.TryRLock(); !ok {
+		t.Fatal("r2 should obtain read lock")
+	}
+	now := time.Now().Add(DefaultExpire + time.Second)
+	c.now = func() time.Time { return now }
+	if ok, _ := w.TryLock(); !ok || w.Fence() != 2 {
+		t.Errorf("writer should obtain lock after readers expired, fence = %d", w.Fence())
+	}
+}
+
+func newTestRedlock(t *testing.T, n int, opts ...Option) (*Client, []*miniredis.Miniredis) {
+	servers := make([]*miniredis.Miniredis, n)
+	nodes := make([]redis.UniversalClient, n)
+	for i := range servers {
+		servers[i] = miniredis.RunT(t)
+		rdb := redis.NewClient(&redis.Options{Addr: servers[i].Addr()})
+		t.Cleanup(func() { rdb.Close() })
+		nodes[i] = rdb
+	}
+	opts = append([]Option{OptWithBackoff(time.Millisecond, 10*time.Millisecond), OptWithWatchdog(false)}, opts...)
+	return NewRedlock(nodes, opts...), servers
+}
+
+func TestRedlock(t *testing.T) {
+	c, servers := newTestRedlock(t, 3, OptWithExpire(time.Second))
+	m, other := c.Mutex("order"), c.Mutex("order")
+
+	start := time.Now()
+	if ok, err := m.TryLock(); !ok || err != nil || m.Fence() != 1 {
+		t.Fatalf("TryLock() = %v, %v, fence = %d", ok, err, m.Fence())
+	}
+	// 有效期从开始获取时算起, 扣除时钟漂移 1s*0.01+2ms
+	validity := 988 * time.Millisecond
+	if until := m.Until(); until.Before(start.Add(validity)) || until.After(time.Now().Add(validity)) {
+		t.Errorf("Until() = %s, start = %s", until, start)
+	}
+	for i, mr := range servers {
+		if mr.HGet("lock:{order}", "owner") != m.Owner() {
+			t.Errorf("node %d should hold the lock", i)
+		}
+	}
+	if ok, _ := other.TryLock(); ok {
+		t.Fatal("other owner should not obtain the lock")
+	}
+	// 在所有节点上释放
+	if err := m.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+	for i, mr := range servers {
+		if mr.Exists("lock:{order}") {
+			t.Errorf("node %d lock should be deleted", i)
+		}
+	}
+
+	// 其他持有者占据多数节点时获取失败, 已获取的少数节点被释放
+	servers[0].HSet("lock:{order}", "owner", "x")
+	servers[1].HSet("lock:{order}", "owner", "x")
+	if ok, err := m.TryLock(); ok || err != nil {
+		t.Fatalf("TryLock() = %v, %v, want false", ok, err)
+	}
+	if servers[2].Exists("lock:{order}") {
+		t.Error("minority node should be released")
+	}
+	servers[0].Del("lock:{order}")
+	servers[1].Del("lock:{order}")
+
+	// fence 取各节点中的最大值
+	servers[2].Set("lock:{order}:fence", "5")
+	if ok, _ := m.TryLock(); !ok || m.Fence() != 6 {
+		t.Fatalf("TryLock() = %v, fence = %d, want 6", ok, m.Fence())
+	}
+	m.Unlock()
+
+	// 少数节点故障时仍可用
+	servers[2].Close()
+	if ok, err := m.TryLock(); !ok || err != nil {
+		t.Fatalf("TryLock() with one node down = %v, %v", ok, err)
+	}
+	if err := m.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+	// 多数节点故障时返回错误
+	servers[1].Close()
+	if ok, err := m.TryLock(); ok || err == nil {
+		t.Fatalf("TryLock() with two nodes down = %v, %v, want error", ok, err)
+	}
+	if servers[0].Exists("lock:{order}") {
+		t.Error("lock should be released after failure")
+	}
+}
+
+func TestRedlock_Fence(t *testing.T) {
+	c, servers := newTestRedlock(t, 3)
+	m := c.Mutex("order")
+	// 其他持有者占据多数节点时获取失败, 少数节点上的计数器仍会递增
+	servers[0].HSet("lock:{order}", "owner", "x")
+	servers[1].HSet("lock:{order}", "owner", "x")
+	for i := 0; i < 5; i++ {
+		if ok, _ := m.TryLock(); ok {
+			t.Fatal("TryLock() should fail")
+		}
+	}
+	servers[0].Del("lock:{order}")
+	servers[1].Del("lock:{order}")
+
+	y := c.Mutex("order")
+	if ok, _ := y.TryLock(); !ok || y.Fence() != 6 {
+		t.Fatalf("TryLock() = %v, fence = %d, want 6", ok, y.Fence())
+	}
+	// 重入时 fence 不变
+	if ok, _ := y.TryLock(); !ok || y.Fence() != 6 {
+		t.Fatalf("reentrant TryLock() = %v, fence = %d, want 6", ok, y.Fence())
+	}
+	y.Unlock()
+	y.Unlock()
+
+	// 计数器最大的节点故障后, 其他多数节点上获取的 fence 仍然更大
+	servers[2].Close()
+	z := c.Mutex("order")
+	if ok, err := z.TryLock(); !ok || err != nil || z.Fence() <= 6 {
+		t.Fatalf("TryLock() = %v, %v, fence = %d, want > 6", ok, err, z.Fence())
+	}
+}
+
+func TestRedlock_RWMutex(t *testing.T) {
+	c, servers := newTestRedlock(t, 3)
+	r, w := c.RWMutex("doc"), c.RWMutex("doc")
+	if ok, _ := r.TryRLock(); !ok {
+		t.Fatal("reader should obtain read lock")
+	}
+	if ok, _ := w.TryLock(); ok {
+		t.Fatal("writer should wait for readers")
+	}
+	if err := r.RUnlock(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Lock(context.TODO()); err != nil || w.Fence() != 1 {
+		t.Fatalf("Lock() error = %v, fence = %d", err, w.Fence())
+	}
+	for i, mr := range servers {
+		if mr.HGet("lock:{doc}", "owner") != w.Owner() {
+			t.Errorf("node %d should hold the write lock", i)
+		}
+	}
+	if err := w.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewClientByConf(t *testing.T) {
+	if _, err := NewClientByConf(nil); err == nil {
+		t.Error("NewClientByConf(nil) should return error")
+	}
+	var confs []*config.RedisConfig
+	for i := 0; i < 3; i++ {
+		confs = append(confs, &config.RedisConfig{Addrs: []string{miniredis.RunT(t).Addr()}})
+	}
+	single, err := NewClientByConf(confs[:1])
+	if err != nil || len(single.nodes) != 1 || single.quorum != 1 || single.validity() != DefaultExpire {
+		t.Fatalf("single node client = %+v, %v", single, err)
+	}
+	multi, err := NewClientByConf(confs)
+	if err != nil || len(multi.nodes) != 3 || multi.quorum != 2 {
+		t.Fatalf("redlock client = %+v, %v", multi, err)
+	}
+	if ok, err := multi.Mutex("conf").TryLock(); !ok || err != nil {
+		t.Errorf("TryLock() = %v, %v", ok, err)
+	}
+}
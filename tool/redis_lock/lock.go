@@ -12,6 +12,7 @@ import (
 )
 
 // DistributeLockRedis 基于redis的分布式可重入锁，自动续租
+// 新代码建议使用 NewClient, 支持阻塞等待、公平锁、fencing token、读写锁
 type DistributeLockRedis struct {
 	key       string                // 锁的key
 	value     interface{}           // 锁设置的值
@@ -0,0 +1,266 @@
+package redis_lock
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/senyu-up/toolbox/tool/logger"
+)
+
+// 公平锁获取时的模式
+const (
+	modeUnfair   = 0
+	modeFairTry  = 1 // 轮到自己才获取, 不进入队列
+	modeFairWait = 2 // 没有轮到自己时进入队列等待
+)
+
+// acquireScript 获取锁, 同一 owner 重入时计数加 1, 首次获取时生成递增的 fencing token
+// KEYS: 锁 hash{owner, count, fence}, fencing 计数器, 等待队列 list, 等待者过期时间 zset
+// ARGV: owner, 过期时间(毫秒), 模式, 当前毫秒时间戳, 等待者过期时间(毫秒)
+// 返回 {1, fence} 或 {0, 锁的剩余毫秒数}
+var acquireScript = redis.NewScript(`
+local owner = ARGV[1]
+local ttl = tonumber(ARGV[2])
+local mode = tonumber(ARGV[3])
+local now = tonumber(ARGV[4])
+local waitTTL = tonumber(ARGV[5])
+
+if mode > 0 then
+	-- 清理队首已放弃等待的等待者
+	while true do
+		local head = redis.call("LINDEX", KEYS[3], 0)
+		if head == false then
+			break
+		end
+		local expireAt = tonumber(redis.call("ZSCORE", KEYS[4], head))
+		if expireAt ~= nil and expireAt >= now then
+			break
+		end
+		redis.call("LPOP", KEYS[3])
+		redis.call("ZREM", KEYS[4], head)
+	end
+end
+
+local holder = redis.call("HGET", KEYS[1], "owner")
+if holder == owner then
+	redis.call("HINCRBY", KEYS[1], "count", 1)
+	redis.call("PEXPIRE", KEYS[1], ttl)
+	return {1, tonumber(redis.call("HGET", KEYS[1], "fence"))}
+end
+if holder == false then
+	local head = false
+	if mode > 0 then
+		head = redis.call("LINDEX", KEYS[3], 0)
+	end
+	if head == false or head == owner then
+		if head == owner then
+			redis.call("LPOP", KEYS[3])
+			redis.call("ZREM", KEYS[4], owner)
+		end
+		local fence = redis.call("INCR", KEYS[2])
+		redis.call("HMSET", KEYS[1], "owner", owner, "count", 1, "fence", fence)
+		redis.call("PEXPIRE", KEYS[1], ttl)
+		return {1, fence}
+	end
+end
+if mode == 2 then
+	if redis.call("ZSCORE", KEYS[4], owner) == false then
+		redis.call("RPUSH", KEYS[3], owner)
+	end
+	redis.call("ZADD", KEYS[4], now + waitTTL, owner)
+	redis.call("PEXPIRE", KEYS[3], ttl + waitTTL)
+	redis.call("PEXPIRE", KEYS[4], ttl + waitTTL)
+end
+return {0, redis.call("PTTL", KEYS[1])}
+`)
+
+// releaseScript 释放锁, 重入计数减到 0 时删除; 返回剩余的重入计数, -1 表示没有持有锁
+// KEYS: 锁 hash; ARGV: owner, 过期时间(毫秒)
+var releaseScript = redis.NewScript(`
+if redis.call("HGET", KEYS[1], "owner") ~= ARGV[1] then
+	return -1
+end
+local count = redis.call("HINCRBY", KEYS[1], "count", -1)
+if count > 0 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+	return count
+end
+redis.call("DEL", KEYS[1])
+return 0
+`)
+
+// refreshScript 续期, 返回 1 表示仍持有锁
+// KEYS: 锁 hash; ARGV: owner, 过期时间(毫秒)
+var refreshScript = redis.NewScript(`
+if redis.call("HGET", KEYS[1], "owner") ~= ARGV[1] then
+	return 0
+end
+redis.call("PEXPIRE", KEYS[1], ARGV[2])
+return 1
+`)
+
+// fenceScript 多节点模式下将 fencing 计数器与锁的 fence 提升到各节点中最大的 fence, 返回 1 表示仍持有锁
+// KEYS: 锁 hash, fencing 计数器; ARGV: owner, fence
+var fenceScript = redis.NewScript(`
+if redis.call("HGET", KEYS[1], "owner") ~= ARGV[1] then
+	return 0
+end
+local fence = tonumber(ARGV[2])
+if tonumber(redis.call("GET", KEYS[2]) or "0") < fence then
+	redis.call("SET", KEYS[2], fence)
+end
+redis.call("HSET", KEYS[1], "fence", fence)
+return 1
+`)
+
+// dequeueScript 放弃等待, 从公平锁的队列中移除
+// KEYS: 等待队列 list, 等待者过期时间 zset; ARGV: owner
+var dequeueScript = redis.NewScript(`
+redis.call("LREM", KEYS[1], 0, ARGV[1])
+redis.call("ZREM", KEYS[2], ARGV[1])
+return 1
+`)
+
+// Mutex 分布式互斥锁, 同一个 Mutex 对象视为同一个持有者, 可重入;
+// 多个 goroutine 之间需要互斥时应各自创建 Mutex
+type Mutex struct {
+	c     *Client
+	name  string
+	owner string
+	fair  bool
+
+	lock  sync.Mutex
+	held  int       // 本对象的重入次数
+	fence int64     // 最近一次获取锁的 fencing token
+	until time.Time // 锁的有效期截止时间
+	stop  chan struct{}
+}
+
+func newMutex(c *Client, name string, fair bool, owner []string) *Mutex {
+	return &Mutex{c: c, name: name, owner: ownerToken(owner), fair: fair}
+}
+
+func (m *Mutex) Name() string {
+	return m.name
+}
+
+// Owner 持有者的 token, 其他进程使用相同的 token 创建锁时可重入
+func (m *Mutex) Owner() string {
+	return m.owner
+}
+
+// Fence 最近一次获取锁的 fencing token, 每次获取锁(非重入)时递增,
+// 写入下游时带上该值, 下游拒绝比已见过的更小的值, 避免锁过期后旧持有者的写入;
+// 多节点模式下要求节点重启后不丢失数据(开启 AOF), 否则不保证递增
+func (m *Mutex) Fence() int64 {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.fence
+}
+
+// Until 锁的有效期截止时间, 从开始获取时算起, 多节点模式下扣除了时钟漂移, watchdog 续期后延长;
+// 超过该时间锁可能已被其他持有者获取
+func (m *Mutex) Until() time.Time {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.until
+}
+
+func (m *Mutex) keys() []string {
+	return []string{m.c.key(m.name, ""), m.c.key(m.name, ":fence"), m.c.key(m.name, ":queue"), m.c.key(m.name, ":timeout")}
+}
+
+func (m *Mutex) try(mode int) (bool, time.Duration, error) {
+	keys := m.keys()
+	ok, fence, until, ttl, err := m.c.acquire(acquireScript, keys,
+		[]interface{}{m.owner, m.c.ttl(), mode, ms(m.c.now()), m.c.waitTTL()}, true,
+		func() { m.c.evalAll(releaseScript, keys[:1], m.owner, m.c.ttl()) })
+	if !ok {
+		return false, ttl, err
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.held++
+	m.fence, m.until = fence, until
+	if m.held == 1 && m.c.watchdog {
+		m.stop = m.c.startWatchdog(m.name, func() (bool, error) {
+			ok, until, err := m.c.refresh(refreshScript, keys[:1], m.owner, m.c.ttl())
+			if ok {
+				m.lock.Lock()
+				m.until = until
+				m.lock.Unlock()
+			}
+			return ok, err
+		})
+	}
+	return true, 0, nil
+}
+
+// TryLock 尝试获取锁, 不等待; 公平锁有其他等待者时不获取
+func (m *Mutex) TryLock() (bool, error) {
+	mode := modeUnfair
+	if m.fair {
+		mode = modeFairTry
+	}
+	ok, _, err := m.try(mode)
+	return ok, err
+}
+
+// Lock 获取锁, 获取不到时等待直到 ctx 结束
+func (m *Mutex) Lock(ctx context.Context) error {
+	if !m.fair {
+		return m.c.wait(ctx, func() (bool, time.Duration, error) { return m.try(modeUnfair) }, nil)
+	}
+	return m.c.wait(ctx, func() (bool, time.Duration, error) {
+		return m.try(modeFairWait)
+	}, func() {
+		for _, r := range m.c.evalAll(dequeueScript, m.keys()[2:], m.owner) {
+			if r.err != nil {
+				logger.GetLogger().Warnw(context.Background(), "redis lock dequeue failed", "name", m.name, "error", r.err)
+			}
+		}
+	})
+}
+
+// Unlock 释放 1 次, 重入计数减到 0 时释放锁; 锁已过期或被其他持有者获取时返回 ErrNotHeld
+func (m *Mutex) Unlock() error {
+	remain, err := m.c.release(releaseScript, m.keys()[:1], m.owner, m.c.ttl())
+	if err != nil {
+		return err
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.held, m.stop = release(m.held, m.stop, remain)
+	if remain < 0 {
+		return ErrNotHeld
+	}
+	return nil
+}
+
+// startWatchdog 每 1/3 过期时间续期一次, 关闭返回的 chan 时停止; 续期发现锁已丢失时停止
+func (c *Client) startWatchdog(name string, refresh func() (bool, error)) chan struct{} {
+	stop := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(c.expire / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				ok, err := refresh()
+				if err != nil {
+					logger.GetLogger().Warnw(context.Background(), "redis lock refresh failed", "name", name, "error", err)
+					continue
+				}
+				if !ok {
+					logger.GetLogger().Warnw(context.Background(), "redis lock lost", "name", name)
+					return
+				}
+			}
+		}
+	}()
+	return stop
+}
@@ -0,0 +1,46 @@
+package redis_lock
+
+import "time"
+
+type Option func(*Client)
+
+// OptWithPrefix key 前缀, 默认 lock:
+func OptWithPrefix(prefix string) Option {
+	return func(c *Client) {
+		c.prefix = prefix
+	}
+}
+
+// OptWithExpire 锁的过期时间, 默认 30 秒
+func OptWithExpire(expire time.Duration) Option {
+	return func(c *Client) {
+		if expire > 0 {
+			c.expire = expire
+		}
+	}
+}
+
+// OptWithWatchdog 持有期间是否自动续期, 默认开启; 关闭后锁在过期时间后自动释放
+func OptWithWatchdog(on bool) Option {
+	return func(c *Client) {
+		c.watchdog = on
+	}
+}
+
+// OptWithBackoff Lock 等待时重试间隔的范围, 默认 10ms ~ 500ms
+func OptWithBackoff(min, max time.Duration) Option {
+	return func(c *Client) {
+		if min > 0 && max >= min {
+			c.minBackoff, c.maxBackoff = min, max
+		}
+	}
+}
+
+// OptWithDriftFactor 多节点模式下的时钟漂移系数, 默认 0.01, 有效期为 过期时间-(过期时间*系数+2ms)
+func OptWithDriftFactor(factor float64) Option {
+	return func(c *Client) {
+		if factor >= 0 && factor < 1 {
+			c.driftFactor = factor
+		}
+	}
+}
@@ -6,23 +6,26 @@ import "github.com/go-redis/redis"
 var Redis redis.UniversalClient
 
 func InitRedisByConf(conf *config.RedisConfig) redis.UniversalClient {
+	Redis = newRedisByConf(conf)
+	return Redis
+}
+
+func newRedisByConf(conf *config.RedisConfig) redis.UniversalClient {
 	if conf.IsCluster {
-		Redis = redis.NewClusterClient(&redis.ClusterOptions{
+		return redis.NewClusterClient(&redis.ClusterOptions{
 			Addrs:         conf.Addrs,
 			Password:      conf.Password,
 			PoolSize:      conf.PoolSize,
 			MinIdleConns:  conf.MinIdleConn,
 			RouteRandomly: conf.RouteRandomly,
 		})
-		return Redis
 	} else {
-		Redis = redis.NewClient(&redis.Options{
+		return redis.NewClient(&redis.Options{
 			Addr:         conf.Addrs[0],
 			Password:     conf.Password,
 			DB:           conf.DB,
 			PoolSize:     conf.PoolSize,
 			MinIdleConns: conf.MinIdleConn,
 		})
-		return Redis
 	}
 }
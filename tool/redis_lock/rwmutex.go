@@ -0,0 +1,278 @@
+package redis_lock
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/senyu-up/toolbox/tool/logger"
+)
+
+// purgeReaders 读写锁脚本的公共部分, 清理已过期的读者, KEYS[3] 读者过期时间 zset, KEYS[4] 读者重入计数 hash
+const purgeReaders = `
+local expired = redis.call("ZRANGEBYSCORE", KEYS[3], "-inf", now)
+for _, reader in ipairs(expired) do
+	redis.call("HDEL", KEYS[4], reader)
+end
+redis.call("ZREMRANGEBYSCORE", KEYS[3], "-inf", now)
+`
+
+// rlockScript 获取读锁, 写锁被其他持有者持有或有写者等待时失败, 已持有读锁、写锁时可重入
+// KEYS: 写锁 hash, fencing 计数器, 读者过期时间 zset, 读者重入计数 hash, 等待的写者
+// ARGV: owner, 过期时间(毫秒), 当前毫秒时间戳
+// 返回 {1, 最近一次写锁的 fence} 或 {0, 剩余毫秒数}
+var rlockScript = redis.NewScript(`
+local owner = ARGV[1]
+local ttl = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+` + purgeReaders + `
+local holder = redis.call("HGET", KEYS[1], "owner")
+if holder ~= false and holder ~= owner then
+	return {0, redis.call("PTTL", KEYS[1])}
+end
+if holder == false and redis.call("HEXISTS", KEYS[4], owner) == 0 then
+	local waiting = redis.call("GET", KEYS[5])
+	if waiting ~= false and waiting ~= owner then
+		return {0, redis.call("PTTL", KEYS[5])}
+	end
+end
+redis.call("HINCRBY", KEYS[4], owner, 1)
+redis.call("ZADD", KEYS[3], now + ttl, owner)
+redis.call("PEXPIRE", KEYS[3], ttl)
+redis.call("PEXPIRE", KEYS[4], ttl)
+return {1, tonumber(redis.call("GET", KEYS[2]) or "0")}
+`)
+
+// wlockScript 获取写锁, 没有其他读者、写者时获取; wait 为 1 时标记写者等待, 阻止新的读者
+// KEYS: 同 rlockScript; ARGV: owner, 过期时间(毫秒), 当前毫秒时间戳, 等待标记过期时间(毫秒), wait
+// 返回 {1, fence} 或 {0, 剩余毫秒数}
+var wlockScript = redis.NewScript(`
+local owner = ARGV[1]
+local ttl = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local waitTTL = tonumber(ARGV[4])
+` + purgeReaders + `
+local holder = redis.call("HGET", KEYS[1], "owner")
+if holder == owner then
+	redis.call("HINCRBY", KEYS[1], "count", 1)
+	redis.call("PEXPIRE", KEYS[1], ttl)
+	return {1, tonumber(redis.call("HGET", KEYS[1], "fence"))}
+end
+if holder == false then
+	local readers = redis.call("ZCARD", KEYS[3])
+	local waiting = redis.call("GET", KEYS[5])
+	if readers == 0 and (waiting == false or waiting == owner) then
+		if waiting == owner then
+			redis.call("DEL", KEYS[5])
+		end
+		local fence = redis.call("INCR", KEYS[2])
+		redis.call("HMSET", KEYS[1], "owner", owner, "count", 1, "fence", fence)
+		redis.call("PEXPIRE", KEYS[1], ttl)
+		return {1, fence}
+	end
+	if ARGV[5] == "1" and (waiting == false or waiting == owner) then
+		redis.call("SET", KEYS[5], owner, "PX", waitTTL)
+	end
+	if readers > 0 then
+		return {0, redis.call("PTTL", KEYS[3])}
+	end
+	return {0, redis.call("PTTL", KEYS[5])}
+end
+return {0, redis.call("PTTL", KEYS[1])}
+`)
+
+// runlockScript 释放 1 次读锁, 返回剩余的重入计数, -1 表示没有持有读锁
+// KEYS: 读者过期时间 zset, 读者重入计数 hash; ARGV: owner
+var runlockScript = redis.NewScript(`
+if redis.call("HEXISTS", KEYS[2], ARGV[1]) == 0 then
+	return -1
+end
+local count = redis.call("HINCRBY", KEYS[2], ARGV[1], -1)
+if count > 0 then
+	return count
+end
+redis.call("HDEL", KEYS[2], ARGV[1])
+redis.call("ZREM", KEYS[1], ARGV[1])
+return 0
+`)
+
+// rrefreshScript 读锁续期, 返回 1 表示仍持有读锁
+// KEYS: 读者过期时间 zset, 读者重入计数 hash; ARGV: owner, 过期时间(毫秒), 当前毫秒时间戳
+var rrefreshScript = redis.NewScript(`
+local expireAt = tonumber(redis.call("ZSCORE", KEYS[1], ARGV[1]))
+if expireAt == nil or expireAt < tonumber(ARGV[3]) then
+	return 0
+end
+redis.call("ZADD", KEYS[1], tonumber(ARGV[3]) + tonumber(ARGV[2]), ARGV[1])
+redis.call("PEXPIRE", KEYS[1], ARGV[2])
+redis.call("PEXPIRE", KEYS[2], ARGV[2])
+return 1
+`)
+
+// unwaitScript 写者放弃等待, 清除等待标记
+// KEYS: 等待的写者; ARGV: owner
+var unwaitScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	redis.call("DEL", KEYS[1])
+end
+return 1
+`)
+
+// RWMutex 分布式读写锁, 读锁共享, 写锁互斥, 同一持有者可重入
+// 持有写锁时可以再获取读锁(降级), 持有读锁时获取写锁会一直等待, 不支持升级
+type RWMutex struct {
+	c     *Client
+	name  string
+	owner string
+
+	lock  sync.Mutex
+	rheld int
+	wheld int
+	fence int64
+	rstop chan struct{}
+	wstop chan struct{}
+}
+
+func newRWMutex(c *Client, name string, owner []string) *RWMutex {
+	return &RWMutex{c: c, name: name, owner: ownerToken(owner)}
+}
+
+func (rw *RWMutex) Name() string {
+	return rw.name
+}
+
+func (rw *RWMutex) Owner() string {
+	return rw.owner
+}
+
+// Fence 最近一次获取写锁的 fencing token, 获取读锁时为当前最新的写锁 fence
+func (rw *RWMutex) Fence() int64 {
+	rw.lock.Lock()
+	defer rw.lock.Unlock()
+	return rw.fence
+}
+
+func (rw *RWMutex) keys() []string {
+	return []string{rw.c.key(rw.name, ""), rw.c.key(rw.name, ":fence"), rw.c.key(rw.name, ":readers"),
+		rw.c.key(rw.name, ":rcount"), rw.c.key(rw.name, ":wwait")}
+}
+
+func (rw *RWMutex) tryRLock() (bool, time.Duration, error) {
+	keys := rw.keys()
+	ok, fence, _, ttl, err := rw.c.acquire(rlockScript, keys, []interface{}{rw.owner, rw.c.ttl(), ms(rw.c.now())}, false,
+		func() { rw.c.evalAll(runlockScript, keys[2:4], rw.owner) })
+	if !ok {
+		return false, ttl, err
+	}
+	rw.lock.Lock()
+	defer rw.lock.Unlock()
+	rw.rheld++
+	rw.fence = fence
+	if rw.rheld == 1 && rw.c.watchdog {
+		rw.rstop = rw.c.startWatchdog(rw.name, func() (bool, error) {
+			ok, _, err := rw.c.refresh(rrefreshScript, keys[2:4], rw.owner, rw.c.ttl(), ms(rw.c.now()))
+			return ok, err
+		})
+	}
+	return true, 0, nil
+}
+
+func (rw *RWMutex) tryLock(wait bool) (bool, time.Duration, error) {
+	flag := 0
+	if wait {
+		flag = 1
+	}
+	keys := rw.keys()
+	ok, fence, _, ttl, err := rw.c.acquire(wlockScript, keys,
+		[]interface{}{rw.owner, rw.c.ttl(), ms(rw.c.now()), rw.c.waitTTL(), flag}, true,
+		func() { rw.c.evalAll(releaseScript, keys[:1], rw.owner, rw.c.ttl()) })
+	if !ok {
+		return false, ttl, err
+	}
+	rw.lock.Lock()
+	defer rw.lock.Unlock()
+	rw.wheld++
+	rw.fence = fence
+	if rw.wheld == 1 && rw.c.watchdog {
+		rw.wstop = rw.c.startWatchdog(rw.name, func() (bool, error) {
+			ok, _, err := rw.c.refresh(refreshScript, keys[:1], rw.owner, rw.c.ttl())
+			return ok, err
+		})
+	}
+	return true, 0, nil
+}
+
+// TryRLock 尝试获取读锁, 不等待
+func (rw *RWMutex) TryRLock() (bool, error) {
+	ok, _, err := rw.tryRLock()
+	return ok, err
+}
+
+// RLock 获取读锁, 获取不到时等待直到 ctx 结束
+func (rw *RWMutex) RLock(ctx context.Context) error {
+	return rw.c.wait(ctx, rw.tryRLock, nil)
+}
+
+// RUnlock 释放 1 次读锁, 读锁已过期时返回 ErrNotHeld
+func (rw *RWMutex) RUnlock() error {
+	remain, err := rw.c.release(runlockScript, rw.keys()[2:4], rw.owner)
+	if err != nil {
+		return err
+	}
+	rw.lock.Lock()
+	defer rw.lock.Unlock()
+	rw.rheld, rw.rstop = release(rw.rheld, rw.rstop, remain)
+	if remain < 0 {
+		return ErrNotHeld
+	}
+	return nil
+}
+
+// TryLock 尝试获取写锁, 不等待
+func (rw *RWMutex) TryLock() (bool, error) {
+	ok, _, err := rw.tryLock(false)
+	return ok, err
+}
+
+// Lock 获取写锁, 获取不到时等待直到 ctx 结束, 等待期间新的读者需要等待
+func (rw *RWMutex) Lock(ctx context.Context) error {
+	return rw.c.wait(ctx, func() (bool, time.Duration, error) {
+		return rw.tryLock(true)
+	}, func() {
+		for _, r := range rw.c.evalAll(unwaitScript, rw.keys()[4:], rw.owner) {
+			if r.err != nil {
+				logger.GetLogger().Warnw(context.Background(), "redis lock unwait failed", "name", rw.name, "error", r.err)
+			}
+		}
+	})
+}
+
+// Unlock 释放 1 次写锁, 写锁已过期或被其他持有者获取时返回 ErrNotHeld
+func (rw *RWMutex) Unlock() error {
+	remain, err := rw.c.release(releaseScript, rw.keys()[:1], rw.owner, rw.c.ttl())
+	if err != nil {
+		return err
+	}
+	rw.lock.Lock()
+	defer rw.lock.Unlock()
+	rw.wheld, rw.wstop = release(rw.wheld, rw.wstop, remain)
+	if remain < 0 {
+		return ErrNotHeld
+	}
+	return nil
+}
+
+// release 根据脚本返回的剩余计数更新本地的重入次数, 减到 0 时停止续期
+func release(held int, stop chan struct{}, remain int64) (int, chan struct{}) {
+	if remain < 0 {
+		held = 0
+	} else if held > 0 {
+		held--
+	}
+	if held == 0 && stop != nil {
+		close(stop)
+		stop = nil
+	}
+	return held, stop
+}
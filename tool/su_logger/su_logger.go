@@ -7,15 +7,12 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"os"
-	"reflect"
 	"runtime"
 	"runtime/debug"
 	"strings"
 	"time"
 
-	jsoniter "github.com/json-iterator/go"
-	"github.com/senyu-up/toolbox/enum"
+	"github.com/senyu-up/toolbox/tool/logger"
 	"github.com/senyu-up/toolbox/tool/trace"
 	"github.com/senyu-up/toolbox/tool/wework/qwrobot"
 	"github.com/spf13/cast"
@@ -23,250 +20,176 @@ import (
 	"go.uber.org/zap/zapcore"
 )
 
+// Deprecated: 使用 logger 的结构化日志 logger.Errorw, logger.Infow 等, 这里的函数转发到 logger 的全局 logger,
+// 日志级别、输出、通知均以 logger 的配置为准
+
 func NewExtra() *Extra {
 	return E()
 }
 
 func E() *Extra {
-	return &Extra{
-		fields: make([]zap.Field, 0),
-	}
+	return &Extra{extras: logger.E()}
 }
 
+// Extra 转发到 logger.Extras
 type Extra struct {
-	fields []zap.Field
+	extras *logger.Extras
+}
+
+// Extras 转换为 logger.Extras, 可以直接传给 logger 的结构化日志
+func (e *Extra) Extras() *logger.Extras {
+	return e.extras
 }
 
 func (e *Extra) String(key string, value string) *Extra {
-	e.fields = append(e.fields, zap.String(key, value))
+	e.extras.KV(key, value)
 	return e
 }
 
 func (e *Extra) Int(key string, v int) *Extra {
-	e.fields = append(e.fields, zap.Int(key, v))
-
+	e.extras.Int(key, v)
 	return e
 }
 
 func (e *Extra) Int8(key string, v int8) *Extra {
-	e.fields = append(e.fields, zap.Int8(key, v))
-
+	e.extras.Int8(key, v)
 	return e
 }
 
 func (e *Extra) Int16(key string, v int16) *Extra {
-	e.fields = append(e.fields, zap.Int16(key, v))
-
+	e.extras.Int16(key, v)
 	return e
 }
 
 func (e *Extra) Int32(key string, v int32) *Extra {
-	e.fields = append(e.fields, zap.Int32(key, v))
-
+	e.extras.Int32(key, v)
 	return e
 }
 
 func (e *Extra) Int64(key string, v int64) *Extra {
-	e.fields = append(e.fields, zap.Int64(key, v))
-
+	e.extras.Int64(key, v)
 	return e
 }
 
 func (e *Extra) Uint(key string, v uint) *Extra {
-	e.fields = append(e.fields, zap.Uint(key, v))
-
+	e.extras.Uint(key, v)
 	return e
 }
 
 func (e *Extra) Uint8(key string, v uint8) *Extra {
-	e.fields = append(e.fields, zap.Uint8(key, v))
-
+	e.extras.Uint8(key, v)
 	return e
 }
 
 func (e *Extra) Uint16(key string, v uint16) *Extra {
-	e.fields = append(e.fields, zap.Uint16(key, v))
-
+	e.extras.Uint16(key, v)
 	return e
 }
 
 func (e *Extra) Uint32(key string, v uint32) *Extra {
-	e.fields = append(e.fields, zap.Uint32(key, v))
-
+	e.extras.Uint32(key, v)
 	return e
 }
 
 func (e *Extra) Uint64(key string, v uint64) *Extra {
-	e.fields = append(e.fields, zap.Uint64(key, v))
-
+	e.extras.Uint64(key, v)
 	return e
 }
 
 func (e *Extra) Float64(key string, v float64) *Extra {
-	e.fields = append(e.fields, zap.Float64(key, v))
-
+	e.extras.KV(key, v)
 	return e
 }
 
 func (e *Extra) Bool(key string, v bool) *Extra {
-	e.fields = append(e.fields, zap.Bool(key, v))
-
+	e.extras.Bool(key, v)
 	return e
 }
 
 func (e *Extra) Any(key string, value interface{}) *Extra {
 	e.Interface(key, value)
-
 	return e
 }
 
 func (e *Extra) Error(err error) *Extra {
-	e.fields = append(e.fields, zap.Error(err))
-
+	e.extras.Error(err)
 	return e
 }
 
 func (e *Extra) NamedError(key string, err error) *Extra {
-	e.fields = append(e.fields, zap.NamedError(key, err))
-
+	e.extras.NamedError(key, err)
 	return e
 }
 
 func (e *Extra) Interface(key string, v interface{}) *Extra {
-	if v == nil {
-		e.fields = append(e.fields, zap.String(key, ""))
-	} else {
-		switch reflect.TypeOf(v).Kind() {
-		case reflect.Slice, reflect.Array, reflect.Struct, reflect.Map, reflect.Ptr:
-			// 对结构体, map, slice 进行特判
-			s, err1 := jsoniter.MarshalToString(v)
-			if err1 != nil {
-				e.fields = append(e.fields, zap.Any(key, v))
-			} else {
-				e.fields = append(e.fields, zap.String(key, s))
-			}
-		default:
-			e.fields = append(e.fields, zap.Any(key, v))
-		}
-	}
-
+	e.extras.Interface(key, v)
 	return e
 }
 
 func (e *Extra) Ctx(ctx context.Context) *Extra {
-	if curC, ok := ctx.(C); ok {
-		e.Trace(curC.traceId)
-		e.Span(curC.spanId)
-	} else {
-		traceId, spanId := trace.ParseFromContext(ctx)
-		e.Trace(traceId)
-		e.Span(spanId)
-	}
-
+	traceId, spanId := logger.ParseTrace(ctx)
+	e.Trace(traceId)
+	e.Span(spanId)
 	return e
 }
 
 func (e *Extra) Trace(traceId string) *Extra {
-	if traceId != "" {
-		e.fields = append(e.fields, zap.String("trace", traceId))
-	}
-
+	e.extras.Trace(traceId)
 	return e
 }
 
 func (e *Extra) NowMs() *Extra {
-	e.fields = append(e.fields, zap.Int64("ms", time.Now().UnixNano()/1e6))
-
+	e.extras.NowMs()
 	return e
 }
 
 func (e *Extra) Span(spanId string) *Extra {
-	if spanId != "" {
-		e.fields = append(e.fields, zap.String("span", spanId))
-	}
-
+	e.extras.Span(spanId)
 	return e
 }
 
-var logLevel zapcore.Level = -2
-
-func isEnable(level zapcore.Level) bool {
-	if logLevel == -2 {
-		// 获取当前的stage, 并设置对应的日志级别
-		stage := os.Getenv(enum.StageKey)
-		if stage == enum.EvnStageProduction || stage == "master" {
-			logLevel = zapcore.InfoLevel
-		} else {
-			logLevel = zapcore.DebugLevel
-		}
-	}
-
-	if level < logLevel {
-		return false
-	}
-
-	return true
-}
-
-func assembleField(ctx context.Context, err error, extra []*Extra) []zap.Field {
-	var e *Extra
+func extraKV(extra []*Extra) []interface{} {
 	if len(extra) == 0 || extra[0] == nil {
-		e = E()
-	} else {
-		e = extra[0]
+		return nil
 	}
-
-	if err != nil {
-		e.Error(err)
-	}
-
-	e.Ctx(ctx)
-
-	return e.fields
+	return []interface{}{extra[0].extras}
 }
 
+// WithCaller 兼容旧的调用方式, 是否打印调用位置由 logger 的配置决定
 func WithCaller() *Extra {
 	return nil
 }
 
 func Info(ctx context.Context, msg string, extra ...*Extra) {
-	if isEnable(zapcore.InfoLevel) {
-		if len(extra) > 1 {
-			loggerWithCaller.Info(msg, assembleField(ctx, nil, extra)...)
-		} else {
-			loggerWithoutCaller.Info(msg, assembleField(ctx, nil, extra)...)
-		}
-	}
+	logger.AddCallerSkip(1).Infow(ctx, msg, extraKV(extra)...)
 }
 
 func InfoWithNotify(ctx context.Context, msg string, extra ...*Extra) {
-	fields := assembleField(ctx, nil, extra)
-	loggerWithCaller.Info(msg, fields...)
-	Notify(zapcore.InfoLevel, msg, fields)
+	notifyLog().Infow(ctx, msg, extraKV(extra)...)
 }
 
 func Debug(ctx context.Context, msg string, extra ...*Extra) {
-	if isEnable(zapcore.DebugLevel) {
-		if len(extra) > 1 {
-			loggerWithCaller.Debug(msg, assembleField(ctx, nil, extra)...)
-		} else {
-			loggerWithoutCaller.Debug(msg, assembleField(ctx, nil, extra)...)
-		}
-	}
+	logger.AddCallerSkip(1).Debugw(ctx, msg, extraKV(extra)...)
 }
 
 func Warn(ctx context.Context, msg string, extra ...*Extra) {
-	if isEnable(zapcore.WarnLevel) {
-		loggerWithCaller.Warn(msg, assembleField(ctx, nil, extra)...)
-	}
+	logger.AddCallerSkip(1).Warnw(ctx, msg, extraKV(extra)...)
 }
 
 func WarnWithNotify(ctx context.Context, msg string, extra ...*Extra) {
-	fields := assembleField(ctx, nil, extra)
-	loggerWithCaller.Warn(msg, fields...)
-	Notify(zapcore.WarnLevel, msg, fields)
+	notifyLog().Warnw(ctx, msg, extraKV(extra)...)
+}
+
+// notifyLog 同时发送到全局的企微机器人, 未初始化机器人时只写日志
+func notifyLog() logger.Log {
+	l := logger.AddCallerSkip(1)
+	if robot := qwrobot.Get(); robot != nil {
+		l = l.SetCallBack(logger.NewQwRobot(robot))
+	}
+	return l.Notify()
 }
 
+// Notify 直接发送企微机器人通知
 func Notify(level zapcore.Level, msg string, fields []zap.Field) {
 	robot := qwrobot.Get()
 	if robot != nil {
@@ -286,31 +209,22 @@ func Notify(level zapcore.Level, msg string, fields []zap.Field) {
 }
 
 func Error(ctx context.Context, err error, msg string, extra ...*Extra) {
-	if isEnable(zapcore.ErrorLevel) {
-		loggerWithCaller.Error(msg, assembleField(ctx, err, extra)...)
-	}
+	logger.AddCallerSkip(1).SetErr(err).Errorw(ctx, msg, extraKV(extra)...)
 }
 
 func ErrorWithNotify(ctx context.Context, err error, msg string, extra ...*Extra) {
-	fields := assembleField(ctx, err, extra)
-	loggerWithCaller.Error(msg, fields...)
-	Notify(zapcore.ErrorLevel, msg, fields)
+	notifyLog().SetErr(err).Errorw(ctx, msg, extraKV(extra)...)
 }
 
+// Panic 记录调用栈, 以 Crit 级别输出, zap driver 在 development 模式下会 panic
 func Panic(ctx context.Context, msg string, extra ...*Extra) {
 	stack := FormatStackInline(debug.Stack(), 5)
-	err := errors.New(stack)
-
-	loggerWithCaller.DPanic(msg, assembleField(ctx, err, extra)...)
+	logger.AddCallerSkip(1).SetErr(errors.New(stack)).Logw(ctx, logger.LevelCritical, msg, extraKV(extra)...)
 }
 
 func PanicWithNotify(ctx context.Context, msg string, extra ...*Extra) {
 	stack := FormatStackInline(debug.Stack(), 5)
-	err := errors.New(stack)
-	fields := assembleField(ctx, err, extra)
-	loggerWithCaller.DPanic(msg, fields...)
-
-	Notify(zapcore.DPanicLevel, msg, fields)
+	notifyLog().SetErr(errors.New(stack)).Logw(ctx, logger.LevelCritical, msg, extraKV(extra)...)
 }
 
 // FormatStackInline
@@ -421,7 +335,7 @@ func Ctx(ctx context.Context) context.Context {
 	traceId, spanId := trace.ParseFromContext(ctx)
 
 	return C{
-		Context: nil,
+		Context: context.Background(),
 		traceId: traceId,
 		spanId:  spanId,
 	}
@@ -438,7 +352,7 @@ func AutoCtx(ctx context.Context) context.Context {
 	}
 
 	return C{
-		Context: nil,
+		Context: context.Background(),
 		traceId: traceId,
 		spanId:  spanId,
 	}
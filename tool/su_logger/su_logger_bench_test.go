@@ -5,6 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"testing"
+	"time"
+
+	"github.com/senyu-up/toolbox/tool/logger"
+	"github.com/senyu-up/toolbox/tool/trace"
 )
 
 func BenchmarkError(b *testing.B) {
@@ -49,3 +53,40 @@ func BenchmarkError(b *testing.B) {
 	}
 	b.StopTimer()
 }
+
+type discardDriver struct{}
+
+func (discardDriver) LogWrite(when time.Time, msg string, level logger.LogLevel, extra []logger.Field) error {
+	return nil
+}
+
+func (discardDriver) Destroy() {}
+
+func (discardDriver) CurrentLevel() logger.LogLevel { return logger.LevelDebug }
+
+func (discardDriver) Name() string { return "discard" }
+
+// su_logger 转发到 logger 与直接使用 logger 结构化日志的对比
+func BenchmarkAdapter(b *testing.B) {
+	var old logger.Driver
+	logger.WrapDriver(func(d logger.Driver) logger.Driver {
+		old = d
+		return discardDriver{}
+	})
+	defer logger.WrapDriver(func(logger.Driver) logger.Driver { return old })
+	var err = errors.New("This is Error ")
+	var ctx = trace.NewContextWithRequestIdAndSpanId(context.TODO(), "req1", "span1")
+
+	b.Run("su_logger", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			Error(ctx, err, "Got Err", E().String("name", "xh").Int("age", 18))
+		}
+	})
+	b.Run("logger", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			logger.SetErr(err).Errorw(ctx, "Got Err", "name", "xh", "age", 18)
+		}
+	})
+}
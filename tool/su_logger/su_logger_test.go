@@ -0,0 +1,87 @@
+package su_logger
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/senyu-up/toolbox/tool/logger"
+	"github.com/senyu-up/toolbox/tool/trace"
+)
+
+// captureDriver 记录写入全局 logger 的日志
+type captureDriver struct {
+	sync.Mutex
+	msgs   []string
+	levels []logger.LogLevel
+	fields []map[string]logger.Field
+}
+
+func (c *captureDriver) LogWrite(when time.Time, msg string, level logger.LogLevel, extra []logger.Field) error {
+	m := make(map[string]logger.Field, len(extra))
+	for _, f := range extra {
+		m[f.Key] = f
+	}
+	c.Lock()
+	defer c.Unlock()
+	c.msgs = append(c.msgs, msg)
+	c.levels = append(c.levels, level)
+	c.fields = append(c.fields, m)
+	return nil
+}
+
+func (c *captureDriver) Destroy() {}
+
+func (c *captureDriver) CurrentLevel() logger.LogLevel { return logger.LevelTrace }
+
+func (c *captureDriver) Name() string { return "capture" }
+
+func capture(t *testing.T) *captureDriver {
+	c := &captureDriver{}
+	var old logger.Driver
+	logger.WrapDriver(func(d logger.Driver) logger.Driver {
+		old = d
+		return c
+	})
+	t.Cleanup(func() {
+		logger.WrapDriver(func(logger.Driver) logger.Driver { return old })
+	})
+	return c
+}
+
+func TestAdapter(t *testing.T) {
+	c := capture(t)
+	err := errors.New("boom")
+	ctx := trace.NewContextWithRequestIdAndSpanId(context.TODO(), "req1", "span1")
+
+	Info(ctx, "info", E().String("name", "xh").Float64("rate", 0.123456789))
+	Error(Ctx(ctx), err, "error", E().Int("uid", 7))
+	Warn(context.TODO(), "warn", E().Trace("t2"), nil)
+	Panic(ctx, "panic")
+
+	if len(c.msgs) != 4 {
+		t.Fatalf("messages = %v", c.msgs)
+	}
+	wantLevels := []logger.LogLevel{logger.LevelInformational, logger.LevelError, logger.LevelWarning, logger.LevelCritical}
+	for i, msg := range c.msgs {
+		// 调用位置为本文件, 而不是 su_logger.go
+		if !strings.Contains(msg, "su_logger_test.go:") || c.levels[i] != wantLevels[i] {
+			t.Errorf("message = %s, level = %d", msg, c.levels[i])
+		}
+	}
+	if f := c.fields[0]; f["name"].String != "xh" || f["rate"].Float != 0.123456789 || f["trace"].String != "req1" {
+		t.Errorf("info fields = %+v", f)
+	}
+	if f := c.fields[1]; f["uid"].Integer != 7 || f["error"].Interface != err || f["span"].String != "span1" {
+		t.Errorf("error fields = %+v", f)
+	}
+	if f := c.fields[2]; f["trace"].String != "t2" {
+		t.Errorf("warn fields = %+v", f)
+	}
+	if f := c.fields[3]; f["error"].Interface == nil {
+		t.Errorf("panic fields = %+v, should have stack", f)
+	}
+}
@@ -11,9 +11,6 @@ import (
 	"runtime"
 )
 
-var loggerWithCaller = NewZapLogger(`{"caller_skip":1}`)
-var loggerWithoutCaller = NewZapLogger(`{}`)
-
 const logTimeDefaultFormat = "2006-01-02 15:04:05.000"
 
 // 日志格式
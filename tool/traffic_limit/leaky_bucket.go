@@ -0,0 +1,119 @@
+package traffic_limit
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/senyu-up/toolbox/tool/config"
+)
+
+// leakyBucketScript 漏桶, 水位按固定速率下降, 加入请求后不超过容量时放行
+// KEYS[1] hash{level, ts}; ARGV: 容量, 每毫秒流出的请求数, 当前毫秒时间戳, 申请数, 过期时间(毫秒)
+var leakyBucketScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local n = tonumber(ARGV[4])
+local ttl = tonumber(ARGV[5])
+
+local state = redis.call("HMGET", KEYS[1], "level", "ts")
+local level = tonumber(state[1])
+local ts = tonumber(state[2])
+if level == nil or ts == nil then
+	level = 0
+	ts = now
+end
+if now > ts then
+	level = math.max(0, level - (now - ts) * rate)
+	ts = now
+end
+
+local allowed = 0
+local retry = 0
+if level + n <= capacity then
+	level = level + n
+	allowed = 1
+else
+	retry = math.ceil((level + n - capacity) / rate)
+end
+redis.call("HMSET", KEYS[1], "level", tostring(level), "ts", ts)
+redis.call("PEXPIRE", KEYS[1], ttl)
+return {allowed, math.floor(capacity - level), retry, math.ceil(level / rate)}
+`)
+
+// NewLeakyBucket 漏桶限流, 桶内最多容纳 Capacity 个请求, 每个周期流出 NumPerPeriod 个,
+// Capacity 较小时请求被平滑为恒定速率
+func NewLeakyBucket(conf config.LeakyBucketConf) (Limiter, error) {
+	if conf.Key == "" {
+		return nil, fmt.Errorf("traffic limit: leaky bucket Key is required")
+	}
+	if conf.Capacity == 0 || conf.NumPerPeriod == 0 {
+		return nil, fmt.Errorf("traffic limit: leaky bucket %s Capacity and NumPerPeriod must be positive", conf.Key)
+	}
+	alg := &leakyBucket{
+		capacity: float64(conf.Capacity),
+		rate:     float64(conf.NumPerPeriod) / float64(period(conf.Period)/time.Millisecond),
+	}
+	return newLimiter(conf.Key, conf.Redis, alg), nil
+}
+
+type leakyBucket struct {
+	capacity float64
+	rate     float64 // 每毫秒流出的请求数
+}
+
+func (l *leakyBucket) limit() int64 {
+	return int64(l.capacity)
+}
+
+// ttl 桶流空所需时间的 2 倍, 之后 key 过期等同于空桶
+func (l *leakyBucket) ttl() int64 {
+	return int64(math.Ceil(l.capacity/l.rate))*2 + 1000
+}
+
+func (l *leakyBucket) eval(rdb redis.UniversalClient, key string, now time.Time, n int64) (Result, error) {
+	v, err := leakyBucketScript.Run(rdb, []string{key},
+		formatFloat(l.capacity), formatFloat(l.rate), ms(now), n, l.ttl()).Result()
+	if err != nil {
+		return Result{}, err
+	}
+	return parseResult(l.limit(), v)
+}
+
+func (l *leakyBucket) newBucket(now time.Time) bucket {
+	return &localLeakyBucket{leakyBucket: l, ts: ms(now)}
+}
+
+type localLeakyBucket struct {
+	*leakyBucket
+	level float64
+	ts    int64
+}
+
+func (b *localLeakyBucket) leak(now int64) {
+	if now > b.ts {
+		b.level = math.Max(0, b.level-float64(now-b.ts)*b.rate)
+		b.ts = now
+	}
+}
+
+func (b *localLeakyBucket) take(now time.Time, n int64) Result {
+	b.leak(ms(now))
+	r := Result{Limit: b.limit()}
+	if b.level+float64(n) <= b.capacity {
+		b.level += float64(n)
+		r.Allowed = true
+	} else {
+		r.RetryAfter = ceilMs((b.level + float64(n) - b.capacity) / b.rate)
+	}
+	r.Remaining = int64(b.capacity - b.level)
+	r.ResetAfter = ceilMs(b.level / b.rate)
+	return r
+}
+
+func (b *localLeakyBucket) idle(now time.Time) bool {
+	b.leak(ms(now))
+	return b.level <= 0
+}
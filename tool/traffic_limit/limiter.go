@@ -0,0 +1,213 @@
+package traffic_limit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/senyu-up/toolbox/tool/logger"
+)
+
+const (
+	pingInterval = time.Millisecond * 100 // redis 故障后探测间隔
+	minWait      = time.Millisecond       // Wait 的最小重试间隔
+)
+
+var (
+	// ErrExceedLimit 单次请求数超过上限, 永远不会放行
+	ErrExceedLimit = errors.New("traffic limit: n exceeds limit")
+	// ErrInvalidN 申请数不是正数
+	ErrInvalidN = errors.New("traffic limit: n must be positive")
+	// ErrWaitDeadline 在 ctx 的截止时间之前无法放行
+	ErrWaitDeadline = errors.New("traffic limit: wait would exceed context deadline")
+)
+
+// Limiter 限流器, 令牌桶、固定窗口、滑动窗口、漏桶实现相同的接口
+// 配置了 Redis 时通过 lua 脚本原子执行, 多个实例共享限额, redis 故障时降级为进程内限流, 恢复后自动切回
+type Limiter interface {
+	// Allow 是否放行 1 个请求
+	Allow() bool
+	// AllowN 是否放行 n 个请求
+	AllowN(n int) bool
+	// Wait 阻塞直到放行 1 个请求, 或 ctx 结束
+	Wait(ctx context.Context) error
+	// WaitN 阻塞直到放行 n 个请求, 或 ctx 结束, n 超过上限时返回 ErrExceedLimit, n 不是正数时返回 ErrInvalidN
+	WaitN(ctx context.Context, n int) error
+	// Take 对 key 维度申请 n 个请求, key 拼接在配置的 Key 之后, 用于按用户、ip 等分别限流, n 不是正数时不放行
+	Take(key string, n int) Result
+}
+
+// Result 一次申请的结果
+type Result struct {
+	Allowed   bool
+	Limit     int64 // 上限, 令牌桶、漏桶为容量, 窗口为周期内的请求数
+	Remaining int64 // 剩余可用的请求数
+	// 未放行时多久之后可以重试, 为 -1 表示 n 超过上限或不是正数, 永远不会放行
+	RetryAfter time.Duration
+	// 多久之后恢复满额
+	ResetAfter time.Duration
+}
+
+// algorithm 限流算法, redis 与本地各有一份实现
+type algorithm interface {
+	limit() int64
+	// eval 在 redis 上执行 lua 脚本
+	eval(rdb redis.UniversalClient, key string, now time.Time, n int64) (Result, error)
+	// newBucket 本地限流的状态
+	newBucket(now time.Time) bucket
+}
+
+// bucket 一个 key 的本地限流状态
+type bucket interface {
+	take(now time.Time, n int64) Result
+	// idle 状态已恢复到初始值, 可以回收
+	idle(now time.Time) bool
+}
+
+type limiter struct {
+	key   string
+	alg   algorithm
+	rdb   redis.UniversalClient
+	now   func() time.Time
+	local *localStore
+
+	down       uint32 // redis 不可用, 使用本地限流
+	monitoring uint32 // redis 探测任务是否已开启
+}
+
+func newLimiter(key string, rdb redis.UniversalClient, alg algorithm) *limiter {
+	return &limiter{
+		key:   key,
+		alg:   alg,
+		rdb:   rdb,
+		now:   time.Now,
+		local: newLocalStore(alg),
+	}
+}
+
+func (l *limiter) Allow() bool {
+	return l.Take("", 1).Allowed
+}
+
+func (l *limiter) AllowN(n int) bool {
+	return l.Take("", n).Allowed
+}
+
+func (l *limiter) Wait(ctx context.Context) error {
+	return l.WaitN(ctx, 1)
+}
+
+func (l *limiter) WaitN(ctx context.Context, n int) error {
+	if n <= 0 {
+		return ErrInvalidN
+	}
+	for {
+		r := l.Take("", n)
+		if r.Allowed {
+			return nil
+		}
+		if r.RetryAfter < 0 {
+			return ErrExceedLimit
+		}
+		wait := r.RetryAfter
+		if wait < minWait {
+			wait = minWait
+		}
+		if deadline, ok := ctx.Deadline(); ok && deadline.Sub(l.now()) < wait {
+			return ErrWaitDeadline
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (l *limiter) Take(key string, n int) Result {
+	if key != "" {
+		key = l.key + ":" + key
+	} else {
+		key = l.key
+	}
+	// n 为负数时会让计数、令牌反向增加
+	if n <= 0 || int64(n) > l.alg.limit() {
+		return Result{Limit: l.alg.limit(), RetryAfter: -1}
+	}
+	now := l.now()
+	if l.rdb != nil && atomic.LoadUint32(&l.down) == 0 {
+		r, err := l.alg.eval(l.rdb, key, now, int64(n))
+		if err == nil {
+			return r
+		}
+		l.markDown(err)
+	}
+	return l.local.take(key, now, int64(n))
+}
+
+// markDown redis 执行失败, 切换为本地限流并开始探测
+func (l *limiter) markDown(err error) {
+	atomic.StoreUint32(&l.down, 1)
+	if !atomic.CompareAndSwapUint32(&l.monitoring, 0, 1) {
+		return
+	}
+	logger.GetLogger().Warnw(context.Background(), "traffic limit redis unavailable, use local limiter", "key", l.key, "error", err)
+	go l.waitForRedis()
+}
+
+// waitForRedis 探测到 redis 恢复后切回分布式限流
+func (l *limiter) waitForRedis() {
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := l.rdb.Ping().Err(); err == nil {
+			atomic.StoreUint32(&l.down, 0)
+			atomic.StoreUint32(&l.monitoring, 0)
+			logger.GetLogger().Infow(context.Background(), "traffic limit redis recovered", "key", l.key)
+			return
+		}
+	}
+}
+
+// parseResult 解析 lua 脚本返回的 {allowed, remaining, retry_ms, reset_ms}
+func parseResult(limit int64, v interface{}) (Result, error) {
+	values, ok := v.([]interface{})
+	if !ok || len(values) != 4 {
+		return Result{}, fmt.Errorf("traffic limit: unexpected script result %v", v)
+	}
+	nums := make([]int64, len(values))
+	for i, value := range values {
+		if nums[i], ok = value.(int64); !ok {
+			return Result{}, fmt.Errorf("traffic limit: unexpected script result %v", v)
+		}
+	}
+	return Result{
+		Allowed:    nums[0] == 1,
+		Limit:      limit,
+		Remaining:  nums[1],
+		RetryAfter: time.Duration(nums[2]) * time.Millisecond,
+		ResetAfter: time.Duration(nums[3]) * time.Millisecond,
+	}, nil
+}
+
+// period 配置的周期, 默认 1 秒
+func period(sec uint32) time.Duration {
+	if sec == 0 {
+		return time.Second
+	}
+	return time.Duration(sec) * time.Second
+}
+
+func ms(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
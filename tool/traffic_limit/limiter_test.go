@@ -0,0 +1,228 @@
+package traffic_limit
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
+	"github.com/senyu-up/toolbox/tool/config"
+)
+
+type clock struct {
+	t time.Time
+}
+
+func (c *clock) now() time.Time {
+	return c.t
+}
+
+func (c *clock) add(d time.Duration) {
+	c.t = c.t.Add(d)
+}
+
+func newRedis(t *testing.T) (*miniredis.Miniredis, redis.UniversalClient) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	return mr, rdb
+}
+
+// newLimiters 每种算法的上限都为 3/s
+func newLimiters(t *testing.T, rdb redis.UniversalClient) map[string]Limiter {
+	var err error
+	limiters := make(map[string]Limiter)
+	if limiters["token"], err = NewTokenBucket(config.TokenBucketConf{Key: "token", Capacity: 3, NumPerPeriod: 3, Redis: rdb}); err != nil {
+		t.Fatal(err)
+	}
+	if limiters["leaky"], err = NewLeakyBucket(config.LeakyBucketConf{Key: "leaky", Capacity: 3, NumPerPeriod: 3, Redis: rdb}); err != nil {
+		t.Fatal(err)
+	}
+	if limiters["fixed"], err = NewFixedWindow(config.StickyWindowConf{Key: "fixed", NumPerPeriod: 3, Redis: rdb}); err != nil {
+		t.Fatal(err)
+	}
+	if limiters["sliding"], err = NewSlidingWindow(config.SlidingWindowConf{Key: "sliding", NumPerPeriod: 3, Redis: rdb}); err != nil {
+		t.Fatal(err)
+	}
+	return limiters
+}
+
+func TestLimiter(t *testing.T) {
+	_, rdb := newRedis(t)
+	// 令牌桶与漏桶每 333.3ms 恢复 1 个, 窗口从第 200ms 开始, 固定窗口 800ms 后切换
+	wantRetry := map[string]time.Duration{
+		"token":   334 * time.Millisecond,
+		"leaky":   334 * time.Millisecond,
+		"fixed":   800 * time.Millisecond,
+		"sliding": time.Second,
+	}
+	for _, backend := range []string{"redis", "local"} {
+		var client redis.UniversalClient
+		if backend == "redis" {
+			client = rdb
+		}
+		for name, l := range newLimiters(t, client) {
+			c := &clock{t: time.Unix(1700000000, 200*int64(time.Millisecond))}
+			l.(*limiter).now = c.now
+
+			for i := 0; i < 3; i++ {
+				if r := l.Take("", 1); !r.Allowed || r.Limit != 3 || r.Remaining != int64(2-i) {
+					t.Fatalf("%s %s: take %d = %+v", backend, name, i, r)
+				}
+			}
+			r := l.Take("", 1)
+			if r.Allowed || r.Remaining != 0 || r.RetryAfter != wantRetry[name] {
+				t.Fatalf("%s %s: take over limit = %+v, want retry %s", backend, name, r, wantRetry[name])
+			}
+			// n 不是正数时不放行, 也不改变状态
+			for _, n := range []int{0, -3} {
+				if r := l.Take("", n); r.Allowed || r.RetryAfter != -1 {
+					t.Errorf("%s %s: take %d = %+v, should never be allowed", backend, name, n, r)
+				}
+			}
+			if l.Allow() {
+				t.Errorf("%s %s: negative n should not refill", backend, name)
+			}
+			c.add(r.RetryAfter - time.Millisecond)
+			if l.Allow() {
+				t.Fatalf("%s %s: allowed before retry after", backend, name)
+			}
+			c.add(time.Millisecond)
+			if !l.Allow() {
+				t.Fatalf("%s %s: not allowed after retry after", backend, name)
+			}
+			if r = l.Take("", 4); r.Allowed || r.RetryAfter != -1 {
+				t.Errorf("%s %s: take 4 = %+v, should never be allowed", backend, name, r)
+			}
+		}
+	}
+}
+
+func TestLimiter_Key(t *testing.T) {
+	l, err := NewSlidingWindow(config.SlidingWindowConf{Key: "api", NumPerPeriod: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &clock{t: time.Unix(1700000000, 0)}
+	l.(*limiter).now = c.now
+	if !l.Take("u1", 1).Allowed || !l.Take("u2", 1).Allowed || l.Take("u1", 1).Allowed {
+		t.Fatal("keys should be limited separately")
+	}
+
+	// 已恢复的 key 在所在分片有请求时被回收
+	local := l.(*limiter).local
+	c.add(gcInterval)
+	keys := 0
+	for i := range local.shards {
+		for local.shards[i].lastGC != c.t {
+			l.Take("k"+strconv.Itoa(keys), 1)
+			keys++
+		}
+	}
+	if n := local.size(); n != keys {
+		t.Errorf("buckets = %d, want %d, idle keys should be removed", n, keys)
+	}
+}
+
+func TestLimiter_RedisDown(t *testing.T) {
+	mr, rdb := newRedis(t)
+	l, err := NewTokenBucket(config.TokenBucketConf{Key: "down", Capacity: 2, NumPerPeriod: 1, Period: 60, Redis: rdb})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lim := l.(*limiter)
+	if !l.Allow() || !l.Allow() || l.Allow() {
+		t.Fatal("redis limiter should allow 2")
+	}
+
+	// redis 故障时使用本地限流
+	mr.Close()
+	if !l.Allow() || atomic.LoadUint32(&lim.down) != 1 {
+		t.Fatal("should fall back to local limiter")
+	}
+	if !l.Allow() || l.Allow() {
+		t.Fatal("local limiter should allow 2")
+	}
+
+	// 恢复后切回 redis
+	if err = mr.Restart(); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for atomic.LoadUint32(&lim.down) == 1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if atomic.LoadUint32(&lim.down) == 1 {
+		t.Fatal("should switch back to redis")
+	}
+	mr.Del("down")
+	if !l.Allow() || mr.HGet("down", "tokens") != "1" {
+		t.Errorf("should use redis after recovered, tokens = %s", mr.HGet("down", "tokens"))
+	}
+}
+
+func TestLimiter_Wait(t *testing.T) {
+	_, rdb := newRedis(t)
+	l, err := NewTokenBucket(config.TokenBucketConf{Key: "wait", Capacity: 1, NumPerPeriod: 20, Redis: rdb})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = l.WaitN(context.TODO(), 2); err != ErrExceedLimit {
+		t.Errorf("WaitN(2) error = %v, want ErrExceedLimit", err)
+	}
+	if err = l.WaitN(context.TODO(), 0); err != ErrInvalidN {
+		t.Errorf("WaitN(0) error = %v, want ErrInvalidN", err)
+	}
+	start := time.Now()
+	if err = l.Wait(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+	defer cancel()
+	if err = l.Wait(ctx); err != ErrWaitDeadline {
+		t.Errorf("Wait() error = %v, want ErrWaitDeadline", err)
+	}
+	if err = l.Wait(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	if cost := time.Since(start); cost < 40*time.Millisecond {
+		t.Errorf("Wait() cost %s, should wait for refill", cost)
+	}
+}
+
+func TestConf(t *testing.T) {
+	if _, err := NewTokenBucket(config.TokenBucketConf{Capacity: 1, NumPerPeriod: 1}); err == nil {
+		t.Error("empty Key should be invalid")
+	}
+	if _, err := NewLeakyBucket(config.LeakyBucketConf{Key: "k", NumPerPeriod: 1}); err == nil {
+		t.Error("zero Capacity should be invalid")
+	}
+	if _, err := NewFixedWindow(config.StickyWindowConf{Key: "k"}); err == nil {
+		t.Error("zero NumPerPeriod should be invalid")
+	}
+}
+
+func TestSlidingWindow_LocalMemory(t *testing.T) {
+	l, err := NewSlidingWindow(config.SlidingWindowConf{Key: "mem", NumPerPeriod: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &clock{t: time.Unix(1700000000, 0)}
+	l.(*limiter).now = c.now
+	w := l.(*limiter).local.shards[shardIndex("mem")].buckets
+	for i := 0; i < 10000; i++ {
+		l.Allow()
+		c.add(15 * time.Millisecond)
+	}
+	b := w["mem"].(*localSlidingWindow)
+	if cap(b.log) > 2*100 {
+		t.Errorf("log cap = %d, should be bounded by the limit", cap(b.log))
+	}
+	c.add(time.Second)
+	if !b.idle(c.t) || b.log != nil {
+		t.Errorf("idle log = %v, should be released", b.log)
+	}
+}
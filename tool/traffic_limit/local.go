@@ -0,0 +1,78 @@
+package traffic_limit
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	gcInterval  = time.Minute // 本地状态的回收间隔
+	localShards = 32          // 本地状态的分片数, 按 key 分片加锁, 回收时每次只扫描一个分片
+)
+
+// localStore 进程内限流, 未配置 redis 或 redis 故障时使用, 每个 key 一份状态
+type localStore struct {
+	alg    algorithm
+	shards [localShards]localShard
+}
+
+type localShard struct {
+	lock    sync.Mutex
+	buckets map[string]bucket
+	lastGC  time.Time
+}
+
+func newLocalStore(alg algorithm) *localStore {
+	s := &localStore{alg: alg}
+	for i := range s.shards {
+		s.shards[i].buckets = make(map[string]bucket)
+	}
+	return s
+}
+
+func (s *localStore) take(key string, now time.Time, n int64) Result {
+	sh := &s.shards[shardIndex(key)]
+	sh.lock.Lock()
+	defer sh.lock.Unlock()
+	if now.Sub(sh.lastGC) >= gcInterval {
+		sh.gc(now)
+	}
+	b, ok := sh.buckets[key]
+	if !ok {
+		b = s.alg.newBucket(now)
+		sh.buckets[key] = b
+	}
+	return b.take(now, n)
+}
+
+// size 本地状态的 key 数
+func (s *localStore) size() int {
+	total := 0
+	for i := range s.shards {
+		sh := &s.shards[i]
+		sh.lock.Lock()
+		total += len(sh.buckets)
+		sh.lock.Unlock()
+	}
+	return total
+}
+
+// gc 回收已恢复到初始状态的 key, 避免按用户、ip 限流时 map 无限增长
+func (sh *localShard) gc(now time.Time) {
+	for key, b := range sh.buckets {
+		if b.idle(now) {
+			delete(sh.buckets, key)
+		}
+	}
+	sh.lastGC = now
+}
+
+// shardIndex fnv-1a
+func shardIndex(key string) uint32 {
+	h := uint32(2166136261)
+	for i := 0; i < len(key); i++ {
+		h ^= uint32(key[i])
+		h *= 16777619
+	}
+	return h % localShards
+}
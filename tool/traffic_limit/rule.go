@@ -0,0 +1,257 @@
+package traffic_limit
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/senyu-up/toolbox/tool/config"
+	"github.com/senyu-up/toolbox/tool/logger"
+	"github.com/senyu-up/toolbox/tool/wework/qwrobot"
+)
+
+const alertInterval = time.Minute // 同一分组告警的最小间隔
+
+// RuleLimiter 按 config.Limiter 的规则限流, 供 fiber、gin 的限流中间件使用
+// LimiterTypeLocal 使用进程内令牌桶, LimiterTypeDistributed 使用 redis 令牌桶, 可信子网内的 ip 不限流
+type RuleLimiter struct {
+	conf  config.Limiter
+	trust []*net.IPNet
+	rules []*rule
+
+	lock    sync.Mutex
+	alerted map[string]time.Time // 分组最近一次告警的时间
+}
+
+type rule struct {
+	conf   config.LimiterRule
+	groups []*group
+}
+
+type group struct {
+	name    string
+	uris    []string
+	limiter Limiter
+}
+
+// Decision 一次检查的结果
+type Decision struct {
+	Result
+	Matched bool   // 命中了规则, 未命中或可信 ip 时不限流
+	Group   string // 命中的分组
+	Source  string // 限流的资源, 如 uri、用户 id
+}
+
+// NewRuleLimiter 编译限流规则, Burst 格式为 次数/单位, 单位为 s、m、h、d, 如 100/m
+func NewRuleLimiter(conf config.Limiter) (*RuleLimiter, error) {
+	r := &RuleLimiter{conf: conf, alerted: make(map[string]time.Time)}
+	if r.conf.Category == "" {
+		r.conf.Category = config.LimiterTypeLocal
+	}
+	switch r.conf.Category {
+	case config.LimiterTypeLocal:
+	case config.LimiterTypeDistributed:
+		if conf.Redis == nil {
+			return nil, fmt.Errorf("traffic limit: %s distributed limiter requires Redis", conf.AppName)
+		}
+	default:
+		return nil, fmt.Errorf("traffic limit: %s unsupported limiter type %s", conf.AppName, conf.Category)
+	}
+
+	for _, mask := range conf.Trust.SubnetMask {
+		ipNet, err := parseSubnet(mask)
+		if err != nil {
+			return nil, err
+		}
+		r.trust = append(r.trust, ipNet)
+	}
+
+	for i, rc := range conf.Rules {
+		num, period, err := ParseBurst(rc.Burst)
+		if err != nil {
+			return nil, err
+		}
+		ru := &rule{conf: rc}
+		for _, g := range rc.Group {
+			if g.Name == "" {
+				return nil, fmt.Errorf("traffic limit: %s rule %d group name is required", conf.AppName, i)
+			}
+			l, err := r.newLimiter("limit:"+conf.AppName+":"+g.Name, num, period)
+			if err != nil {
+				return nil, err
+			}
+			ru.groups = append(ru.groups, &group{name: g.Name, uris: g.Uris, limiter: l})
+		}
+		r.rules = append(r.rules, ru)
+	}
+	return r, nil
+}
+
+// newLimiter 本地与分布式都使用令牌桶, 每个资源只记录令牌数与时间, 不随 num 增长; 本地限流不传 redis
+func (r *RuleLimiter) newLimiter(key string, num uint64, period uint32) (Limiter, error) {
+	conf := config.TokenBucketConf{Key: key, Capacity: num, NumPerPeriod: num, Period: period}
+	if r.conf.Category == config.LimiterTypeDistributed {
+		conf.Redis = r.conf.Redis
+	}
+	return NewTokenBucket(conf)
+}
+
+// ParseBurst 解析 100/m 格式的频率, 返回次数与周期(秒), 省略单位时为每秒
+func ParseBurst(burst string) (num uint64, period uint32, err error) {
+	items := strings.SplitN(strings.TrimSpace(burst), "/", 2)
+	if num, err = strconv.ParseUint(strings.TrimSpace(items[0]), 10, 64); err != nil || num == 0 {
+		return 0, 0, fmt.Errorf("traffic limit: invalid burst %q", burst)
+	}
+	period = 1
+	if len(items) == 2 {
+		switch strings.ToLower(strings.TrimSpace(items[1])) {
+		case "s":
+		case "m":
+			period = 60
+		case "h":
+			period = 3600
+		case "d":
+			period = 86400
+		default:
+			return 0, 0, fmt.Errorf("traffic limit: invalid burst %q", burst)
+		}
+	}
+	return num, period, nil
+}
+
+// parseSubnet 支持 CIDR 与单个 ip
+func parseSubnet(mask string) (*net.IPNet, error) {
+	if strings.Contains(mask, "/") {
+		_, ipNet, err := net.ParseCIDR(mask)
+		if err != nil {
+			return nil, fmt.Errorf("traffic limit: invalid trust subnet %q", mask)
+		}
+		return ipNet, nil
+	}
+	ip := net.ParseIP(mask)
+	if ip == nil {
+		return nil, fmt.Errorf("traffic limit: invalid trust subnet %q", mask)
+	}
+	bits := 8 * net.IPv6len
+	if ip4 := ip.To4(); ip4 != nil {
+		ip, bits = ip4, 8*net.IPv4len
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
+
+// Trusted ip 是否在可信子网内
+func (r *RuleLimiter) Trusted(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, ipNet := range r.trust {
+		if ipNet.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check 检查一个请求, 依次检查命中的每条规则, 任一规则超限即拒绝
+// input 为框架的请求上下文, 配置了 Taker 时传给 Taker; 否则通过 take 按 SourceKey 获取资源,
+// SourceKey 为空时资源为 path, 获取不到时使用 ip
+func (r *RuleLimiter) Check(ctx context.Context, input interface{}, path, ip string, take func(key string) string) Decision {
+	var d Decision
+	if r.Trusted(ip) {
+		return d
+	}
+	for _, ru := range r.rules {
+		g := ru.match(path)
+		if g == nil {
+			continue
+		}
+		source := path
+		if key := ru.conf.SourceKey; key != "" {
+			if r.conf.Taker != nil {
+				source = r.conf.Taker(input, key)
+			} else if take != nil {
+				source = take(key)
+			}
+			if source == "" {
+				source = ip
+			}
+		}
+		res := g.limiter.Take(source, 1)
+		if !d.Matched || !res.Allowed || res.Remaining < d.Remaining {
+			d = Decision{Result: res, Matched: true, Group: g.name, Source: source}
+		}
+		if !res.Allowed {
+			r.breach(ctx, ru.conf.Controller, d, path, ip)
+			break
+		}
+	}
+	return d
+}
+
+// match uri 以 * 结尾时按前缀匹配
+func (ru *rule) match(path string) *group {
+	for _, g := range ru.groups {
+		for _, uri := range g.uris {
+			if uri == path || strings.HasSuffix(uri, "*") && strings.HasPrefix(path, strings.TrimSuffix(uri, "*")) {
+				return g
+			}
+		}
+	}
+	return nil
+}
+
+// breach 超限时按 Controller 输出日志或发送机器人告警, 告警按分组每分钟最多 1 次
+func (r *RuleLimiter) breach(ctx context.Context, controller config.LimiterController, d Decision, path, ip string) {
+	if controller == config.LimiterControllerAlert {
+		if robot := qwrobot.Get(); robot != nil && r.shouldAlert(d.Group) {
+			robot.Warn(qwrobot.Message{
+				Title: "限流告警 " + r.conf.AppName,
+				Content: fmt.Sprintf("分组: %s\n资源: %s\n路由: %s\nip: %s\n上限: %d",
+					d.Group, d.Source, path, ip, d.Limit),
+			})
+		}
+	}
+	logger.GetLogger().Warnw(ctx, "traffic limit exceeded", "app", r.conf.AppName, "group", d.Group,
+		"source", d.Source, "path", path, "ip", ip, "limit", d.Limit)
+}
+
+func (r *RuleLimiter) shouldAlert(group string) bool {
+	now := time.Now()
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if now.Sub(r.alerted[group]) < alertInterval {
+		return false
+	}
+	r.alerted[group] = now
+	return true
+}
+
+// Headers 限流响应头, 未命中规则时为空
+// RateLimit-Reset、Retry-After 单位为秒
+func (d Decision) Headers() map[string]string {
+	if !d.Matched {
+		return nil
+	}
+	h := map[string]string{
+		"RateLimit-Limit":     strconv.FormatInt(d.Limit, 10),
+		"RateLimit-Remaining": strconv.FormatInt(d.Remaining, 10),
+		"RateLimit-Reset":     strconv.FormatInt(seconds(d.ResetAfter), 10),
+	}
+	if !d.Allowed {
+		h["Retry-After"] = strconv.FormatInt(seconds(d.RetryAfter), 10)
+	}
+	return h
+}
+
+// seconds 向上取整
+func seconds(d time.Duration) int64 {
+	if d <= 0 {
+		return 0
+	}
+	return int64((d + time.Second - 1) / time.Second)
+}
@@ -0,0 +1,147 @@
+package traffic_limit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/senyu-up/toolbox/tool/config"
+)
+
+func TestParseBurst(t *testing.T) {
+	tests := []struct {
+		burst  string
+		num    uint64
+		period uint32
+		err    bool
+	}{
+		{burst: "100/m", num: 100, period: 60},
+		{burst: "10/S", num: 10, period: 1},
+		{burst: " 5 / h ", num: 5, period: 3600},
+		{burst: "1/d", num: 1, period: 86400},
+		{burst: "20", num: 20, period: 1},
+		{burst: "", err: true},
+		{burst: "0/s", err: true},
+		{burst: "10/w", err: true},
+	}
+	for _, tt := range tests {
+		num, period, err := ParseBurst(tt.burst)
+		if (err != nil) != tt.err || num != tt.num || period != tt.period {
+			t.Errorf("ParseBurst(%q) = %d, %d, %v", tt.burst, num, period, err)
+		}
+	}
+}
+
+func TestRuleLimiter(t *testing.T) {
+	_, rdb := newRedis(t)
+	for _, category := range []config.LimiterType{config.LimiterTypeLocal, config.LimiterTypeDistributed} {
+		rl, err := NewRuleLimiter(config.Limiter{
+			AppName:  "app_" + string(category),
+			Trust:    config.Trust{SubnetMask: []string{"10.0.0.0/8", "127.0.0.1"}},
+			Category: category,
+			Redis:    rdb,
+			Rules: []config.LimiterRule{
+				{
+					Group: []config.LimiterGroup{{Name: "user", Uris: []string{"/user/login", "/user/sms/*"}}},
+					Burst: "2/m",
+				},
+				{
+					Group:     []config.LimiterGroup{{Name: "order", Uris: []string{"/order/*"}}},
+					Burst:     "1/s",
+					SourceKey: "uid",
+				},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx := context.TODO()
+		take := func(key string) string { return "7" }
+
+		if d := rl.Check(ctx, nil, "/other", "1.1.1.1", take); d.Matched || d.Headers() != nil {
+			t.Errorf("%s: unmatched path = %+v", category, d)
+		}
+		// 默认按 uri 限流
+		for i := 0; i < 2; i++ {
+			if d := rl.Check(ctx, nil, "/user/sms/send", "1.1.1.1", take); !d.Allowed || d.Group != "user" || d.Source != "/user/sms/send" {
+				t.Fatalf("%s: check %d = %+v", category, i, d)
+			}
+		}
+		d := rl.Check(ctx, nil, "/user/sms/send", "1.1.1.2", take)
+		if !d.Matched || d.Allowed {
+			t.Fatalf("%s: third check = %+v, should be limited", category, d)
+		}
+		h := d.Headers()
+		if h["RateLimit-Limit"] != "2" || h["RateLimit-Remaining"] != "0" || h["Retry-After"] == "" || h["Retry-After"] == "0" {
+			t.Errorf("%s: headers = %v", category, h)
+		}
+		if d = rl.Check(ctx, nil, "/user/login", "1.1.1.1", take); !d.Allowed {
+			t.Errorf("%s: other uri should not be limited, %+v", category, d)
+		}
+		// 可信子网不限流
+		if d = rl.Check(ctx, nil, "/user/sms/send", "10.1.2.3", take); d.Matched {
+			t.Errorf("%s: trusted ip = %+v", category, d)
+		}
+
+		// 按 SourceKey 限流
+		if d = rl.Check(ctx, nil, "/order/create", "1.1.1.1", take); !d.Allowed || d.Source != "7" {
+			t.Fatalf("%s: order check = %+v", category, d)
+		}
+		if d = rl.Check(ctx, nil, "/order/pay", "1.1.1.2", take); d.Allowed {
+			t.Errorf("%s: same uid should be limited, %+v", category, d)
+		}
+		if d = rl.Check(ctx, nil, "/order/pay", "1.1.1.2", func(string) string { return "" }); !d.Allowed || d.Source != "1.1.1.2" {
+			t.Errorf("%s: empty source should use ip, %+v", category, d)
+		}
+	}
+}
+
+func TestRuleLimiter_Taker(t *testing.T) {
+	rl, err := NewRuleLimiter(config.Limiter{
+		AppName: "taker",
+		Rules: []config.LimiterRule{{
+			Group:      []config.LimiterGroup{{Name: "api", Uris: []string{"*"}}},
+			Burst:      "1/s",
+			SourceKey:  "token",
+			Controller: config.LimiterControllerAlert,
+		}},
+		Taker: func(input interface{}, key string) string {
+			return input.(map[string]string)[key]
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.TODO()
+	if d := rl.Check(ctx, map[string]string{"token": "a"}, "/x", "1.1.1.1", nil); !d.Allowed || d.Source != "a" {
+		t.Errorf("check = %+v", d)
+	}
+	if d := rl.Check(ctx, map[string]string{"token": "a"}, "/y", "1.1.1.1", nil); d.Allowed {
+		t.Errorf("check = %+v, should be limited", d)
+	}
+	if d := rl.Check(ctx, map[string]string{"token": "b"}, "/y", "1.1.1.1", nil); !d.Allowed {
+		t.Errorf("check = %+v", d)
+	}
+	if !rl.shouldAlert("g") || rl.shouldAlert("g") {
+		t.Error("alert should be sent once per interval")
+	}
+	rl.alerted["g"] = time.Now().Add(-alertInterval)
+	if !rl.shouldAlert("g") {
+		t.Error("alert should be sent after interval")
+	}
+}
+
+func TestNewRuleLimiter(t *testing.T) {
+	invalid := []config.Limiter{
+		{Category: config.LimiterTypeDistributed},
+		{Category: config.LimiterTypeAli},
+		{Trust: config.Trust{SubnetMask: []string{"10.0.0.0/33"}}},
+		{Rules: []config.LimiterRule{{Burst: "1/x"}}},
+		{Rules: []config.LimiterRule{{Burst: "1/s", Group: []config.LimiterGroup{{Uris: []string{"/"}}}}}},
+	}
+	for _, conf := range invalid {
+		if _, err := NewRuleLimiter(conf); err == nil {
+			t.Errorf("NewRuleLimiter(%+v) should fail", conf)
+		}
+	}
+}
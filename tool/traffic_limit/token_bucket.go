@@ -0,0 +1,123 @@
+package traffic_limit
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/senyu-up/toolbox/tool/config"
+)
+
+// tokenBucketScript 令牌桶, 按时间差补充令牌, 令牌足够时扣减
+// KEYS[1] hash{tokens, ts}; ARGV: 容量, 每毫秒产生的令牌数, 当前毫秒时间戳, 申请数, 过期时间(毫秒)
+var tokenBucketScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local n = tonumber(ARGV[4])
+local ttl = tonumber(ARGV[5])
+
+local state = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+	tokens = capacity
+	ts = now
+end
+-- 多个实例时钟不一致时, 时间不回退
+if now > ts then
+	tokens = math.min(capacity, tokens + (now - ts) * rate)
+	ts = now
+end
+
+local allowed = 0
+local retry = 0
+if tokens >= n then
+	tokens = tokens - n
+	allowed = 1
+else
+	retry = math.ceil((n - tokens) / rate)
+end
+redis.call("HMSET", KEYS[1], "tokens", tostring(tokens), "ts", ts)
+redis.call("PEXPIRE", KEYS[1], ttl)
+return {allowed, math.floor(tokens), retry, math.ceil((capacity - tokens) / rate)}
+`)
+
+// NewTokenBucket 令牌桶限流, 桶内最多 Capacity 个令牌, 每个周期产生 NumPerPeriod 个, 允许突发
+func NewTokenBucket(conf config.TokenBucketConf) (Limiter, error) {
+	if conf.Key == "" {
+		return nil, fmt.Errorf("traffic limit: token bucket Key is required")
+	}
+	if conf.Capacity == 0 || conf.NumPerPeriod == 0 {
+		return nil, fmt.Errorf("traffic limit: token bucket %s Capacity and NumPerPeriod must be positive", conf.Key)
+	}
+	alg := &tokenBucket{
+		capacity: float64(conf.Capacity),
+		rate:     float64(conf.NumPerPeriod) / float64(period(conf.Period)/time.Millisecond),
+	}
+	return newLimiter(conf.Key, conf.Redis, alg), nil
+}
+
+type tokenBucket struct {
+	capacity float64
+	rate     float64 // 每毫秒产生的令牌数
+}
+
+func (t *tokenBucket) limit() int64 {
+	return int64(t.capacity)
+}
+
+// ttl 填满桶所需时间的 2 倍, 之后 key 过期等同于满桶
+func (t *tokenBucket) ttl() int64 {
+	return int64(math.Ceil(t.capacity/t.rate))*2 + 1000
+}
+
+func (t *tokenBucket) eval(rdb redis.UniversalClient, key string, now time.Time, n int64) (Result, error) {
+	v, err := tokenBucketScript.Run(rdb, []string{key},
+		formatFloat(t.capacity), formatFloat(t.rate), ms(now), n, t.ttl()).Result()
+	if err != nil {
+		return Result{}, err
+	}
+	return parseResult(t.limit(), v)
+}
+
+func (t *tokenBucket) newBucket(now time.Time) bucket {
+	return &localTokenBucket{tokenBucket: t, tokens: t.capacity, ts: ms(now)}
+}
+
+type localTokenBucket struct {
+	*tokenBucket
+	tokens float64
+	ts     int64
+}
+
+func (b *localTokenBucket) refill(now int64) {
+	if now > b.ts {
+		b.tokens = math.Min(b.capacity, b.tokens+float64(now-b.ts)*b.rate)
+		b.ts = now
+	}
+}
+
+func (b *localTokenBucket) take(now time.Time, n int64) Result {
+	b.refill(ms(now))
+	r := Result{Limit: b.limit()}
+	if b.tokens >= float64(n) {
+		b.tokens -= float64(n)
+		r.Allowed = true
+	} else {
+		r.RetryAfter = ceilMs((float64(n) - b.tokens) / b.rate)
+	}
+	r.Remaining = int64(b.tokens)
+	r.ResetAfter = ceilMs((b.capacity - b.tokens) / b.rate)
+	return r
+}
+
+func (b *localTokenBucket) idle(now time.Time) bool {
+	b.refill(ms(now))
+	return b.tokens >= b.capacity
+}
+
+func ceilMs(f float64) time.Duration {
+	return time.Duration(math.Ceil(f)) * time.Millisecond
+}
@@ -0,0 +1,218 @@
+package traffic_limit
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/senyu-up/toolbox/tool/config"
+)
+
+// fixedWindowScript 固定窗口计数, key 中带有窗口序号, 窗口结束后过期
+// KEYS[1] 当前窗口的计数; ARGV: 周期内允许的请求数, 申请数, 距窗口结束的毫秒数
+var fixedWindowScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local n = tonumber(ARGV[2])
+local reset = tonumber(ARGV[3])
+
+local count = tonumber(redis.call("GET", KEYS[1]) or "0")
+if count + n > limit then
+	return {0, limit - count, reset, reset}
+end
+count = redis.call("INCRBY", KEYS[1], n)
+if count == n then
+	redis.call("PEXPIRE", KEYS[1], reset)
+end
+return {1, limit - count, 0, reset}
+`)
+
+// slidingWindowScript 滑动窗口日志, zset 记录周期内每个请求的时间
+// KEYS[1] zset; ARGV: 周期内允许的请求数, 周期(毫秒), 当前毫秒时间戳, 申请数, 请求 id 前缀
+var slidingWindowScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local n = tonumber(ARGV[4])
+
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", now - period)
+local count = redis.call("ZCARD", KEYS[1])
+if count + n <= limit then
+	for i = 1, n do
+		redis.call("ZADD", KEYS[1], now, ARGV[5] .. ":" .. i)
+	end
+	redis.call("PEXPIRE", KEYS[1], period)
+	return {1, limit - count - n, 0, period}
+end
+
+-- 需要等最早的 count + n - limit 个请求移出窗口
+local idx = count + n - limit - 1
+local wait = redis.call("ZRANGE", KEYS[1], idx, idx, "WITHSCORES")
+local last = redis.call("ZRANGE", KEYS[1], -1, -1, "WITHSCORES")
+return {0, limit - count, tonumber(wait[2]) + period - now, tonumber(last[2]) + period - now}
+`)
+
+// NewFixedWindow 固定窗口限流, 每个周期最多 NumPerPeriod 个请求, 窗口切换时可能出现 2 倍的突发
+func NewFixedWindow(conf config.StickyWindowConf) (Limiter, error) {
+	if conf.Key == "" {
+		return nil, fmt.Errorf("traffic limit: fixed window Key is required")
+	}
+	if conf.NumPerPeriod == 0 {
+		return nil, fmt.Errorf("traffic limit: fixed window %s NumPerPeriod must be positive", conf.Key)
+	}
+	alg := &fixedWindow{max: int64(conf.NumPerPeriod), period: int64(period(conf.Period) / time.Millisecond)}
+	return newLimiter(conf.Key, conf.Redis, alg), nil
+}
+
+type fixedWindow struct {
+	max    int64
+	period int64 // 毫秒
+}
+
+func (f *fixedWindow) limit() int64 {
+	return f.max
+}
+
+// window 当前窗口的序号与距窗口结束的毫秒数
+func (f *fixedWindow) window(now time.Time) (idx, reset int64) {
+	t := ms(now)
+	idx = t / f.period
+	return idx, (idx+1)*f.period - t
+}
+
+func (f *fixedWindow) eval(rdb redis.UniversalClient, key string, now time.Time, n int64) (Result, error) {
+	idx, reset := f.window(now)
+	v, err := fixedWindowScript.Run(rdb, []string{key + ":" + strconv.FormatInt(idx, 10)}, f.max, n, reset).Result()
+	if err != nil {
+		return Result{}, err
+	}
+	return parseResult(f.max, v)
+}
+
+func (f *fixedWindow) newBucket(now time.Time) bucket {
+	return &localFixedWindow{fixedWindow: f}
+}
+
+type localFixedWindow struct {
+	*fixedWindow
+	idx   int64
+	count int64
+}
+
+func (w *localFixedWindow) take(now time.Time, n int64) Result {
+	idx, reset := w.window(now)
+	if idx != w.idx {
+		w.idx, w.count = idx, 0
+	}
+	r := Result{Limit: w.max, ResetAfter: time.Duration(reset) * time.Millisecond}
+	if w.count+n > w.max {
+		r.RetryAfter = r.ResetAfter
+	} else {
+		w.count += n
+		r.Allowed = true
+	}
+	r.Remaining = w.max - w.count
+	return r
+}
+
+func (w *localFixedWindow) idle(now time.Time) bool {
+	idx, _ := w.window(now)
+	return idx != w.idx || w.count == 0
+}
+
+// NewSlidingWindow 滑动窗口限流, 任意 Period 时长内最多 NumPerPeriod 个请求,
+// 精确但需要记录每个请求的时间, NumPerPeriod 较大时占用较多内存
+func NewSlidingWindow(conf config.SlidingWindowConf) (Limiter, error) {
+	if conf.Key == "" {
+		return nil, fmt.Errorf("traffic limit: sliding window Key is required")
+	}
+	if conf.NumPerPeriod == 0 {
+		return nil, fmt.Errorf("traffic limit: sliding window %s NumPerPeriod must be positive", conf.Key)
+	}
+	alg := &slidingWindow{
+		max:    int64(conf.NumPerPeriod),
+		period: int64(period(conf.Period) / time.Millisecond),
+		id:     instanceId(),
+	}
+	return newLimiter(conf.Key, conf.Redis, alg), nil
+}
+
+type slidingWindow struct {
+	max    int64
+	period int64  // 毫秒
+	id     string // 实例 id, 与 seq 组成 zset 中唯一的 member
+	seq    uint64
+}
+
+func (s *slidingWindow) limit() int64 {
+	return s.max
+}
+
+func (s *slidingWindow) eval(rdb redis.UniversalClient, key string, now time.Time, n int64) (Result, error) {
+	member := s.id + ":" + strconv.FormatUint(atomic.AddUint64(&s.seq, 1), 10)
+	v, err := slidingWindowScript.Run(rdb, []string{key}, s.max, s.period, ms(now), n, member).Result()
+	if err != nil {
+		return Result{}, err
+	}
+	return parseResult(s.max, v)
+}
+
+func (s *slidingWindow) newBucket(now time.Time) bucket {
+	return &localSlidingWindow{slidingWindow: s}
+}
+
+// localSlidingWindow 按时间顺序记录周期内每个请求的毫秒时间
+type localSlidingWindow struct {
+	*slidingWindow
+	log []int64
+}
+
+func (w *localSlidingWindow) evict(now int64) {
+	i := 0
+	for i < len(w.log) && w.log[i] <= now-w.period {
+		i++
+	}
+	if i == len(w.log) {
+		// 全部移出窗口时释放底层数组, 空闲的 key 不占用内存
+		w.log = nil
+	} else if i > 0 {
+		// 原地前移, 底层数组不超过 max 个元素
+		w.log = w.log[:copy(w.log, w.log[i:])]
+	}
+}
+
+func (w *localSlidingWindow) take(now time.Time, n int64) Result {
+	t := ms(now)
+	w.evict(t)
+	r := Result{Limit: w.max}
+	count := int64(len(w.log))
+	if count+n <= w.max {
+		for i := int64(0); i < n; i++ {
+			w.log = append(w.log, t)
+		}
+		r.Allowed = true
+		r.Remaining = w.max - count - n
+		r.ResetAfter = time.Duration(w.period) * time.Millisecond
+		return r
+	}
+	r.Remaining = w.max - count
+	r.RetryAfter = time.Duration(w.log[count+n-w.max-1]+w.period-t) * time.Millisecond
+	r.ResetAfter = time.Duration(w.log[len(w.log)-1]+w.period-t) * time.Millisecond
+	return r
+}
+
+func (w *localSlidingWindow) idle(now time.Time) bool {
+	w.evict(ms(now))
+	return len(w.log) == 0
+}
+
+func instanceId() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
@@ -28,8 +28,16 @@ var appKeyPattern = regexp.MustCompile(`^[a-zA-Z0-9]+:[a-zA-Z0-9]+:\d{10}$`)
 var xhOrderPattern = regexp.MustCompile(`XH_[a-zA-Z0-9]+`)
 
 // NewSUValidator
-// @description new一个validator, 带有 appKey、order 校验规则
+// @description new一个validator, 带有 appKey、order 校验规则, 并替换 StructValidator 使用的 validator
 func NewSUValidator() *validator.Validate {
+	v := NewValidator()
+	validate = v
+	return v
+}
+
+// NewValidator
+// @description new一个validator, 带有 appKey、order 校验规则, 不影响 StructValidator
+func NewValidator() *validator.Validate {
 	v := validator.New()
 	_ = v.RegisterValidation("appKey", func(fl validator.FieldLevel) bool {
 
@@ -48,8 +56,6 @@ func NewSUValidator() *validator.Validate {
 		}
 		return false
 	})
-
-	validate = v
 	return v
 }
 